@@ -12,4 +12,5 @@ require (
 	github.com/goki/gi v1.2.2
 	github.com/goki/ki v1.1.1
 	github.com/goki/mat32 v1.0.3
+	gonum.org/v1/plot v0.7.0
 )
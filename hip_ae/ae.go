@@ -0,0 +1,155 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/leabra/leabra"
+	"github.com/xiaonanl/sleep_model/hipbench"
+)
+
+// RPSchedule is retrieval practice through the auto-encoder: ECout recall is
+// copied into Autoin during a longer 3rd quarter, and the auto-encoder output
+// is clamped back onto ECout for the plus phase
+func RPSchedule() *hipbench.PhaseSchedule {
+	ps := hipbench.RPSchedule()
+	for nm, lrn := range AELearn(false) {
+		ps.Learn[nm] = lrn
+	}
+	ps.Abs["AutoinToAutohid"] = 1
+	ps.Rel = nil
+	ps.Quarters[1] = hipbench.Quarter{Cycles: 25}
+	ps.Quarters[2] = hipbench.Quarter{Cycles: 100,
+		Abs:    map[string]float32{"ECinToCA1": 0, "CA3ToCA1": 1},
+		Clamps: []hipbench.Clamp{{From: "ECout", To: "Autoin", Cyc: 26}, {From: "ECout", To: "Output"}, {From: "Auto", To: "ECout"}},
+		Stats:  []hipbench.StatFunc{(*hipbench.Sim).MemStats}}
+	return ps
+}
+
+// RPFullSchedule is retrieval practice through the auto-encoder with full
+// feedback: ECout and Output are clamped to the target pattern for the plus phase
+func RPFullSchedule() *hipbench.PhaseSchedule {
+	ps := RPSchedule()
+	ps.Quarters[2].Clamps = []hipbench.Clamp{{From: "ECout", To: "Autoin", Cyc: 26}, {To: "Output", Col: "ECout"}, {To: "ECout", Col: "ECout"}}
+	return ps
+}
+
+// AESchedule trains the auto-encoder alone, with the rest of the network off
+func AESchedule() *hipbench.PhaseSchedule {
+	ps := &hipbench.PhaseSchedule{
+		Learn: AELearn(true),
+		Off:   map[string]bool{"CA1": true, "CA3": true, "DG": true, "ECin": true, "Cortex": true, "Autohid": false, "Auto": false, "ECout": false},
+	}
+	for nm := range hipbench.HipLearn(false) {
+		ps.Learn[nm] = false
+	}
+	ps.Quarters[2].Stats = []hipbench.StatFunc{func(ss *hipbench.Sim, train bool) { ss.MemStats(true) }}
+	return ps
+}
+
+// AELearn returns Learn flags for the auto-encoder projections
+func AELearn(on bool) map[string]bool {
+	return map[string]bool{"AutoinToAutohid": on, "AutohidToAuto": on, "AutoToAutohid": on}
+}
+
+// ApplyInputsAE applies the auto-encoder's Autoin and Auto patterns from given environment
+func ApplyInputsAE(ss *hipbench.Sim, en env.Env) {
+	ss.ApplyLays(en, []string{"Autoin", "Auto"})
+}
+
+// AETrainTrial runs one trial of auto-encoder training using TrainEnv
+func AETrainTrial(ss *hipbench.Sim) {
+	if ss.NeedsNewRun {
+		ss.NewRun()
+	}
+	autoin := ss.Net.LayerByName("Autoin").(leabra.LeabraLayer).AsLeabra()
+
+	autoin.SetType(emer.Input)
+	autoin.UpdateExtFlags()
+	ss.StepTrain() // the Env encapsulates and manages all counter state
+
+	// Key to query counters FIRST because current state is in NEXT epoch
+	// if epoch counter has changed
+	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	if chg {
+		ss.LogTrnEpc(ss.TrnEpcLog)
+		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
+			ss.UpdateView(true)
+		}
+		if epc >= ss.AETrainEpcs { // done with training..
+			ss.StopNow = true
+			return
+		}
+	}
+
+	ApplyInputsAE(ss, &ss.TrainEnv)
+	ss.AlphaCycPhase("AE", true) // train
+	ss.TrialStats(true)          // accumulate
+	ss.LogTrnTrl(ss.TrnTrlLog)
+}
+
+// AERun trains the auto-encoder on TrainNoise for AETrainEpcs epochs
+func AERun(ss *hipbench.Sim) {
+	ss.SetTrainPats(ss.TrainNoise, ss.TrainEnv.Run.Cur)
+	ss.TrainEnv.Trial.Cur = -1
+	ss.StopNow = false
+	for {
+		AETrainTrial(ss)
+		if ss.StopNow {
+			break
+		}
+	}
+	ss.Stopped()
+}
+
+// TestTrialAE runs one trial of auto-encoder testing using TestEnv
+func TestTrialAE(ss *hipbench.Sim, returnOnChg bool) {
+	ss.TestEnv.Step()
+	ecout := ss.Net.LayerByName("ECout").(leabra.LeabraLayer).AsLeabra()
+
+	ecout.SetType(emer.Input)
+	ecout.UpdateExtFlags()
+	// Query counters FIRST
+	_, _, chg := ss.TestEnv.Counter(env.Epoch)
+	if chg {
+		if ss.ViewOn && ss.TestUpdt > leabra.AlphaCycle {
+			ss.UpdateView(false)
+		}
+		if returnOnChg {
+			return
+		}
+	}
+
+	ApplyInputsAE(ss, &ss.TestEnv)
+	ss.AlphaCycPhase("AE", false) // !train
+	ss.TrialStats(false)          // !accumulate
+	ss.LogTstTrl(ss.TstTrlLog)
+
+	ecout.SetType(emer.Target) // back to a target for the study and RP trials
+	ecout.UpdateExtFlags()
+}
+
+// TestAE runs all of the TrainNoise patterns through the auto-encoder
+func TestAE(ss *hipbench.Sim) {
+	ss.TestEnv.Table = etable.NewIdxView(ss.TrainNoise)
+	ss.TestEnv.Init(ss.TrainEnv.Run.Cur)
+	for {
+		TestTrialAE(ss, true) // return on chg
+		_, _, chg := ss.TestEnv.Counter(env.Epoch)
+		if chg || ss.StopNow {
+			break
+		}
+	}
+	ss.LogTstEpc(ss.TstEpcLog)
+}
+
+// RunTestAE runs TestAE, has stop running = false at end -- for gui
+func RunTestAE(ss *hipbench.Sim) {
+	ss.StopNow = false
+	TestAE(ss)
+	ss.Stopped()
+}
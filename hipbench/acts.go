@@ -0,0 +1,129 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Activity log
+//
+// With Acts.On, the ActLog records the whole activity pattern of each of the
+// Acts.Lays (by default DG, CA3 and CA1) on each item of each study, rp,
+// restudy and test stage of a protocol, one tensor column per layer and
+// variable, for analyses of how the representations of the items change.
+
+// ActFormats are the file extensions of the formats the ActLog can be saved in
+var ActFormats = map[string]string{
+	"tsv": ".tsv",
+	"bin": ".gob.gz",
+}
+
+// ActStageName returns the name of the stage in the ActLog: its Save name,
+// or test, for a test stage, and its checkpoint name (by default its Do) for
+// study, rp and restudy, so that the third study of the restudy condition
+// (see ForkStages) is restudy -- empty for the stages whose activity is not
+// recorded
+func (st *Stage) ActStageName() string {
+	switch st.Do {
+	case "study", "rp", "restudy":
+		return st.ckptName()
+	case "test":
+		if st.Save != "" {
+			return st.Save
+		}
+		return "test"
+	}
+	return ""
+}
+
+// LogAct adds a row for the current trial of environment en to the ActLog
+// table, with the activity of each of the Acts.RecLays, if Acts.Recording and
+// a protocol stage that is recorded is running.  testNm is the test set of a
+// test trial.
+func (ss *Sim) LogAct(dt *etable.Table, en *env.FixedTable, testNm string) {
+	if !ss.Acts.Recording() || ss.ActStage == "" {
+		return
+	}
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellString("Params", row, ss.RunName())
+	dt.SetCellString("Stage", row, ss.ActStage)
+	dt.SetCellString("TestNm", row, testNm)
+	dt.SetCellFloat("Epoch", row, float64(en.Epoch.Cur))
+	dt.SetCellFloat("Trial", row, float64(en.Trial.Cur))
+	dt.SetCellString("TrialName", row, en.TrialName.Cur)
+	dt.SetCellFloat("Item", row, float64(en.Row()))
+	vals := ss.ValsTsr("Act")
+	for _, lnm := range ss.Acts.RecLays() {
+		ly, ok := ss.Net.LayerByName(lnm).(leabra.LeabraLayer)
+		if !ok {
+			continue
+		}
+		for _, vnm := range ss.Acts.RecVars() {
+			ly.AsLeabra().UnitValsTensor(vals, vnm)
+			dt.SetCellTensor(lnm+" "+vnm, row, vals)
+		}
+	}
+}
+
+// ConfigActLog configures the ActLog for the current Acts and layer sizes,
+// with no rows
+func (ss *Sim) ConfigActLog(dt *etable.Table) {
+	dt.SetMetaData("name", "ActLog")
+	dt.SetMetaData("desc", "Record of the layer activity patterns per item and stage")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"TestNm", etensor.STRING, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Trial", etensor.INT64, nil, nil},
+		{"TrialName", etensor.STRING, nil, nil},
+		{"Item", etensor.INT64, nil, nil},
+	}
+	for _, lnm := range ss.Acts.RecLays() {
+		ly, ok := ss.Net.LayerByName(lnm).(leabra.LeabraLayer)
+		if !ok {
+			continue
+		}
+		shp := ly.AsLeabra().Shp
+		for _, vnm := range ss.Acts.RecVars() {
+			sch = append(sch, etable.Column{lnm + " " + vnm, etensor.FLOAT32, shp.Shp, shp.Nms})
+		}
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// ActFileName returns the file that the ActLog of the current run is saved
+// to: <net>_<runname>_run<run>_acts, with the extension of its Acts.Format
+func (ss *Sim) ActFileName() string {
+	fnm := ss.LogFileName(fmt.Sprintf("run%03d_acts", ss.TrainEnv.Run.Cur))
+	return strings.TrimSuffix(fnm, ".tsv") + ActFormats[ss.Acts.Format]
+}
+
+// SaveActLog saves the ActLog to the file fname, in the Acts.Format
+func (ss *Sim) SaveActLog(fname string) error {
+	switch ss.Acts.Format {
+	case "tsv":
+		return SaveCSV(ss.ActLog, fname)
+	case "bin":
+		return SaveTableBin(ss.ActLog, fname)
+	}
+	return fmt.Errorf("hipbench: unknown activity log format: %s", ss.Acts.Format)
+}
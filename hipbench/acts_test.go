@@ -0,0 +1,139 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/leabra/leabra"
+)
+
+func TestActStageName(t *testing.T) {
+	tests := []struct {
+		st   Stage
+		want string
+	}{
+		{Stage{Do: "study"}, "study"},
+		{Stage{Do: "study", Set: "AC"}, "study"},
+		{Stage{Do: "study", Ckpt: "restudy"}, "restudy"},
+		{Stage{Do: "rp", Feedback: "full"}, "rp"},
+		{Stage{Do: "restudy"}, "restudy"},
+		{Stage{Do: "test", Save: "test_full"}, "test_full"},
+		{Stage{Do: "test"}, "test"},
+		{Stage{Do: "init"}, ""},
+		{Stage{Do: "delay", N: 2}, ""},
+		{Stage{Do: "load", Ckpt: "study"}, ""},
+	}
+	for _, tt := range tests {
+		if nm := tt.st.ActStageName(); nm != tt.want {
+			t.Errorf("%+v: ActStageName = %q, want %q", tt.st, nm, tt.want)
+		}
+	}
+}
+
+// actProtocol has each of the stages whose activity is recorded
+var actProtocol = &Protocol{Name: "Acts", Stages: []Stage{
+	{Do: "init"},
+	{Do: "study"},
+	{Do: "rp"},
+	{Do: "restudy"},
+	{Do: "delay", Mode: "decay"},
+	{Do: "test", Set: "AB", Save: "final"},
+}}
+
+func TestActLog(t *testing.T) {
+	for _, format := range []string{"tsv", "bin"} {
+		t.Run(format, func(t *testing.T) {
+			ss := testSim(t)
+			ss.Tag = "T"
+			ss.OutDir = t.TempDir()
+			ss.Acts.On = true
+			ss.Acts.Format = format
+			ss.Init()
+			if err := ss.RunProtocol(actProtocol); err != nil {
+				t.Fatal(err)
+			}
+			dt := ss.ActLog
+			rows := map[string]int{}
+			for row := 0; row < dt.Rows; row++ {
+				rows[dt.CellString("Stage", row)]++
+			}
+			for _, stg := range []string{"study", "rp", "restudy", "final"} {
+				if rows[stg] == 0 {
+					t.Errorf("no rows for stage %s", stg)
+				}
+			}
+			if len(rows) != 4 {
+				t.Errorf("rows by stage = %v, want just study, rp, restudy and final", rows)
+			}
+			if rows["final"] != ss.TestAB.Rows {
+				t.Errorf("%d final rows, want one per AB item: %d", rows["final"], ss.TestAB.Rows)
+			}
+			for _, lnm := range ss.Acts.Lays {
+				ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+				for _, vnm := range ss.Acts.Vars {
+					cl := dt.ColByName(lnm + " " + vnm)
+					if cl == nil {
+						t.Fatalf("no column %s %s", lnm, vnm)
+					}
+					if shp := cl.Shapes()[1:]; !reflect.DeepEqual(shp, ly.Shp.Shp) {
+						t.Errorf("%s %s: cell shape %v, want the layer's %v", lnm, vnm, shp, ly.Shp.Shp)
+					}
+				}
+			}
+			var act []float64
+			dt.CellTensor("CA3 ActM", 0).Floats(&act)
+			sum := 0.0
+			for _, v := range act {
+				sum += v
+			}
+			if sum == 0 {
+				t.Errorf("no CA3 ActM activity in the first study trial")
+			}
+
+			fnm := ss.ActFileName()
+			if filepath.Dir(fnm) != ss.OutDir || filepath.Ext(fnm) != filepath.Ext(ActFormats[format]) {
+				t.Errorf("ActFileName = %s", fnm)
+			}
+			od := &etable.Table{}
+			var err error
+			if format == "tsv" {
+				err = OpenCSV(od, fnm)
+			} else {
+				err = OpenTableBin(od, fnm)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if od.Rows != dt.Rows || od.ColIdx("CA1 ActP") < 0 {
+				t.Fatalf("opened %d rows, want %d", od.Rows, dt.Rows)
+			}
+			var oact []float64
+			od.CellTensor("CA3 ActM", 0).Floats(&oact)
+			if format == "bin" && !reflect.DeepEqual(oact, act) {
+				t.Errorf("opened CA3 ActM differs from the saved one")
+			}
+		})
+	}
+}
+
+func TestActLogOff(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	if err := ss.RunProtocol(actProtocol); err != nil {
+		t.Fatal(err)
+	}
+	if ss.ActLog.Rows != 0 {
+		t.Errorf("%d ActLog rows without Acts.On", ss.ActLog.Rows)
+	}
+	ss.Acts.On = true
+	ss.TestAll() // outside of a protocol
+	if ss.ActLog.Rows != 0 {
+		t.Errorf("%d ActLog rows outside of a protocol", ss.ActLog.Rows)
+	}
+}
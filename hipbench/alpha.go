@@ -0,0 +1,229 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"log"
+
+	"github.com/emer/etable/etensor"
+	"github.com/emer/etable/metric"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// 	    Running the Network, starting bottom-up..
+
+// AlphaCyc runs one alpha-cycle (100 msec, 4 quarters) of study processing.
+// External inputs must have already been applied prior to calling,
+// using ApplyExt method on relevant layers (see TrainTrial, TestTrial).
+// If train is true, then learning DWt or WtFmDWt calls are made.
+// Handles netview updating within scope of AlphaCycle
+func (ss *Sim) AlphaCyc(train bool) {
+	ss.AlphaCycPhase("Study", train)
+}
+
+// AlphaCycPhase runs one alpha-cycle using the Model.Phases schedule of given name
+func (ss *Sim) AlphaCycPhase(name string, train bool) {
+	ps, ok := ss.Model.Phases[name]
+	if !ok {
+		log.Printf("hipbench: no phase schedule named: %s\n", name)
+		return
+	}
+	ss.RunPhases(ps, train)
+}
+
+// PrjnByName returns the projection of given name (Send + "To" + Recv), or nil if not found
+func (ss *Sim) PrjnByName(name string) *leabra.Prjn {
+	for _, ly := range ss.Net.Layers {
+		for _, pj := range *ly.RecvPrjns() {
+			if pj.Name() == name {
+				return pj.(leabra.LeabraPrjn).AsLeabra()
+			}
+		}
+	}
+	return nil
+}
+
+// SetPrjnScales sets the WtScale.Abs or .Rel of the named projections
+func (ss *Sim) SetPrjnScales(scales map[string]float32, abs bool) {
+	for nm, sc := range scales {
+		pj := ss.PrjnByName(nm)
+		if pj == nil {
+			continue // not all models have all projections
+		}
+		if abs {
+			pj.WtScale.Abs = sc
+		} else {
+			pj.WtScale.Rel = sc
+		}
+	}
+}
+
+// ApplyClamp applies the given clamp
+func (ss *Sim) ApplyClamp(cl *Clamp) {
+	to := ss.Net.LayerByName(cl.To).(leabra.LeabraLayer).AsLeabra()
+	if cl.From == "" {
+		if pat := ss.TrainEnv.State(cl.Col); pat != nil {
+			to.ApplyExt(pat)
+		}
+		return
+	}
+	from := ss.Net.LayerByName(cl.From).(leabra.LeabraLayer).AsLeabra()
+	if cl.Table == "" {
+		from.UnitVals(&ss.TmpVals, "Act")
+		to.ApplyExt1D32(ss.TmpVals)
+		return
+	}
+	act := &etensor.Float32{}
+	from.UnitValsTensor(act, "Act")
+	pats := ss.PatsByName(cl.Table).ColByName(cl.Col).(*etensor.Float32)
+	row, _ := metric.ClosestRow32(act, pats, metric.StdFunc32(metric.Euclidean))
+	to.ApplyExt4D(pats.SubSpace([]int{row}))
+}
+
+// RunPhases runs one alpha-cycle (100 msec, 4 quarters) according to given schedule.
+// External inputs must have already been applied prior to calling.
+// If train is true, then learning DWt or WtFmDWt calls are made.
+// Handles netview updating within scope of AlphaCycle
+func (ss *Sim) RunPhases(ps *PhaseSchedule, train bool) {
+	// ss.Win.PollEvents() // this can be used instead of running in a separate goroutine
+	viewUpdt := ss.TrainUpdt
+	if !train {
+		viewUpdt = ss.TestUpdt
+	}
+	// update prior weight changes at start, so any DWt values remain visible at end
+	// you might want to do this less frequently to achieve a mini-batch update
+	// in which case, move it out to the TrainTrial method where the relevant
+	// counters are being dealt with.
+	if train {
+		ss.Net.WtFmDWt()
+	}
+
+	for nm, lrn := range ps.Learn {
+		if pj := ss.PrjnByName(nm); pj != nil {
+			pj.Learn.Learn = lrn
+		}
+	}
+	for nm, off := range ps.Off {
+		ss.Net.LayerByName(nm).(leabra.LeabraLayer).AsLeabra().Off = off
+	}
+	ss.SetPrjnScales(ps.Abs, true)
+	ss.SetPrjnScales(ps.Rel, false)
+	if train {
+		ss.SetPrjnScales(ps.TrainRel, false)
+	}
+	if ps.Start != nil {
+		ps.Start(ss, train)
+	}
+
+	ca3FmDg := ss.PrjnByName("DGToCA3")
+	dgwtscale := ca3FmDg.WtScale.Rel
+	ss.SetMossy(ps.Mossy, dgwtscale)
+
+	types := ps.Types
+	if !train {
+		types = ps.TestTypes
+	}
+	for nm, typ := range types {
+		ly := ss.Net.LayerByName(nm).(leabra.LeabraLayer).AsLeabra()
+		ly.SetType(typ)
+		ly.UpdateExtFlags() // call this after updating type
+	}
+
+	ss.Net.AlphaCycInit()
+	ss.Time.AlphaCycStart()
+	for qtr := 0; qtr < 4; qtr++ {
+		qs := &ps.Quarters[qtr]
+		ncyc := qs.Cycles
+		if ncyc == 0 {
+			ncyc = ss.Time.CycPerQtr
+		}
+		for cyc := 0; cyc < ncyc; cyc++ {
+			ss.Net.Cycle(&ss.Time)
+			if !train {
+				ss.LogTstCyc(ss.TstCycLog, ss.Time.Cycle)
+			}
+			ss.LogCyc(ss.CycLog, train, qtr)
+			if train {
+				for ci := range qs.Clamps {
+					if qs.Clamps[ci].Cyc == cyc+1 {
+						ss.ApplyClamp(&qs.Clamps[ci])
+					}
+				}
+			}
+			ss.Time.CycleInc()
+			if ss.ViewOn {
+				switch viewUpdt {
+				case leabra.Cycle:
+					if cyc != ncyc-1 { // will be updated by quarter
+						ss.UpdateView(train)
+					}
+				case leabra.FastSpike:
+					if (cyc+1)%10 == 0 {
+						ss.UpdateView(train)
+					}
+				}
+			}
+		}
+		mossy := qs.Mossy
+		if !train {
+			mossy = qs.TestMossy
+		}
+		if len(qs.Abs) > 0 || mossy != MossyKeep {
+			ss.SetPrjnScales(qs.Abs, true)
+			ss.SetMossy(mossy, dgwtscale)
+			ss.Net.GScaleFmAvgAct() // update computed scaling factors
+			ss.Net.InitGInc()       // scaling params change, so need to recompute all netins
+		}
+		if train {
+			for ci := range qs.Clamps {
+				if qs.Clamps[ci].Cyc == 0 {
+					ss.ApplyClamp(&qs.Clamps[ci])
+				}
+			}
+		}
+		ss.Net.QuarterFinal(&ss.Time)
+		for _, sf := range qs.Stats {
+			sf(ss, train) // must come after QuarterFinal
+		}
+		ss.Time.QuarterInc()
+		if ss.ViewOn {
+			switch {
+			case viewUpdt <= leabra.Quarter:
+				ss.UpdateView(train)
+			case viewUpdt == leabra.Phase:
+				if qtr >= 2 {
+					ss.UpdateView(train)
+				}
+			}
+		}
+	}
+
+	ca3FmDg.WtScale.Rel = dgwtscale // restore
+	ss.SetPrjnScales(ps.EndAbs, true)
+
+	if train {
+		ss.Net.DWt()
+	}
+	if ss.ViewOn && viewUpdt == leabra.AlphaCycle {
+		ss.UpdateView(train)
+	}
+	if !train {
+		ss.UpdatePlot("TstCycLog") // make sure up-to-date at end
+	}
+}
+
+// SetMossy sets the DG -> CA3 WtScale.Rel relative to its full strength dgwtscale
+func (ss *Sim) SetMossy(ms Mossy, dgwtscale float32) {
+	ca3FmDg := ss.PrjnByName("DGToCA3")
+	switch ms {
+	case MossyFull:
+		ca3FmDg.WtScale.Rel = dgwtscale
+	case MossyDel:
+		ca3FmDg.WtScale.Rel = dgwtscale - ss.Hip.MossyDel
+	case MossyDelTest:
+		ca3FmDg.WtScale.Rel = dgwtscale - ss.Hip.MossyDelTest
+	}
+}
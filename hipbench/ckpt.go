@@ -0,0 +1,90 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Checkpoints
+
+// CkptStages are the protocol stages after which a checkpoint of the weights is saved
+var CkptStages = map[string]bool{
+	"pretrain": true,
+	"study":    true,
+	"rp":       true,
+	"restudy":  true,
+}
+
+// CkptFileName returns the file name of the named checkpoint of the current
+// run (subject), keyed by its run number and random seed, in CkptDir, or
+// else OutDir
+func (ss *Sim) CkptFileName(name string) string {
+	fnm := fmt.Sprintf("%s_%s_run%03d_seed%d_%s.wts.gz", ss.Net.Nm, ss.RunName(), ss.TrainEnv.Run.Cur, ss.RndSeed, name)
+	if ss.CkptDir == "" {
+		return ss.OutFile(fnm)
+	}
+	return filepath.Join(ss.CkptDir, fnm)
+}
+
+// WriteCkpt writes the weights of all the layers, including those that are
+// currently off, to w as JSON
+func (ss *Sim) WriteCkpt(w io.Writer) error {
+	off := make([]bool, len(ss.Net.Layers))
+	for li, lyi := range ss.Net.Layers {
+		ly := lyi.(leabra.LeabraLayer).AsLeabra()
+		off[li] = ly.Off
+		ly.Off = false // off layers are not written
+	}
+	err := ss.Net.WriteWtsJSON(w)
+	for li, lyi := range ss.Net.Layers {
+		lyi.(leabra.LeabraLayer).AsLeabra().Off = off[li]
+	}
+	return err
+}
+
+// SaveCkpt saves the weights to the named checkpoint of the current run, as
+// compressed JSON, and records it in Ckpts
+func (ss *Sim) SaveCkpt(name string) error {
+	fnm := ss.CkptFileName(name)
+	if ss.CkptDir != "" {
+		if err := os.MkdirAll(ss.CkptDir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := ss.SaveWtsFile(fnm); err != nil {
+		return err
+	}
+	ss.markCkpt(name)
+	return nil
+}
+
+// markCkpt records that the named checkpoint has been saved in this run
+func (ss *Sim) markCkpt(name string) {
+	if ss.Ckpts == nil {
+		ss.Ckpts = map[string]bool{}
+	}
+	ss.Ckpts[name] = true
+}
+
+// LoadCkpt loads the weights of the named checkpoint of the current run,
+// saved by SaveCkpt -- not a file of the same name left by another
+// invocation, in a shared CkptDir
+func (ss *Sim) LoadCkpt(name string) error {
+	if !ss.Ckpts[name] {
+		return fmt.Errorf("hipbench: no checkpoint %s saved in this run", name)
+	}
+	fnm := ss.CkptFileName(name)
+	if _, err := os.Stat(fnm); err != nil {
+		return fmt.Errorf("hipbench: no checkpoint %s for this run: %v", name, err)
+	}
+	return ss.OpenWtsFile(fnm)
+}
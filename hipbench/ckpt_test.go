@@ -0,0 +1,111 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// netWts returns the weights of the learning projections
+func netWts(ss *Sim) map[string][]float32 {
+	wts := map[string][]float32{}
+	for _, nm := range ss.Model.DecayPrjns {
+		pj := ss.PrjnByName(nm)
+		for si := range pj.Syns {
+			wts[nm] = append(wts[nm], pj.Syns[si].Wt)
+		}
+	}
+	return wts
+}
+
+// wtsDiff returns the largest difference between the weights
+func wtsDiff(a, b map[string][]float32) float64 {
+	d := 0.0
+	for nm, wa := range a {
+		for si, w := range wa {
+			d = math.Max(d, math.Abs(float64(w-b[nm][si])))
+		}
+	}
+	return d
+}
+
+func TestCkptRoundTrip(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	ss.TrainTable(ss.TrainAB)
+	if err := ss.SaveCkpt("x"); err != nil {
+		t.Fatal(err)
+	}
+	saved := netWts(ss)
+	ss.TrainTable(ss.TrainAB)
+	ss.Net.WtFmDWt()
+	if wtsDiff(netWts(ss), saved) == 0 {
+		t.Fatalf("weights did not change with more training")
+	}
+	if err := ss.LoadCkpt("x"); err != nil {
+		t.Fatal(err)
+	}
+	if d := wtsDiff(netWts(ss), saved); d > 1e-6 {
+		t.Errorf("weights after loading differ from the saved ones by %g", d)
+	}
+	if err := ss.LoadCkpt("y"); err == nil {
+		t.Errorf("LoadCkpt of a checkpoint never saved: no error")
+	}
+
+	fnm := ss.CkptFileName("x")
+	ss.TrainEnv.Run.Cur++
+	if ss.CkptFileName("x") == fnm {
+		t.Errorf("checkpoints of runs 0 and 1 have the same file: %s", fnm)
+	}
+	ss.NewRun()
+	if err := ss.LoadCkpt("x"); err == nil {
+		t.Errorf("LoadCkpt of a checkpoint of another run: no error")
+	}
+	if err := ss.SaveWtsFile(ss.CkptFileName("x")); err != nil { // a stale file of the same name
+		t.Fatal(err)
+	}
+	if err := ss.LoadCkpt("x"); err == nil || !strings.Contains(err.Error(), "no checkpoint") {
+		t.Errorf("LoadCkpt of a file not saved in this run: %v", err)
+	}
+}
+
+func TestCkptFork(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	pr := &Protocol{Name: "Study", Stages: []Stage{{Do: "init"}, {Do: "study"}}}
+	if err := ss.RunProtocol(pr); err != nil {
+		t.Fatal(err)
+	}
+	study := netWts(ss)
+	if err := ss.RunStage(&Stage{Do: "rp"}); err != nil {
+		t.Fatal(err)
+	}
+	if wtsDiff(netWts(ss), study) == 0 {
+		t.Fatalf("weights did not change with retrieval practice")
+	}
+	if err := ss.RunStage(&Stage{Do: "load", Ckpt: "study"}); err != nil {
+		t.Fatal(err)
+	}
+	if d := wtsDiff(netWts(ss), study); d > 1e-6 {
+		t.Errorf("weights forked from the study checkpoint differ from those after study by %g", d)
+	}
+}
+
+func TestCkptSaveError(t *testing.T) {
+	ss := testSim(t)
+	ss.CkptDir = filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(ss.CkptDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ss.Init()
+	err := ss.RunProtocol(TestingEffect("T", "AB"))
+	if err == nil || !strings.Contains(err.Error(), "stage") {
+		t.Errorf("protocol with checkpoints that cannot be saved: %v, want a stage error", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Cycle log
+//
+// The CycLog records the Cyc.Vars on each cycle of each trial of the protocol
+// stages in Cyc.Stages -- training and retrieval practice as well as testing --
+// for the settling dynamics, without the gui.  It holds the current trial, and
+// with Cyc.Save its rows are streamed to a file for each run.
+
+// CycVar splits a Cyc.Vars entry, <layer> <var>, into its layer and variable
+func CycVar(lv string) (lay, vr string) {
+	flds := strings.Fields(lv)
+	if len(flds) != 2 {
+		return "", ""
+	}
+	return flds[0], flds[1]
+}
+
+// ValidateCycVars returns an error for the first of the Cyc.Vars that does not
+// name a layer of the network and one of its unit variables
+func (ss *Sim) ValidateCycVars() error {
+	for _, lv := range ss.Cyc.Vars {
+		lnm, vnm := CycVar(lv)
+		if lnm == "" {
+			return fmt.Errorf("hipbench: cycle log variable %q is not <layer> <var>", lv)
+		}
+		ly, ok := ss.Net.LayerByName(lnm).(leabra.LeabraLayer)
+		if !ok {
+			return fmt.Errorf("hipbench: cycle log variable %q: no layer %s", lv, lnm)
+		}
+		if _, err := ly.AsLeabra().UnitVarIdx(vnm); err != nil {
+			return fmt.Errorf("hipbench: cycle log variable %q: %v", lv, err)
+		}
+	}
+	return nil
+}
+
+// LogCyc adds a row for the current cycle, in quarter qtr of a training or
+// testing trial, to the CycLog table, which has the cycles of the current
+// trial, if the protocol stage being run is one of the Cyc.Stages -- and
+// streams it to CycFile, if Cyc.Save
+func (ss *Sim) LogCyc(dt *etable.Table, train bool, qtr int) {
+	if ss.CurStage == nil || !ss.Cyc.On(ss.CurStage.Do) {
+		return
+	}
+	en := &ss.TestEnv
+	if train {
+		en = &ss.TrainEnv
+	}
+	cyc := ss.Time.Cycle
+	if cyc == 0 {
+		dt.SetNumRows(0)
+	}
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	stg := ss.CurStage.ActStageName()
+	if stg == "" {
+		stg = ss.CurStage.Do
+	}
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellString("Params", row, ss.RunName())
+	dt.SetCellString("Stage", row, stg)
+	dt.SetCellFloat("Epoch", row, float64(en.Epoch.Cur))
+	dt.SetCellFloat("Trial", row, float64(en.Trial.Cur))
+	dt.SetCellString("TrialName", row, en.TrialName.Cur)
+	dt.SetCellFloat("Quarter", row, float64(qtr))
+	dt.SetCellFloat("Cycle", row, float64(cyc))
+	for _, lv := range ss.Cyc.Vars {
+		lnm, vnm := CycVar(lv)
+		ly, ok := ss.Net.LayerByName(lnm).(leabra.LeabraLayer)
+		if !ok {
+			continue
+		}
+		dt.SetCellFloat(lv, row, ss.LayerMean(ly.AsLeabra(), vnm))
+	}
+
+	if !ss.Cyc.Save {
+		return
+	}
+	if ss.CycFile == nil {
+		var err error
+		fnm := ss.CycFileName()
+		ss.CycFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.Cyc.Save = false
+			return
+		}
+		fmt.Printf("Saving cycle log to: %v\n", fnm)
+		dt.WriteCSVHeaders(ss.CycFile, etable.Tab)
+	}
+	dt.WriteCSVRow(ss.CycFile, row, etable.Tab)
+}
+
+// LayerMean returns the mean of the unit variable over the units of the layer
+func (ss *Sim) LayerMean(ly *leabra.Layer, vnm string) float64 {
+	vi, err := ly.UnitVarIdx(vnm)
+	if err != nil || len(ly.Neurons) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for ni := range ly.Neurons {
+		sum += float64(ly.UnitVal1D(vi, ni))
+	}
+	return sum / float64(len(ly.Neurons))
+}
+
+// CycFileName returns the file that the CycLog rows of the current run are
+// streamed to
+func (ss *Sim) CycFileName() string {
+	return ss.LogFileName(fmt.Sprintf("run%03d_cyc", ss.TrainEnv.Run.Cur))
+}
+
+// CloseCycFile closes the CycFile of the run, if it is open
+func (ss *Sim) CloseCycFile() {
+	if ss.CycFile == nil {
+		return
+	}
+	if err := ss.CycFile.Close(); err != nil {
+		log.Println(err)
+	}
+	ss.CycFile = nil
+}
+
+// ConfigCycLog configures the CycLog for the current Cyc.Vars, with no rows
+func (ss *Sim) ConfigCycLog(dt *etable.Table) {
+	dt.SetMetaData("name", "CycLog")
+	dt.SetMetaData("desc", "Record of the layer variables by cycle of the current trial")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Trial", etensor.INT64, nil, nil},
+		{"TrialName", etensor.STRING, nil, nil},
+		{"Quarter", etensor.INT64, nil, nil},
+		{"Cycle", etensor.INT64, nil, nil},
+	}
+	for _, lv := range ss.Cyc.Vars {
+		sch = append(sch, etable.Column{lv, etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, 0)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"testing"
+
+	"github.com/emer/etable/etable"
+)
+
+func TestCycVar(t *testing.T) {
+	tests := []struct {
+		lv, lay, vr string
+	}{
+		{"CA3 Act", "CA3", "Act"},
+		{" Output  ActAvg ", "Output", "ActAvg"},
+		{"CA3", "", ""},
+		{"CA3 Act Ge", "", ""},
+	}
+	for _, tt := range tests {
+		if lay, vr := CycVar(tt.lv); lay != tt.lay || vr != tt.vr {
+			t.Errorf("CycVar(%q) = %q, %q, want %q, %q", tt.lv, lay, vr, tt.lay, tt.vr)
+		}
+	}
+}
+
+func TestValidateCycVars(t *testing.T) {
+	ss := testSim(t)
+	tests := []struct {
+		vars []string
+		ok   bool
+	}{
+		{[]string{"CA3 Act", "Output ActAvg", "ECout Ge"}, true},
+		{nil, true},
+		{[]string{"CA3"}, false},
+		{[]string{"CA3 Act", "CA4 Act"}, false},
+		{[]string{"CA3 Nope"}, false},
+	}
+	for _, tt := range tests {
+		ss.Cyc.Vars = tt.vars
+		if err := ss.ValidateCycVars(); (err == nil) != tt.ok {
+			t.Errorf("%v: ValidateCycVars = %v", tt.vars, err)
+		}
+	}
+}
+
+func TestCycLog(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.OutDir = t.TempDir()
+	ss.Cyc.Vars = []string{"CA3 Act", "Output ActAvg", "ECout Ge"}
+	ss.Cyc.Stages = []string{"rp", "test"}
+	ss.Cyc.Save = true
+	ss.Init()
+	if err := ss.RunProtocol(actProtocol); err != nil {
+		t.Fatal(err)
+	}
+	if ss.CycFile != nil {
+		t.Errorf("CycFile still open after the protocol")
+	}
+	cl := ss.CycLog
+	if cl.Rows == 0 || cl.CellFloat("Cycle", 0) != 0 || cl.CellString("TrialName", 0) != cl.CellString("TrialName", cl.Rows-1) {
+		t.Errorf("CycLog does not have just the cycles of the last trial")
+	}
+
+	dt := &etable.Table{}
+	if err := OpenCSV(dt, ss.CycFileName()); err != nil {
+		t.Fatal(err)
+	}
+	trials := map[string]int{} // trials by stage
+	act := 0.0
+	for row := 0; row < dt.Rows; row++ {
+		if dt.CellFloat("Cycle", row) == 0 {
+			trials[dt.CellString("Stage", row)]++
+		}
+		act += dt.CellFloat("CA3 Act", row)
+	}
+	if len(trials) != 2 || trials["rp"] == 0 || trials["final"] != ss.TestAB.Rows {
+		t.Errorf("trials by stage = %v, want rp and one final per AB item", trials)
+	}
+	if act == 0 {
+		t.Errorf("no CA3 Act recorded")
+	}
+	for _, lv := range ss.Cyc.Vars {
+		if dt.ColIdx(lv) < 0 {
+			t.Errorf("no column %s", lv)
+		}
+	}
+}
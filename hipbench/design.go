@@ -0,0 +1,226 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Crossed designs
+
+// Factor is one factor of a crossed Design, whose levels are ParamSets
+type Factor struct {
+	Name   string   `desc:"name of the factor: its column in the design logs"`
+	Levels []string `desc:"ParamSet of each level -- Base for the base params alone"`
+}
+
+// ParseFactor parses a factor given as name=level1,level2,...
+func ParseFactor(s string) (Factor, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 || i == len(s)-1 {
+		return Factor{}, fmt.Errorf("hipbench: factor %q is not name=level1,level2,...", s)
+	}
+	return Factor{Name: s[:i], Levels: strings.Split(s[i+1:], ",")}, nil
+}
+
+// Factors is a list of factors that is a flag.Value, each flag adding a factor
+// given as name=level1,level2,...
+type Factors []Factor
+
+func (fs *Factors) String() string {
+	var s []string
+	for _, f := range *fs {
+		s = append(s, f.Name+"="+strings.Join(f.Levels, ","))
+	}
+	return strings.Join(s, " ")
+}
+
+func (fs *Factors) Set(s string) error {
+	f, err := ParseFactor(s)
+	if err != nil {
+		return err
+	}
+	*fs = append(*fs, f)
+	return nil
+}
+
+// Design is a crossed (factorial) design: each of its cells, a combination of
+// one level of each factor, applies the ParamSets of its levels, in factor
+// order, after those of the Sim, and runs the protocol for each run.
+// Designs can be saved and loaded as JSON.
+type Design struct {
+	Factors Factors `desc:"the factors, crossed in order: the levels of the last vary fastest"`
+}
+
+// OpenDesign loads a Design from a JSON file
+func OpenDesign(fname string) (*Design, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	ds := &Design{}
+	if err := json.Unmarshal(b, ds); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return ds, nil
+}
+
+// SaveDesign saves the Design to a JSON file
+func (ds *Design) SaveDesign(fname string) error {
+	b, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, b, 0644)
+}
+
+// Validate returns an error for the first factor without a name or levels, or
+// named as a column of the logs, and for the first level that is not a
+// ParamSet of the Sim
+func (ds *Design) Validate(ss *Sim) error {
+	if len(ds.Factors) == 0 {
+		return fmt.Errorf("hipbench: design has no factors")
+	}
+	names := map[string]bool{}
+	for _, f := range ds.Factors {
+		if f.Name == "" {
+			return fmt.Errorf("hipbench: design factor without a name")
+		}
+		if names[f.Name] {
+			return fmt.Errorf("hipbench: design factor %s is given twice", f.Name)
+		}
+		names[f.Name] = true
+		if ss.RunLog.ColIdx(f.Name) >= 0 || ss.TstTrlLog.ColIdx(f.Name) >= 0 {
+			return fmt.Errorf("hipbench: design factor %s has the name of a log column", f.Name)
+		}
+		if len(f.Levels) == 0 {
+			return fmt.Errorf("hipbench: design factor %s has no levels", f.Name)
+		}
+		levs := map[string]bool{}
+		for _, lv := range f.Levels {
+			if levs[lv] {
+				return fmt.Errorf("hipbench: design factor %s: level %s is given twice", f.Name, lv)
+			}
+			levs[lv] = true
+			if _, err := ss.Params.SetByNameTry(lv); err != nil {
+				return fmt.Errorf("hipbench: design factor %s: level %s is not a ParamSet", f.Name, lv)
+			}
+		}
+	}
+	return nil
+}
+
+// Cells returns the levels of each cell of the design, the levels of the last
+// factor varying fastest
+func (ds *Design) Cells() [][]string {
+	cells := [][]string{nil}
+	for _, f := range ds.Factors {
+		var nc [][]string
+		for _, c := range cells {
+			for _, lv := range f.Levels {
+				nc = append(nc, append(append([]string{}, c...), lv))
+			}
+		}
+		cells = nc
+	}
+	return cells
+}
+
+// CellTag returns the Tag of the runs of the cell: the Sim's Tag, if any, and
+// the levels of the cell, joined by _
+func (ds *Design) CellTag(ss *Sim, cell []string) string {
+	tag := strings.Join(cell, "_")
+	if ss.Tag != "" {
+		tag = ss.Tag + "_" + tag
+	}
+	return tag
+}
+
+// Jobs returns jobs for MaxRuns runs of each cell of the design, each tagged
+// with CellTag and applying the ParamSets of its levels
+func (ds *Design) Jobs(ss *Sim) []Job {
+	var jobs []Job
+	for _, cell := range ds.Cells() {
+		var pss []string
+		for _, lv := range cell {
+			if lv != "Base" { // always applied first
+				pss = append(pss, lv)
+			}
+		}
+		for run := 0; run < ss.MaxRuns; run++ {
+			jobs = append(jobs, Job{Run: run, Tag: ds.CellTag(ss, cell), Params: pss})
+		}
+	}
+	return jobs
+}
+
+// FactorLog returns a copy of the log dt, which has a Params column (the
+// RunName of each row's run, as in RunLog and TstTrlLog), with a column for
+// each factor, holding the level of the row's cell
+func (ds *Design) FactorLog(ss *Sim, dt *etable.Table) *etable.Table {
+	cells := map[string][]string{}
+	for _, cell := range ds.Cells() {
+		cells[runName(ds.CellTag(ss, cell), ss.ParamsName())] = cell
+	}
+	fl := dt.Clone()
+	for _, f := range ds.Factors {
+		fl.AddCol(etensor.NewString([]int{fl.Rows}, nil, nil), f.Name)
+	}
+	for row := 0; row < fl.Rows; row++ {
+		cell, ok := cells[fl.CellString("Params", row)]
+		if !ok {
+			continue
+		}
+		for fi, f := range ds.Factors {
+			fl.SetCellString(f.Name, row, cell[fi])
+		}
+	}
+	return fl
+}
+
+// RunDesign runs the protocol for MaxRuns runs of each cell of the design, as
+// set by Dist.  The master then has the run log and test trial log of all the
+// runs, each with a column for each factor, as its RunLog and TstTrlLog.
+func (ss *Sim) RunDesign(ds *Design, pr *Protocol) error {
+	if err := ds.Validate(ss); err != nil {
+		return err
+	}
+	fmt.Printf("Running design: %s, %d cells\n", ds.Factors.String(), len(ds.Cells()))
+	if err := ss.DoJobs(ds.Jobs(ss), func(rs *Sim) error { return rs.RunProtocol(pr) }); err != nil {
+		return err
+	}
+	if !ss.IsMaster() {
+		return nil
+	}
+	ss.RunLog = ds.FactorLog(ss, ss.RunLog)
+	ss.TstTrlLog = ds.FactorLog(ss, ss.TstTrlLog)
+	return nil
+}
+
+// DoDesign runs the design (see RunDesign), and saves the run log and test
+// trial log of all the runs, with their factor columns, to
+// <net>_<runname>_design.tsv and <net>_<runname>_design_trl.tsv
+func (ss *Sim) DoDesign(ds *Design, pr *Protocol) error {
+	if err := ss.RunDesign(ds, pr); err != nil || !ss.IsMaster() {
+		return err
+	}
+	for _, lg := range []struct {
+		dt  *etable.Table
+		fnm string
+	}{{ss.RunLog, ss.LogFileName("design")}, {ss.TstTrlLog, ss.LogFileName("design_trl")}} {
+		if err := SaveCSV(lg.dt, lg.fnm); err != nil {
+			return err
+		}
+		fmt.Printf("Saving design log to: %v\n", lg.fnm)
+	}
+	return nil
+}
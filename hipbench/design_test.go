@@ -0,0 +1,155 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emer/emergent/params"
+)
+
+func TestParseFactor(t *testing.T) {
+	tests := []struct {
+		s  string
+		f  Factor
+		ok bool
+	}{
+		{"Size=SmallHip,MedHip", Factor{Name: "Size", Levels: []string{"SmallHip", "MedHip"}}, true},
+		{"RP=Base", Factor{Name: "RP", Levels: []string{"Base"}}, true},
+		{"Size", Factor{}, false},
+		{"=SmallHip", Factor{}, false},
+		{"Size=", Factor{}, false},
+	}
+	for _, tt := range tests {
+		f, err := ParseFactor(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseFactor(%q) error = %v, want ok = %v", tt.s, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(f, tt.f) {
+			t.Errorf("ParseFactor(%q) = %+v, want %+v", tt.s, f, tt.f)
+		}
+	}
+	var fs Factors
+	for _, s := range []string{"A=Base,RP", "B=RP"} {
+		if err := fs.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fs.String() != "A=Base,RP B=RP" {
+		t.Errorf("Factors = %s, want A=Base,RP B=RP", fs.String())
+	}
+}
+
+func TestDesignCells(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	ss.MaxRuns = 2
+	ds := &Design{Factors: Factors{
+		{Name: "A", Levels: []string{"Base", "RP"}},
+		{Name: "B", Levels: []string{"X", "Y", "Z"}},
+	}}
+	want := [][]string{{"Base", "X"}, {"Base", "Y"}, {"Base", "Z"}, {"RP", "X"}, {"RP", "Y"}, {"RP", "Z"}}
+	if cells := ds.Cells(); !reflect.DeepEqual(cells, want) {
+		t.Errorf("Cells() = %v, want %v", cells, want)
+	}
+	tests := []struct {
+		tag string
+		job Job
+		ji  int
+	}{
+		{"", Job{Run: 0, Tag: "Base_X", Params: []string{"X"}}, 0},
+		{"", Job{Run: 1, Tag: "Base_X", Params: []string{"X"}}, 1},
+		{"", Job{Run: 1, Tag: "RP_Z", Params: []string{"RP", "Z"}}, 11},
+		{"sw", Job{Run: 0, Tag: "sw_RP_Y", Params: []string{"RP", "Y"}}, 8},
+	}
+	for _, tt := range tests {
+		ss.Tag = tt.tag
+		jobs := ds.Jobs(ss)
+		if len(jobs) != 12 {
+			t.Fatalf("%d jobs, want 12", len(jobs))
+		}
+		if !reflect.DeepEqual(jobs[tt.ji], tt.job) {
+			t.Errorf("tag %q: job %d = %+v, want %+v", tt.tag, tt.ji, jobs[tt.ji], tt.job)
+		}
+	}
+}
+
+func TestDesignValidate(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	tests := []struct {
+		name string
+		fs   Factors
+		ok   bool
+	}{
+		{"ok", Factors{{Name: "A", Levels: []string{"Base", "RP"}}}, true},
+		{"two", Factors{{Name: "A", Levels: []string{"Base"}}, {Name: "B", Levels: []string{"RP"}}}, true},
+		{"none", nil, false},
+		{"no name", Factors{{Levels: []string{"RP"}}}, false},
+		{"twice", Factors{{Name: "A", Levels: []string{"Base"}}, {Name: "A", Levels: []string{"RP"}}}, false},
+		{"no levels", Factors{{Name: "A"}}, false},
+		{"level twice", Factors{{Name: "A", Levels: []string{"RP", "RP"}}}, false},
+		{"no such set", Factors{{Name: "A", Levels: []string{"MedHip"}}}, false},
+		{"column", Factors{{Name: "Run", Levels: []string{"RP"}}}, false},
+	}
+	ss.ConfigRunLog(ss.RunLog)
+	for _, tt := range tests {
+		ds := &Design{Factors: tt.fs}
+		if err := ds.Validate(ss); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestRunDesign(t *testing.T) {
+	ss := testSim(t)
+	ss.MaxRuns = 2
+	ss.Dist.Workers = 2
+	// everything is recalled with MemThr above 1, and nothing below 0
+	thr := func(name, val string) *params.Set {
+		return &params.Set{Name: name, Sheets: params.Sheets{
+			"Sim": &params.Sheet{{Sel: "Sim", Params: params.Params{"Sim.MemThr": val}}},
+		}}
+	}
+	if err := ss.AddParamSets(thr("None", "-1"), thr("All", "2")); err != nil {
+		t.Fatal(err)
+	}
+	ds := &Design{Factors: Factors{
+		{Name: "Thr", Levels: []string{"None", "All"}},
+		{Name: "RP", Levels: []string{"Base", "RP"}},
+	}}
+	if err := ss.RunDesign(ds, testProtocol); err != nil {
+		t.Fatal(err)
+	}
+	rl := ss.RunLog
+	if rl.Rows != 8 {
+		t.Fatalf("%d run log rows, want 8", rl.Rows)
+	}
+	for row := 0; row < rl.Rows; row++ {
+		cell := []string{rl.CellString("Thr", row), rl.CellString("RP", row)}
+		want := ds.Cells()[row/2]
+		if !reflect.DeepEqual(cell, want) {
+			t.Errorf("row %d: cell %v, want %v", row, cell, want)
+		}
+		mem := 0.0
+		if cell[0] == "All" {
+			mem = 1
+		}
+		if m := rl.CellFloat("AB Mem", row); m != mem {
+			t.Errorf("row %d: cell %v: AB Mem = %g, want %g", row, cell, m, mem)
+		}
+	}
+	tl := ss.TstTrlLog
+	if tl.Rows == 0 {
+		t.Fatalf("no test trial log rows")
+	}
+	for row := 0; row < tl.Rows; row++ {
+		if tl.CellString("Thr", row) == "" || tl.CellString("RP", row) == "" {
+			t.Fatalf("test trial row %d (%s) has no cell", row, tl.CellString("Params", row))
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/emer/empi/empi"
+	"github.com/emer/empi/mpi"
+	"github.com/emer/etable/etable"
+)
+
+// DistParams say how jobs are distributed: over MPI ranks (build with -tags mpi
+// and run under mpirun), or else over local worker processes of this same binary,
+// each doing its share of the jobs in a Runner of Workers goroutines.
+// The master process (MPI rank 0, or the parent of the workers) gathers the logs.
+type DistParams struct {
+	Workers  int       `desc:"number of jobs to do at once in each process, each with its own network -- 0 = number of CPUs"`
+	MPI      bool      `desc:"distribute jobs over MPI ranks"`
+	Procs    int       `desc:"without MPI, number of local worker processes to spawn -- 0 or 1 = do the jobs in this process"`
+	Shard    string    `desc:"rank/n of a spawned worker process -- set by the parent"`
+	ShardLog string    `desc:"file name prefix for the logs of a spawned worker process -- set by the parent"`
+	Comm     *mpi.Comm `view:"-" desc:"MPI communicator over all ranks"`
+}
+
+// Distributed returns true if jobs are done in more than one process
+func (dp *DistParams) Distributed() bool {
+	return dp.MPI || dp.Shard != "" || dp.Procs > 1
+}
+
+// Shard returns the indexes of the jobs, out of njobs, that process rank of n does
+func Shard(njobs, rank, n int) []int {
+	var idx []int
+	for ji := rank; ji < njobs; ji += n {
+		idx = append(idx, ji)
+	}
+	return idx
+}
+
+// MPIInit initializes MPI and the communicator over all ranks
+func (ss *Sim) MPIInit() {
+	mpi.Init()
+	var err error
+	ss.Dist.Comm, err = mpi.NewComm(nil) // use all procs
+	if err != nil {
+		log.Println(err)
+		ss.Dist.MPI = false
+		return
+	}
+	mpi.Printf("MPI running on %d procs\n", mpi.WorldSize())
+}
+
+// IsMaster returns false for the workers of a distributed run (MPI ranks
+// other than 0, and spawned worker processes), which leave the saving of the
+// combined logs to the master
+func (ss *Sim) IsMaster() bool {
+	if ss.Dist.Shard != "" {
+		return false
+	}
+	if ss.Dist.MPI {
+		return ss.Dist.Comm.Rank() == 0
+	}
+	return true
+}
+
+// MasterTime returns the time t of MPI rank 0, so that all the ranks name
+// the same output directory (see MakeOutDir)
+func (ss *Sim) MasterTime(t time.Time) (time.Time, error) {
+	comm := ss.Dist.Comm
+	ts := make([]int, comm.Size())
+	if err := comm.AllGatherInt(ts, []int{int(t.Unix())}); err != nil {
+		return t, err
+	}
+	return time.Unix(int64(ts[0]), 0), nil
+}
+
+// DoJobs does the jobs, each doing run on its own Sim (Train if nil), as set by
+// Dist.  The master then has the RunLog, TstTrlLog and RPTrlLog rows of all the
+// jobs, in job order, as its own RunLog, TstTrlLog and RPTrlLog, saves the
+// RunLog to RunFile -- or, for distributed runs, always to the default run log
+// file -- and the RPTrlLog to RPTrlFile.  Jobs done in
+// this process also leave their merged TstEpcLog, saved to TstEpcFile.
+// If any of the processes fails, nothing is saved and the error is returned.
+func (ss *Sim) DoJobs(jobs []Job, run func(ss *Sim) error) error {
+	switch {
+	case ss.Dist.Shard != "":
+		return ss.DoShard(jobs, run)
+	case ss.Dist.MPI:
+		if err := ss.DoJobsMPI(jobs, run); err != nil {
+			return err
+		}
+	case ss.Dist.Procs > 1:
+		if err := ss.DoJobsProcs(); err != nil {
+			return err
+		}
+	default:
+		rn := NewRunner(ss, jobs, ss.Dist.Workers)
+		rn.Run = run
+		err := rn.Exec()
+		if err != nil {
+			return err
+		}
+		ss.RunLog = rn.RunLog
+		ss.TstEpcLog = rn.TstEpcLog
+		ss.TstTrlLog = rn.TstTrlLog
+		ss.RPTrlLog = rn.RPTrlLog
+		if ss.TstEpcFile != nil {
+			ss.TstEpcLog.WriteCSV(ss.TstEpcFile, etable.Tab, etable.Headers)
+		}
+	}
+	if !ss.IsMaster() {
+		return nil
+	}
+	ss.LogRunStats()
+	if ss.RPTrlFile != nil {
+		if err := ss.RPTrlLog.WriteCSV(ss.RPTrlFile, etable.Tab, etable.Headers); err != nil {
+			return err
+		}
+	}
+	if ss.RunFile == nil && ss.Dist.Distributed() {
+		var err error
+		fnm := ss.LogFileName("run")
+		ss.RunFile, err = os.Create(fnm)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saving run log to: %v\n", fnm)
+		defer func() {
+			ss.RunFile.Close()
+			ss.RunFile = nil
+		}()
+	}
+	if ss.RunFile != nil {
+		return ss.RunLog.WriteCSV(ss.RunFile, etable.Tab, etable.Headers)
+	}
+	return nil
+}
+
+// runShard does the jobs of given indexes, returning the Runner with their logs
+func (ss *Sim) runShard(jobs []Job, idx []int, run func(ss *Sim) error) (*Runner, error) {
+	sj := make([]Job, len(idx))
+	for i, ji := range idx {
+		sj[i] = jobs[ji]
+	}
+	rn := NewRunner(ss, sj, ss.Dist.Workers)
+	rn.Run = run
+	return rn, rn.Exec()
+}
+
+// DoJobsMPI does this rank's share of the jobs, and gathers the logs of all ranks
+func (ss *Sim) DoJobsMPI(jobs []Job, run func(ss *Sim) error) error {
+	comm := ss.Dist.Comm
+	idx := Shard(len(jobs), comm.Rank(), comm.Size())
+	rn, runErr := ss.runShard(jobs, idx, run) // the other ranks still gather this one's logs
+	var err error
+	ss.RunLog, err = ss.GatherJobLog(JobLog(rn.RunLogs, idx, ss.ConfigRunLog), ss.ConfigRunLog)
+	if err != nil {
+		return err
+	}
+	ss.TstTrlLog, err = ss.GatherJobLog(JobLog(rn.TstTrlAll, idx, ss.ConfigTstTrlLog), ss.ConfigTstTrlLog)
+	if err != nil {
+		return err
+	}
+	ss.RPTrlLog, err = ss.GatherJobLog(JobLog(rn.RPTrls, idx, ss.ConfigRPTrlLog), ss.ConfigRPTrlLog)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	if runErr != nil {
+		log.Println(runErr)
+		failed = 1
+	}
+	fails := make([]int, comm.Size())
+	if err := comm.AllGatherInt(fails, []int{failed}); err != nil {
+		return err
+	}
+	nfail := 0
+	for _, f := range fails {
+		nfail += f
+	}
+	if nfail > 0 {
+		return fmt.Errorf("hipbench: runs failed on %d of %d MPI ranks", nfail, comm.Size())
+	}
+	return nil
+}
+
+// GatherJobLog gathers the JobLog rows of all MPI ranks, in job order, in a
+// table configured by cfg.  The ranks can have different numbers of rows, but
+// GatherTableRows needs the same number from each, so each rank pads its rows
+// to the largest number, with padding rows of Job -1 that SortJobLog drops.
+func (ss *Sim) GatherJobLog(jl *etable.Table, cfg func(dt *etable.Table)) (*etable.Table, error) {
+	comm := ss.Dist.Comm
+	counts := make([]int, comm.Size())
+	if err := comm.AllGatherInt(counts, []int{jl.Rows}); err != nil {
+		return nil, err
+	}
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	n := jl.Rows
+	jl.SetNumRows(max)
+	for row := n; row < max; row++ {
+		jl.SetCellFloat("Job", row, -1)
+	}
+	all := &etable.Table{}
+	empi.GatherTableRows(all, jl, comm)
+	return SortJobLog(all, cfg), nil
+}
+
+// DoShard does the share of the jobs of this spawned worker process, and
+// saves their logs for the parent
+func (ss *Sim) DoShard(jobs []Job, run func(ss *Sim) error) error {
+	var rank, n int
+	if _, err := fmt.Sscanf(ss.Dist.Shard, "%d/%d", &rank, &n); err != nil {
+		return fmt.Errorf("hipbench: bad shard %q: %v", ss.Dist.Shard, err)
+	}
+	idx := Shard(len(jobs), rank, n)
+	rn, err := ss.runShard(jobs, idx, run)
+	if err != nil {
+		return err
+	}
+	if err := SaveCSV(JobLog(rn.RunLogs, idx, ss.ConfigRunLog), ss.Dist.ShardLog+"_run.tsv"); err != nil {
+		return err
+	}
+	if err := SaveCSV(JobLog(rn.TstTrlAll, idx, ss.ConfigTstTrlLog), ss.Dist.ShardLog+"_tst.tsv"); err != nil {
+		return err
+	}
+	return SaveCSV(JobLog(rn.RPTrls, idx, ss.ConfigRPTrlLog), ss.Dist.ShardLog+"_rp.tsv")
+}
+
+// DoJobsProcs spawns Dist.Procs worker processes of this binary with the same
+// command line, each doing its shard of the jobs, and merges their logs.
+// It fails, without merging, if any of the workers fails.
+func (ss *Sim) DoJobsProcs() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir("", ss.Model.Name)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	n := ss.Dist.Procs
+	cmds := make([]*exec.Cmd, n)
+	for i := range cmds {
+		args := append(append([]string{}, os.Args[1:]...), "-outdir", ss.OutDir, "-shard", fmt.Sprintf("%d/%d", i, n), "-shardlog", filepath.Join(dir, fmt.Sprintf("shard%d", i)))
+		cmd := exec.Command(exe, args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			log.Printf("hipbench: worker %d: %v\n", i, err)
+			continue
+		}
+		cmds[i] = cmd
+	}
+	nfail := 0
+	for i, cmd := range cmds {
+		if cmd == nil {
+			nfail++
+			continue
+		}
+		if err := cmd.Wait(); err != nil {
+			log.Printf("hipbench: worker %d: %v\n", i, err)
+			nfail++
+		}
+	}
+	if nfail > 0 {
+		return fmt.Errorf("hipbench: %d of %d worker processes failed", nfail, n)
+	}
+	rls := make([]*etable.Table, n)
+	tls := make([]*etable.Table, n)
+	pls := make([]*etable.Table, n)
+	for i := range cmds {
+		prefix := filepath.Join(dir, fmt.Sprintf("shard%d", i))
+		rls[i] = &etable.Table{}
+		if err := OpenCSV(rls[i], prefix+"_run.tsv"); err != nil {
+			return err
+		}
+		tls[i] = &etable.Table{}
+		if err := OpenCSV(tls[i], prefix+"_tst.tsv"); err != nil {
+			return err
+		}
+		pls[i] = &etable.Table{}
+		if err := OpenCSV(pls[i], prefix+"_rp.tsv"); err != nil {
+			return err
+		}
+	}
+	ss.RunLog = SortJobLog(MergeLogs(rls), ss.ConfigRunLog)
+	ss.TstTrlLog = SortJobLog(MergeLogs(tls), ss.ConfigTstTrlLog)
+	ss.RPTrlLog = SortJobLog(MergeLogs(pls), ss.ConfigRPTrlLog)
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShard(t *testing.T) {
+	tests := []struct {
+		njobs, rank, n int
+		want           []int
+	}{
+		{6, 0, 2, []int{0, 2, 4}},
+		{6, 1, 2, []int{1, 3, 5}},
+		{5, 1, 3, []int{1, 4}},
+		{5, 2, 3, []int{2}},
+		{2, 2, 3, nil},
+		{3, 0, 1, []int{0, 1, 2}},
+		{0, 0, 4, nil},
+	}
+	for _, tt := range tests {
+		if got := Shard(tt.njobs, tt.rank, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Shard(%d, %d, %d) = %v, want %v", tt.njobs, tt.rank, tt.n, got, tt.want)
+		}
+	}
+	// the shards of all ranks cover each job once
+	for _, n := range []int{1, 2, 3, 7} {
+		seen := make([]int, 10)
+		for rank := 0; rank < n; rank++ {
+			for _, ji := range Shard(len(seen), rank, n) {
+				seen[ji]++
+			}
+		}
+		for ji, c := range seen {
+			if c != 1 {
+				t.Errorf("%d ranks: job %d done %d times", n, ji, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Testing effect
+
+// Condition is a practice condition of the testing effect.  Its final tests
+// are the test stages of each route saved as <Prefix>_<route>, as made by
+// TestStages (e.g., test_full, or test_d8_full after delays).
+type Condition struct {
+	Name    string `desc:"name of the condition, in the log columns: its Kind, and its Variant if any (e.g., RP, RP none, Restudy d8)"`
+	Prefix  string `desc:"prefix of the Save names of its test stages"`
+	Kind    string `desc:"kind of practice: RP, Restudy or NoPractice -- see CondKinds"`
+	Variant string `desc:"variant of the kind, in a protocol with several of them: the retrieval practice feedback (e.g., none) or the delay (e.g., d8) -- empty for none"`
+}
+
+// Effect is a testing effect: the final-test Mem of a retrieval practice
+// condition minus that of the restudy condition it is compared with
+type Effect struct {
+	Name    string `desc:"name of the effect, in the log columns: TE, and the Variant of its RP condition if any (e.g., TE none, TE d8)"`
+	RP      string `desc:"name of its retrieval practice condition"`
+	Restudy string `desc:"name of its restudy condition"`
+}
+
+// Conds are the testing effect conditions of a protocol, and their effects
+type Conds struct {
+	Conditions []Condition
+	Effects    []Effect
+}
+
+// CondKinds are the kinds of practice condition, by the first part of the
+// Save prefix of their tests: the test after retrieval practice (test, or
+// rp_<feedback> for each feedback of FeedbackEffect), restudy, and the
+// no-practice baseline of the test after study
+var CondKinds = map[string]string{
+	"test":    "RP",
+	"rp":      "RP",
+	"restudy": "Restudy",
+	"study":   "NoPractice",
+}
+
+// condKindOrder is the order of the kinds of condition in the log columns
+var condKindOrder = map[string]int{"RP": 0, "Restudy": 1, "NoPractice": 2}
+
+// PrefixCondition returns the condition of the tests with the given Save
+// prefix (e.g., test, restudy_d8, rp_none), and false if it is not one
+func PrefixCondition(prefix string) (Condition, bool) {
+	flds := strings.SplitN(prefix, "_", 2)
+	kind, ok := CondKinds[flds[0]]
+	if !ok {
+		return Condition{}, false
+	}
+	cd := Condition{Name: kind, Prefix: prefix, Kind: kind}
+	if len(flds) == 2 {
+		cd.Variant = flds[1]
+		cd.Name += " " + cd.Variant
+	}
+	return cd, true
+}
+
+// ProtocolConds returns the testing effect conditions of the test stages of
+// the protocol, by kind and then in the order of their first test, and an
+// effect for each retrieval practice condition that has a restudy condition
+// to compare with: that of the same Variant (e.g., after the same delay), or
+// else the one without a Variant (e.g., for each feedback)
+func ProtocolConds(pr *Protocol) *Conds {
+	cs := &Conds{}
+	has := map[string]bool{}
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		if st.Do != "test" {
+			continue
+		}
+		sfx := "_" + RouteSaves[st.routeName()]
+		if !strings.HasSuffix(st.Save, sfx) {
+			continue
+		}
+		cd, ok := PrefixCondition(strings.TrimSuffix(st.Save, sfx))
+		if !ok || has[cd.Name] {
+			continue
+		}
+		has[cd.Name] = true
+		cs.Conditions = append(cs.Conditions, cd)
+	}
+	sort.SliceStable(cs.Conditions, func(i, j int) bool {
+		return condKindOrder[cs.Conditions[i].Kind] < condKindOrder[cs.Conditions[j].Kind]
+	})
+	for _, cd := range cs.Conditions {
+		if cd.Kind != "RP" {
+			continue
+		}
+		rs := "Restudy"
+		if cd.Variant != "" && has[rs+" "+cd.Variant] {
+			rs += " " + cd.Variant
+		}
+		if !has[rs] {
+			continue
+		}
+		ef := Effect{Name: "TE", RP: cd.Name, Restudy: rs}
+		if cd.Variant != "" {
+			ef.Name += " " + cd.Variant
+		}
+		cs.Effects = append(cs.Effects, ef)
+	}
+	return cs
+}
+
+// IsTest returns true if the test stage saved as save is a test of the
+// condition on the route
+func (cd *Condition) IsTest(save, route string) bool {
+	return save == cd.Prefix+"_"+RouteSaves[route]
+}
+
+// EffectMemCol returns the RunLog column of the final-test Mem of the
+// condition on the route (e.g., "RP full Mem")
+func EffectMemCol(cond, route string) string {
+	return cond + " " + route + " Mem"
+}
+
+// EffectCol returns the RunLog column of the testing effect on the route:
+// RP minus Restudy final-test Mem (e.g., "TE full", "TE d8 full")
+func EffectCol(eff, route string) string {
+	return eff + " " + route
+}
+
+// Cols returns the RunLog columns of the testing effect: the final-test Mem
+// of each condition and each testing effect, on each route
+func (cs *Conds) Cols() []string {
+	var cols []string
+	for _, rt := range RouteNames {
+		for _, cd := range cs.Conditions {
+			cols = append(cols, EffectMemCol(cd.Name, rt))
+		}
+		for _, ef := range cs.Effects {
+			cols = append(cols, EffectCol(ef.Name, rt))
+		}
+	}
+	return cols
+}
+
+// Schema returns the RunLog columns of the testing effect
+func (cs *Conds) Schema() etable.Schema {
+	var sch etable.Schema
+	for _, cn := range cs.Cols() {
+		sch = append(sch, etable.Column{cn, etensor.FLOAT64, nil, nil})
+	}
+	return sch
+}
+
+// LogConds returns the testing effect conditions and effects of the columns
+// of a RunLog (e.g., one read from a file): the conditions of the kinds of
+// CondKinds with a "<cond> full Mem" column, and the effects with a
+// "TE... full" column (without their RP and Restudy)
+func LogConds(dt *etable.Table) *Conds {
+	cs := &Conds{}
+	msfx := " " + RouteNames[0] + " Mem"
+	for _, cn := range dt.ColNames {
+		switch {
+		case strings.HasSuffix(cn, msfx):
+			nm := strings.TrimSuffix(cn, msfx)
+			kind := strings.SplitN(nm, " ", 2)[0]
+			if _, ok := condKindOrder[kind]; ok {
+				cs.Conditions = append(cs.Conditions, Condition{Name: nm, Kind: kind})
+			}
+		case strings.HasPrefix(cn, "TE ") && strings.HasSuffix(cn, " "+RouteNames[0]):
+			cs.Effects = append(cs.Effects, Effect{Name: strings.TrimSuffix(cn, " "+RouteNames[0])})
+		}
+	}
+	return cs
+}
+
+// SetConds sets the testing effect conditions of the RunLog to those of the
+// protocol, reconfiguring the RunLog (without its rows) if they differ
+func (ss *Sim) SetConds(pr *Protocol) {
+	cs := ProtocolConds(pr)
+	if reflect.DeepEqual(cs, ss.Conds) {
+		return
+	}
+	ss.Conds = cs
+	ss.ConfigRunLog(ss.RunLog)
+}
+
+// LogRunEffect records, in the RunLog row, the final-test Mem (of the first
+// test, e.g. AB) of each of the Conds conditions on each route in the
+// TstEpcLog rows of the run, and each of its testing effects on each route.
+// Conditions without a final test in the run are NaN.
+func (ss *Sim) LogRunEffect(dt *etable.Table, row int) {
+	if len(ss.TstNms) == 0 {
+		return
+	}
+	memcol := ss.TstNms[0] + " Mem"
+	epc := ss.TstEpcLog
+	run := ss.TrainEnv.Run.Cur
+	for _, rt := range RouteNames {
+		mem := map[string]float64{}
+		for _, cd := range ss.Conds.Conditions {
+			mem[cd.Name] = math.NaN()
+			for er := epc.Rows - 1; er >= 0; er-- {
+				if int(epc.CellFloat("Run", er)) == run && cd.IsTest(epc.CellString("Stage", er), rt) {
+					mem[cd.Name] = epc.CellFloat(memcol, er)
+					break
+				}
+			}
+			dt.SetCellFloat(EffectMemCol(cd.Name, rt), row, mem[cd.Name])
+		}
+		for _, ef := range ss.Conds.Effects {
+			dt.SetCellFloat(EffectCol(ef.Name, rt), row, mem[ef.RP]-mem[ef.Restudy])
+		}
+	}
+}
+
+// tCrit95 are the two-sided 95% critical values of Student's t, by degrees of
+// freedom - 1, up to 30
+var tCrit95 = []float64{12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042}
+
+// TCrit95 returns the two-sided 95% critical value of Student's t with df degrees
+// of freedom: from a table up to 30, and the Cornish-Fisher expansion above
+func TCrit95(df int) float64 {
+	if df <= 0 {
+		return math.NaN()
+	}
+	if df <= len(tCrit95) {
+		return tCrit95[df-1]
+	}
+	z := Probit(.975)
+	n := float64(df)
+	z3, z5, z7 := z*z*z, math.Pow(z, 5), math.Pow(z, 7)
+	return z + (z3+z)/(4*n) + (5*z5+16*z3+3*z)/(96*n*n) + (3*z7+19*z5+17*z3-15*z)/(384*n*n*n)
+}
+
+// AddCI95 adds a col:CI95 column to the RunStats-style table dt for each of
+// the cols, described with split.Desc: the half-width of the 95% confidence
+// interval of the mean, from col:Count and col:Sem
+func AddCI95(dt *etable.Table, cols []string) {
+	for _, cn := range cols {
+		if dt.ColIdx(cn+":Sem") < 0 || dt.ColIdx(cn+":Count") < 0 {
+			continue
+		}
+		ci := etensor.NewFloat64([]int{dt.Rows}, nil, nil)
+		for row := 0; row < dt.Rows; row++ {
+			n := int(dt.CellFloat(cn+":Count", row))
+			ci.Values[row] = TCrit95(n-1) * dt.CellFloat(cn+":Sem", row)
+		}
+		dt.AddCol(ci, cn+":CI95")
+	}
+}
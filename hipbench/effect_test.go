@@ -0,0 +1,199 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+func TestTCrit95(t *testing.T) {
+	tests := []struct {
+		df int
+		t  float64
+	}{
+		{1, 12.706},
+		{2, 4.303},
+		{10, 2.228},
+		{30, 2.042},
+		{40, 2.021},
+		{60, 2.000},
+		{120, 1.980},
+	}
+	for _, tt := range tests {
+		if v := TCrit95(tt.df); math.Abs(v-tt.t) > 1e-3 {
+			t.Errorf("TCrit95(%d) = %g, want %g", tt.df, v, tt.t)
+		}
+	}
+	if !math.IsNaN(TCrit95(0)) {
+		t.Errorf("TCrit95(0) = %g, want NaN", TCrit95(0))
+	}
+}
+
+func TestProtocolConds(t *testing.T) {
+	tests := []struct {
+		pr      *Protocol
+		conds   []string
+		effects []Effect
+	}{
+		{Protocols["Short"], []string{"RP", "Restudy", "NoPractice"},
+			[]Effect{{"TE", "RP", "Restudy"}}},
+		{Protocols["Delay"], []string{"RP d0", "RP d2", "RP d8", "Restudy d0", "Restudy d2", "Restudy d8"},
+			[]Effect{{"TE d0", "RP d0", "Restudy d0"}, {"TE d2", "RP d2", "Restudy d2"}, {"TE d8", "RP d8", "Restudy d8"}}},
+		{Protocols["Feedback"], []string{"RP none", "RP full", "RP delayed", "Restudy"},
+			[]Effect{{"TE none", "RP none", "Restudy"}, {"TE full", "RP full", "Restudy"}, {"TE delayed", "RP delayed", "Restudy"}}},
+		{testProtocol, nil, nil},
+	}
+	for _, tt := range tests {
+		cs := ProtocolConds(tt.pr)
+		var conds []string
+		for _, cd := range cs.Conditions {
+			conds = append(conds, cd.Name)
+		}
+		if !reflect.DeepEqual(conds, tt.conds) {
+			t.Errorf("%s: conditions %v, want %v", tt.pr.Name, conds, tt.conds)
+		}
+		if !reflect.DeepEqual(cs.Effects, tt.effects) {
+			t.Errorf("%s: effects %v, want %v", tt.pr.Name, cs.Effects, tt.effects)
+		}
+	}
+}
+
+func TestConditionIsTest(t *testing.T) {
+	cond := func(prefix string) *Condition {
+		cd, ok := PrefixCondition(prefix)
+		if !ok {
+			t.Fatalf("%s is not a condition", prefix)
+		}
+		return &cd
+	}
+	rp, rs, np, rpd, rpn := cond("test"), cond("restudy"), cond("study"), cond("test_d8"), cond("rp_none")
+	tests := []struct {
+		cd    *Condition
+		save  string
+		route string
+		is    bool
+	}{
+		{rp, "test_full", "full", true},
+		{rp, "test_cor", "cortex", true},
+		{rp, "test_full", "hip", false},
+		{rp, "test_d8_hip", "hip", false},
+		{rpd, "test_d8_hip", "hip", true},
+		{rpd, "test_d2_hip", "hip", false},
+		{rp, "restudy_full", "full", false},
+		{rs, "restudy_full", "full", true},
+		{rs, "restudy_d2_cor", "cortex", false},
+		{np, "study_hip", "hip", true},
+		{np, "restudy_hip", "hip", false},
+		{rp, "rp_none_full", "full", false},
+		{rpn, "rp_none_full", "full", true},
+		{rp, "final", "full", false},
+	}
+	for _, tt := range tests {
+		if is := tt.cd.IsTest(tt.save, tt.route); is != tt.is {
+			t.Errorf("%s.IsTest(%s, %s) = %v, want %v", tt.cd.Name, tt.save, tt.route, is, tt.is)
+		}
+	}
+	if rpn.Name != "RP none" || rpd.Kind != "RP" || rpd.Variant != "d8" {
+		t.Errorf("rp_none: %+v, test_d8: %+v", *rpn, *rpd)
+	}
+	if _, ok := PrefixCondition("final"); ok {
+		t.Errorf("final is a condition")
+	}
+}
+
+func TestLogRunEffect(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   *Protocol
+	}{
+		{"testing effect", TestingEffect("T", "AB")},
+		{"no restudy", &Protocol{Name: "R", Stages: append(append(StudyStages(), Stage{Do: "rp"}), TestStages("AB", "test")...)}},
+		{"delay", Protocols["Delay"]},
+		{"feedback", Protocols["Feedback"]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := testSim(t)
+			ss.Acts.RSA = true
+			ss.ConfigRunLog(ss.RunLog)
+			ss.Init()
+			if err := ss.RunProtocol(tt.pr); err != nil {
+				t.Fatal(err)
+			}
+			rl, epc := ss.RunLog, ss.TstEpcLog
+			if rl.Rows != 1 {
+				t.Fatalf("%d run log rows, want 1", rl.Rows)
+			}
+			cs := ProtocolConds(tt.pr)
+			if !reflect.DeepEqual(ss.Conds, cs) {
+				t.Errorf("Conds are not those of the protocol")
+			}
+			tst := ss.TstNms[0] + " Mem"
+			for _, rt := range RouteNames {
+				for _, cd := range cs.Conditions {
+					v := rl.CellFloat(EffectMemCol(cd.Name, rt), 0)
+					save := cd.Prefix + "_" + RouteSaves[rt]
+					want := math.NaN()
+					for er := 0; er < epc.Rows; er++ {
+						if epc.CellString("Stage", er) == save {
+							want = epc.CellFloat(tst, er)
+						}
+					}
+					if v != want && !(math.IsNaN(v) && math.IsNaN(want)) {
+						t.Errorf("%s: %s = %g, want %g from %s", rt, cd.Name, v, want, save)
+					}
+				}
+				for _, ef := range cs.Effects {
+					te := rl.CellFloat(EffectCol(ef.Name, rt), 0)
+					if math.IsNaN(te) {
+						t.Errorf("%s: %s = NaN", rt, ef.Name)
+					}
+					want := rl.CellFloat(EffectMemCol(ef.RP, rt), 0) - rl.CellFloat(EffectMemCol(ef.Restudy, rt), 0)
+					if te != want {
+						t.Errorf("%s: %s = %g, want %g", rt, ef.Name, te, want)
+					}
+				}
+			}
+			for _, ef := range cs.Effects {
+				if cn := RSACol("CA3", ef.Name, "Diff"); math.IsNaN(rl.CellFloat(cn, 0)) {
+					t.Errorf("%s = NaN", cn)
+				}
+			}
+			if len(cs.Effects) > 0 && ss.RunStats.ColIdx(EffectCol(cs.Effects[0].Name, "full")+":CI95") < 0 {
+				t.Errorf("RunStats has no testing effect columns")
+			}
+			if len(cs.Effects) == 0 && rl.ColIdx(EffectCol("TE", "full")) >= 0 {
+				t.Errorf("RunLog has a TE column without a restudy condition")
+			}
+		})
+	}
+}
+
+func TestAddCI95(t *testing.T) {
+	dt := &etable.Table{}
+	dt.SetFromSchema(etable.Schema{
+		{"TE full:Count", etensor.FLOAT64, nil, nil},
+		{"TE full:Sem", etensor.FLOAT64, nil, nil},
+	}, 3)
+	for row, v := range [][2]float64{{2, 1}, {11, .5}, {1, 0}} {
+		dt.SetCellFloat("TE full:Count", row, v[0])
+		dt.SetCellFloat("TE full:Sem", row, v[1])
+	}
+	AddCI95(dt, []string{"TE full", "TE hip"})
+	if dt.ColIdx("TE hip:CI95") >= 0 {
+		t.Errorf("CI95 added for a column that is not described")
+	}
+	for row, want := range []float64{12.706, 2.228 * .5, math.NaN()} {
+		ci := dt.CellFloat("TE full:CI95", row)
+		if math.Abs(ci-want) > 1e-9 || math.IsNaN(want) != math.IsNaN(ci) {
+			t.Errorf("row %d: CI95 = %g, want %g", row, ci, want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/emer/empi/mpi"
+)
+
+// CmdArgs runs the sim from the command line, as set by the flags, returning
+// an error if the runs could not be done.  All the files it writes go to
+// its output directory (see OutFile), with a manifest.json of what it ran.
+func (ss *Sim) CmdArgs() (err error) {
+	ss.NoGui = true
+	var nogui bool
+	var saveEpcLog bool
+	var saveRunLog bool
+	var saveRPLog bool
+	var note string
+	var decay float64
+	var resume string
+	var search string
+	var psfile string
+	var design string
+	var actLays string
+	var cycVars string
+	var cycStages string
+	var factors Factors
+	flag.StringVar(&ss.ParamSet, "params", "", "ParamSet name to use -- must be valid name as listed in compiled-in params or loaded params")
+	flag.StringVar(&psfile, "paramsfile", "", "JSON file of a ParamSet (e.g., the best of a -search) added to the compiled-in ones, to use with -params")
+	flag.StringVar(&ss.Tag, "tag", "", "extra tag to add to file names saved from this run")
+	flag.StringVar(&note, "note", "", "user note -- describe the run params etc")
+	flag.StringVar(&ss.Protocol, "protocol", "", "experiment protocol to run: name of a built-in protocol (Short, Long, Delay, Feedback) or a protocol .json file -- defaults to the tag if it names a built-in protocol")
+	flag.IntVar(&ss.MaxRuns, "runs", 1, "number of runs to do")
+	flag.Int64Var(&ss.RndSeed, "seed", ss.RndSeed, "master random seed, from which the seeds of the pattern, weights and order streams of each run are derived -- recorded in the run log")
+	flag.IntVar(&ss.Dist.Workers, "workers", 1, "number of runs to do at once in each process, each with its own network -- 0 = number of CPUs -- the logs of the runs of the protocol (or of training) are merged in run order")
+	flag.BoolVar(&ss.Dist.MPI, "mpi", false, "if true, distribute runs over MPI ranks -- build with -tags mpi and run under mpirun")
+	flag.IntVar(&ss.Dist.Procs, "procs", 1, "without -mpi, number of local worker processes to distribute runs over")
+	flag.StringVar(&ss.Dist.Shard, "shard", "", "rank/n of a worker process -- set by the parent for -procs")
+	flag.StringVar(&ss.Dist.ShardLog, "shardlog", "", "file name prefix for the logs of a worker process -- set by the parent for -procs")
+	flag.IntVar(&ss.MaxEpcs, "epcs", 1, "maximum number of epochs to run (split between AB / AC)")
+	flag.IntVar(&ss.PreTrainEpcs, "preepcs", 1, "maximum number of epochs to run (split between AB / AC)")
+
+	flag.BoolVar(&ss.Pat.DriftCtxt, "drift", false, "if true, use drifting context: each item's context drifts by DriftPct from the previous item's")
+	flag.BoolVar(&ss.Pat.Permute, "permute", false, "if true, study items in a new random order each epoch (ignored with -drift)")
+	flag.IntVar(&ss.Ret.FillerEpcs, "fillerepcs", 1, "number of epochs of study of each filler list in a retention interval (delay) step")
+	flag.Float64Var(&decay, "decay", 0.05, "proportion by which learned weights decay toward their initial mean in each retention interval (delay) step")
+	flag.StringVar(&ss.RP.Feedback, "feedback", "none", "retrieval practice feedback: none (own recall), full (target clamped) or delayed (targets after each epoch of practice)")
+	flag.StringVar(&ss.RP.ParamSet, "rpparams", "RP", "ParamSet applied during retrieval practice only -- empty for none")
+	flag.BoolVar(&ss.Record, "record", false, "if true, record the ECout activity of each test trial into the Autoin column of TrainNoise, for auto-encoder training (for models that use it, e.g., hip_ae)")
+	flag.StringVar(&ss.MemLay, "memlay", "", "layer scored for Mem: ECout or Output -- defaults to the model's")
+	flag.BoolVar(&ss.LogSetParams, "setparams", false, "if true, print a record of each parameter that is set")
+	flag.BoolVar(&ss.SaveWts, "wts", false, "if true, save final weights after each run")
+	flag.StringVar(&ss.SnapFile, "snapshot", "", "if set, save a snapshot of the whole simulation after each protocol stage to this file name, with the run number added (e.g., name_run000.snap), which -resume can continue from")
+	flag.StringVar(&resume, "resume", "", "snapshot file to resume the protocol that it was saved from -- continues the one run of the snapshot, ignoring -runs, -workers, -procs and -mpi")
+	flag.StringVar(&search, "search", "", "JSON file of a parameter search (see Search): runs the protocol (default Short) for each candidate, and saves the ranked candidates and the best ParamSet")
+	flag.StringVar(&design, "design", "", "JSON file of a crossed design (see Design): runs the protocol (default Short) for -runs runs of each cell, and saves the logs with a column per factor")
+	flag.Var(&factors, "factor", "a factor of a crossed design, as name=paramset1,paramset2,... -- repeat for each factor, added to those of -design")
+	flag.StringVar(&ss.OutDir, "outdir", "", "output directory for all the logs, weights, checkpoints and snapshots, and the manifest.json of the run -- default <net>_<tag>_<yyyymmdd-hhmmss>")
+	flag.StringVar(&ss.CkptDir, "ckptdir", "", "directory for the weights checkpoints saved after each protocol stage -- default the output directory")
+	flag.StringVar(&ss.Acts.Format, "acts", "", "if set, record the activity (ActQ1, ActM, ActP) of the -actlays layers on each item of each study, rp, restudy and test stage in the activity log, saved after each run in this format: tsv or bin")
+	flag.StringVar(&actLays, "actlays", strings.Join(ss.Acts.Lays, ","), "comma-separated layers whose activity -acts records")
+	flag.BoolVar(&ss.Acts.RSA, "rsa", false, "if true, compute the representational similarity analysis of DG, CA3, CA1, ECout and Cortex in each study, rp, restudy and test stage, recorded in the run log and saved after each run to the RSA log")
+	flag.StringVar(&cycVars, "cyclog", "", "comma-separated layer variables, as <layer> <var> (e.g., \"CA3 Act,Output ActAvg,ECout Ge\"), whose mean over the layer is recorded on each cycle of the -cycstages stages, and streamed to a cycle log file for each run")
+	flag.StringVar(&cycStages, "cycstages", strings.Join(ss.Cyc.Stages, ","), "comma-separated protocol stages (by Do) that -cyclog records in")
+	flag.BoolVar(&ss.WtChg.On, "wtchg", false, "if true, record the weight changes of each learning projection in each pretrain, study, rp, restudy and delay stage -- mean |dWt|, synapses changed, and net change of the practiced items' synapses -- saved after each run to the weight change log")
+	flag.BoolVar(&saveEpcLog, "epclog", false, "if true, save train epoch log to file")
+	flag.BoolVar(&saveRunLog, "runlog", false, "if true, save run epoch log to file")
+	flag.BoolVar(&saveRPLog, "rplog", false, "if true, save retrieval practice trial log (one row per attempt) to file")
+	flag.BoolVar(&nogui, "nogui", true, "if not passing any other args and want to run nogui, use nogui")
+	flag.Parse()
+	ss.Ret.Decay = float32(decay)
+	if ss.Acts.Format != "" {
+		if _, ok := ActFormats[ss.Acts.Format]; !ok {
+			return fmt.Errorf("hipbench: unknown -acts format: %s", ss.Acts.Format)
+		}
+		ss.Acts.On = true
+		ss.Acts.Lays = strings.Split(actLays, ",")
+	}
+	if ss.Acts.RSA {
+		ss.Acts.SaveRSA = true
+		ss.ConfigRunLog(ss.RunLog) // with the RSA columns
+	}
+	if cycVars != "" {
+		ss.Cyc.Vars = nil
+		for _, lv := range strings.Split(cycVars, ",") {
+			ss.Cyc.Vars = append(ss.Cyc.Vars, strings.Join(strings.Fields(lv), " "))
+		}
+		ss.Cyc.Stages = strings.Split(cycStages, ",")
+		ss.Cyc.Save = true
+		if err := ss.ValidateCycVars(); err != nil {
+			return err
+		}
+		ss.ConfigCycLog(ss.CycLog)
+	}
+	if psfile != "" {
+		if err := ss.OpenParamSet(psfile); err != nil {
+			return err
+		}
+	}
+	if ss.Dist.MPI {
+		ss.MPIInit()
+		defer mpi.Finalize()
+	}
+	ss.Init()
+	if ss.Protocol == "" {
+		if _, ok := Protocols[ss.Tag]; ok {
+			ss.Protocol = ss.Tag
+		}
+	}
+
+	start := time.Now()
+	if ss.Dist.MPI {
+		if start, err = ss.MasterTime(start); err != nil {
+			return err
+		}
+	}
+	if err := ss.MakeOutDir(start); err != nil {
+		return err
+	}
+	fmt.Printf("Saving to: %s\n", ss.OutDir)
+	if ss.IsMaster() {
+		mf := ss.NewManifest(start)
+		fnm := ss.OutFile("manifest.json")
+		if err := mf.SaveManifest(fnm); err != nil {
+			return err
+		}
+		defer func() {
+			mf.Done(time.Now(), err)
+			if serr := mf.SaveManifest(fnm); serr != nil && err == nil {
+				err = serr
+			}
+		}()
+	}
+
+	fmt.Printf("tag:" + ss.Tag + "\n")
+	if note != "" {
+		fmt.Printf("note: %s\n", note)
+	}
+	if ss.ParamSet != "" {
+		fmt.Printf("Using ParamSet: %s\n", ss.ParamSet)
+	}
+
+	if !ss.IsMaster() { // only the master saves logs
+		saveEpcLog = false
+		saveRunLog = false
+		saveRPLog = false
+	}
+	if saveEpcLog {
+		var err error
+		fnm := ss.LogFileName("epc")
+		ss.TstEpcFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.TstEpcFile = nil
+		} else {
+			fmt.Printf("Saving test epoch log to: %v\n", fnm)
+			defer ss.TstEpcFile.Close()
+		}
+	}
+	if saveRunLog {
+		var err error
+		fnm := ss.LogFileName("run")
+		ss.RunFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.RunFile = nil
+		} else {
+			fmt.Printf("Saving run log to: %v\n", fnm)
+			defer ss.RunFile.Close()
+		}
+	}
+	if saveRPLog {
+		var err error
+		fnm := ss.LogFileName("rp")
+		ss.RPTrlFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.RPTrlFile = nil
+		} else {
+			fmt.Printf("Saving retrieval practice trial log to: %v\n", fnm)
+			defer ss.RPTrlFile.Close()
+		}
+	}
+	if ss.SaveWts {
+		fmt.Printf("Saving final weights per run\n")
+	}
+	fmt.Printf("Running %d Runs\n", ss.MaxRuns)
+
+	if resume != "" {
+		if ss.MaxRuns > 1 || ss.Dist.Workers > 1 || ss.Dist.Distributed() {
+			log.Printf("hipbench: -resume continues the one run of the snapshot -- ignoring -runs, -workers, -procs and -mpi\n")
+		}
+		return ss.ResumeProtocol(resume)
+	}
+
+	var pr *Protocol
+	if ss.Protocol != "" {
+		var err error
+		pr, err = FindProtocol(ss.Protocol)
+		if err != nil {
+			return err
+		}
+		if err := ss.ValidateProtocol(pr); err != nil {
+			return err
+		}
+		ss.SetConds(pr) // the RunLog columns that the jobs' logs are merged into
+		fmt.Printf("Running protocol: %s\n", pr.Name)
+	}
+	if search != "" {
+		sr, err := OpenSearch(search)
+		if err != nil {
+			return err
+		}
+		if pr == nil {
+			pr = Protocols["Short"]
+		}
+		return ss.DoSearch(sr, pr)
+	}
+	if design != "" || len(factors) > 0 {
+		ds := &Design{}
+		if design != "" {
+			var err error
+			ds, err = OpenDesign(design)
+			if err != nil {
+				return err
+			}
+		}
+		ds.Factors = append(ds.Factors, factors...)
+		if pr == nil {
+			pr = Protocols["Short"]
+		}
+		return ss.DoDesign(ds, pr)
+	}
+	if pr == nil && ss.Model.CmdRun != nil {
+		return ss.Model.CmdRun(ss)
+	}
+	var run func(ss *Sim) error
+	if pr != nil {
+		run = func(rs *Sim) error { return rs.RunProtocol(pr) }
+	}
+	if err := ss.DoJobs(RunJobs(ss.MaxRuns), run); err != nil {
+		return err
+	}
+	if pr != nil && ss.IsMaster() {
+		ss.SaveStages(pr, ss.TstTrlLog)
+		fnm := ss.LogFileName("runstats")
+		if err := SaveCSV(ss.RunStats, fnm); err != nil {
+			return err
+		}
+		fmt.Printf("Saving run stats to: %v\n", fnm)
+	}
+	return nil
+}
+
+// Main configures TheSim for the given model and runs it -- from the command
+// line if there are any args, otherwise in the gui.  With report as the first
+// arg, it runs the report command instead (see ReportCmd).
+func Main(m *Model) {
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := ReportCmd(os.Args[2:]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	TheSim.Model = m
+	TheSim.New()
+	TheSim.Config()
+	if len(os.Args) > 1 {
+		cmdrun() // simple assumption is that any args = no gui -- could add explicit arg if you want
+	} else {
+		guirun()
+	}
+}
+
+// cmdrun runs TheSim from the command line, exiting with an error status if
+// the runs fail
+func cmdrun() {
+	if err := TheSim.CmdArgs(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emer/etable/etable"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Files
+//
+// These take plain file names, rather than the gi.FileName of the etable and
+// leabra methods, so that headless builds do not depend on the gui packages.
+
+// OpenCSV reads dt, with its columns configured from the headers, from the
+// tab-separated file fname
+func OpenCSV(dt *etable.Table, fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dt.ReadCSV(f, etable.Tab)
+}
+
+// SaveCSV writes dt, with headers, to the tab-separated file fname
+func SaveCSV(dt *etable.Table, fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = dt.WriteCSV(f, etable.Tab, etable.Headers)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// OpenTableBin reads dt from the gzipped gob file fname that SaveTableBin
+// saved it to -- with the shapes of its tensor columns, and at full precision
+func OpenTableBin(dt *etable.Table, fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	ts := &TableState{}
+	if err := gob.NewDecoder(gz).Decode(ts); err != nil {
+		return fmt.Errorf("%s: %v", fname, err)
+	}
+	ts.SetTable(dt)
+	return nil
+}
+
+// SaveTableBin writes dt, in binary form (a gzipped gob of its TableState),
+// to the file fname
+func SaveTableBin(dt *etable.Table, fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	err = gob.NewEncoder(gz).Encode(NewTableState(dt))
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// SaveWtsFile saves the weights of all the layers (see WriteCkpt) to the JSON
+// file fname, compressed if it ends in .gz
+func (ss *Sim) SaveWtsFile(fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !strings.HasSuffix(fname, ".gz") {
+		return ss.WriteCkpt(f)
+	}
+	gz := gzip.NewWriter(f)
+	err = ss.WriteCkpt(gz)
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// OpenWtsFile loads the weights from the JSON file fname, which is
+// decompressed if it ends in .gz
+func (ss *Sim) OpenWtsFile(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(fname, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return ss.Net.ReadWtsJSON(r)
+}
@@ -0,0 +1,443 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build cgo && !nogui
+// +build cgo,!nogui
+
+package hipbench
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/emer/emergent/netview"
+	"github.com/emer/etable/eplot"
+	"github.com/emer/etable/etable"
+	_ "github.com/emer/etable/etview" // include to get gui views
+	"github.com/emer/leabra/leabra"
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gimain"
+	"github.com/goki/gi/giv"
+	"github.com/goki/ki/ki"
+	"github.com/goki/mat32"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// 		Gui
+
+// Gui is the GoGi gui View of the Sim
+type Gui struct {
+	Sim     *Sim                     `desc:"the sim being viewed"`
+	Win     *gi.Window               `desc:"main GUI window"`
+	NetView *netview.NetView         `desc:"the network viewer"`
+	ToolBar *gi.ToolBar              `desc:"the master toolbar"`
+	Plots   map[string]*eplot.Plot2D `desc:"the log plots, by log name (e.g., TstTrlLog)"`
+}
+
+// SetNet sets the network shown in the NetView
+func (gu *Gui) SetNet(net *leabra.Network) {
+	gu.NetView.SetNet(net)
+	gu.NetView.Update() // issue #41 closed
+}
+
+// UpdateNet updates the NetView, if visible
+func (gu *Gui) UpdateNet(train bool) {
+	if gu.NetView.IsVisible() {
+		gu.NetView.Record(gu.Sim.Counters(train))
+		// note: essential to use Go version of update when called from another goroutine
+		gu.NetView.GoUpdate() // note: using counters is significantly slower..
+	}
+}
+
+// UpdatePlot updates the plot of the named log
+func (gu *Gui) UpdatePlot(lognm string) {
+	plt, ok := gu.Plots[lognm]
+	if !ok {
+		return
+	}
+	if lognm == "RunStats" {
+		gu.Sim.ConfigRunStatsPlot(plt, gu.Sim.RunStats)
+		if ep, ok := gu.Plots["Effect"]; ok {
+			gu.Sim.ConfigEffectPlot(ep, gu.Sim.RunStats)
+		}
+		return
+	}
+	plt.GoUpdate()
+}
+
+// Stopped updates the toolbar
+func (gu *Gui) Stopped() {
+	vp := gu.Win.WinViewport2D()
+	gu.ToolBar.UpdateActions()
+	vp.SetNeedsFullRender()
+}
+
+// AddPlot adds a tab with a plot of the named log, configured by cfg
+func (gu *Gui) AddPlot(tv *gi.TabView, lognm, tabnm string, dt *etable.Table, cfg func(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D) {
+	plt := tv.AddNewTab(eplot.KiT_Plot2D, tabnm).(*eplot.Plot2D)
+	if cfg != nil {
+		plt = cfg(plt, dt)
+	}
+	gu.Plots[lognm] = plt
+}
+
+// SaveWeights saves the network weights -- when called with giv.CallMethod
+// it will auto-prompt for filename
+func (ss *Sim) SaveWeights(filename gi.FileName) {
+	ss.Net.SaveWtsJSON(filename)
+}
+
+// ConfigGui configures the GoGi gui interface for this simulation,
+func (ss *Sim) ConfigGui() *gi.Window {
+	gu := &Gui{Sim: ss, Plots: map[string]*eplot.Plot2D{}}
+
+	width := 1600
+	height := 1200
+
+	gi.SetAppName(ss.Model.Name)
+	gi.SetAppAbout(`This demonstrates a basic Hippocampus model in Leabra. See <a href="https://github.com/emer/emergent">emergent on GitHub</a>.</p>`)
+
+	win := gi.NewMainWindow(ss.Model.Name, ss.Model.Title, width, height)
+	gu.Win = win
+
+	vp := win.WinViewport2D()
+	updt := vp.UpdateStart()
+
+	mfr := win.SetMainFrame()
+
+	tbar := gi.AddNewToolBar(mfr, "tbar")
+	tbar.SetStretchMaxWidth()
+	gu.ToolBar = tbar
+
+	split := gi.AddNewSplitView(mfr, "split")
+	split.Dim = mat32.X
+	split.SetStretchMax()
+
+	sv := giv.AddNewStructView(split, "sv")
+	sv.SetStruct(ss)
+
+	tv := gi.AddNewTabView(split, "tv")
+
+	nv := tv.AddNewTab(netview.KiT_NetView, "NetView").(*netview.NetView)
+	nv.Var = "Act"
+	// nv.Params.ColorMap = "Jet" // default is ColdHot
+	// which fares pretty well in terms of discussion here:
+	// https://matplotlib.org/tutorials/colors/colormaps.html
+	nv.SetNet(ss.Net)
+	gu.NetView = nv
+	nv.ViewDefaults()
+
+	gu.AddPlot(tv, "TrnTrlLog", "TrnTrlPlot", ss.TrnTrlLog, ss.ConfigTrnTrlPlot)
+	gu.AddPlot(tv, "TrnEpcLog", "TrnEpcPlot", ss.TrnEpcLog, ss.ConfigTrnEpcPlot)
+	gu.AddPlot(tv, "TstTrlLog", "TstTrlPlot", ss.TstTrlLog, ss.ConfigTstTrlPlot)
+	gu.AddPlot(tv, "RPTrlLog", "RPTrlPlot", ss.RPTrlLog, ss.ConfigRPTrlPlot)
+	gu.AddPlot(tv, "RSALog", "RSAPlot", ss.RSALog, ss.ConfigRSAPlot)
+	gu.AddPlot(tv, "TstEpcLog", "TstEpcPlot", ss.TstEpcLog, ss.ConfigTstEpcPlot)
+	gu.AddPlot(tv, "TstCycLog", "TstCycPlot", ss.TstCycLog, ss.ConfigTstCycPlot)
+	gu.AddPlot(tv, "RunLog", "RunPlot", ss.RunLog, ss.ConfigRunPlot)
+	gu.AddPlot(tv, "RunStats", "RunStatsPlot", ss.RunStats, nil) // configured by LogRunStats
+	gu.AddPlot(tv, "Effect", "EffectPlot", ss.RunStats, nil)     // configured by LogRunStats
+	ss.View = gu
+
+	split.SetSplits(.2, .8)
+
+	tbar.AddAction(gi.ActOpts{Label: "Init", Icon: "update", Tooltip: "Initialize everything including network weights, and start over.  Also applies current params.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		ss.Init()
+		vp.SetNeedsFullRender()
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Train", Icon: "run", Tooltip: "Starts the network training, picking up from wherever it may have left off.  If not stopped, training will complete the specified number of Runs through the full number of Epochs of training, with testing automatically occuring at the specified interval.",
+		UpdateFunc: func(act *gi.Action) {
+			act.SetActiveStateUpdt(!ss.IsRunning)
+		}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			// ss.Train()
+			go ss.Train()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "RP", Icon: "run", Tooltip: "Starts the network training, picking up from wherever it may have left off.  If not stopped, training will complete the specified number of Runs through the full number of Epochs of training, with testing automatically occuring at the specified interval.",
+		UpdateFunc: func(act *gi.Action) {
+			act.SetActiveStateUpdt(!ss.IsRunning)
+		}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			// ss.Train()
+			go func() {
+				if err := ss.RPRun(); err != nil {
+					log.Println(err)
+				}
+			}()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Restudy", Icon: "run", Tooltip: "Starts the network training, picking up from wherever it may have left off.  If not stopped, training will complete the specified number of Runs through the full number of Epochs of training, with testing automatically occuring at the specified interval.",
+		UpdateFunc: func(act *gi.Action) {
+			act.SetActiveStateUpdt(!ss.IsRunning)
+		}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			// ss.Train()
+			go ss.RestudyRun()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Stop", Icon: "stop", Tooltip: "Interrupts running.  Hitting Train again will pick back up where it left off.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		ss.Stop()
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Step Trial", Icon: "step-fwd", Tooltip: "Advances one training trial at a time.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			ss.TrainTrial()
+			ss.IsRunning = false
+			vp.SetNeedsFullRender()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Step Epoch", Icon: "fast-fwd", Tooltip: "Advances one epoch (complete set of training patterns) at a time.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.TrainEpoch()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Step Run", Icon: "fast-fwd", Tooltip: "Advances one full training Run at a time.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.TrainRun()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Pre Train", Icon: "fast-fwd", Tooltip: "Does full pretraining.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.PreTrain()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Run Protocol", Icon: "fast-fwd", Tooltip: "Runs the experiment protocol named in Protocol (built-in name or .json file).", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			pr, err := FindProtocol(ss.Protocol)
+			if err != nil {
+				gi.PromptDialog(vp, gi.DlgOpts{Title: "Protocol Error", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+				return
+			}
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go func() {
+				if err := ss.RunProtocol(pr); err != nil {
+					log.Println(err)
+				}
+			}()
+		}
+	})
+
+	tbar.AddSeparator("test")
+
+	tbar.AddAction(gi.ActOpts{Label: "Test Trial", Icon: "step-fwd", Tooltip: "Runs the next testing trial.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			ss.TestTrial(false) // don't return on trial -- wrap
+			ss.IsRunning = false
+			vp.SetNeedsFullRender()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Test Item", Icon: "step-fwd", Tooltip: "Prompts for a specific input pattern name to run, and runs it in testing mode.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		gi.StringPromptDialog(vp, "", "Test Item",
+			gi.DlgOpts{Title: "Test Item", Prompt: "Enter the Name of a given input pattern to test (case insensitive, contains given string."},
+			win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				dlg := send.(*gi.Dialog)
+				if sig == int64(gi.DialogAccepted) {
+					val := gi.StringPromptDialogValue(dlg)
+					idxs := ss.TestEnv.Table.RowsByString("Name", val, etable.Contains, etable.IgnoreCase)
+					if len(idxs) == 0 {
+						gi.PromptDialog(nil, gi.DlgOpts{Title: "Name Not Found", Prompt: "No patterns found containing: " + val}, gi.AddOk, gi.NoCancel, nil, nil)
+					} else {
+						if !ss.IsRunning {
+							ss.IsRunning = true
+							fmt.Printf("testing index: %v\n", idxs[0])
+							ss.TestItem(idxs[0])
+							ss.IsRunning = false
+							vp.SetNeedsFullRender()
+						}
+					}
+				}
+			})
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Test All", Icon: "fast-fwd", Tooltip: "Tests all of the testing trials.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.RunTestAll()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Test Long", Icon: "fast-fwd", Tooltip: "Tests all of the testing trials.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.RunTestAllLong()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Env", Icon: "gear", Tooltip: "select training input patterns: AB or AC."}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			giv.CallMethod(ss, "SetEnv", vp)
+		})
+
+	tbar.AddSeparator("log")
+
+	tbar.AddAction(gi.ActOpts{Label: "Reset RunLog", Icon: "reset", Tooltip: "Reset the accumulated log of all Runs, which are tagged with the ParamSet used"}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			ss.RunLog.SetNumRows(0)
+			gu.Plots["RunLog"].Update()
+		})
+
+	tbar.AddAction(gi.ActOpts{Label: "Rebuild Net", Icon: "reset", Tooltip: "Rebuild network with current params"}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			ss.ReConfigNet()
+		})
+
+	tbar.AddAction(gi.ActOpts{Label: "Run Stats", Icon: "file-data", Tooltip: "compute stats from run log -- avail in plot"}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			ss.LogRunStats()
+		})
+
+	tbar.AddSeparator("misc")
+
+	tbar.AddAction(gi.ActOpts{Label: "New Seed", Icon: "new", Tooltip: "Generate a new initial random seed to get different results.  By default, Init re-establishes the same initial seed every time."}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			ss.NewRndSeed()
+		})
+
+	for i := range ss.Model.Actions {
+		ma := &ss.Model.Actions[i]
+		tbar.AddAction(gi.ActOpts{Label: ma.Label, Icon: ma.Icon, Tooltip: ma.Tooltip, UpdateFunc: func(act *gi.Action) {
+			act.SetActiveStateUpdt(!ss.IsRunning)
+		}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if !ss.IsRunning {
+				ss.IsRunning = true
+				tbar.UpdateActions()
+				go ma.Func(ss)
+			}
+		})
+	}
+
+	tbar.AddAction(gi.ActOpts{Label: "README", Icon: "file-markdown", Tooltip: "Opens your browser on the README file that contains instructions for how to run this model."}, win.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			gi.OpenURL("https://github.com/emer/leabra/blob/master/examples/ra25/README.md")
+		})
+
+	vp.UpdateEndNoSig(updt)
+
+	// main menu
+	appnm := gi.AppName()
+	mmen := win.MainMenu
+	mmen.ConfigMenus([]string{appnm, "File", "Edit", "Window"})
+
+	amen := win.MainMenu.ChildByName(appnm, 0).(*gi.Action)
+	amen.Menu.AddAppMenu(win)
+
+	emen := win.MainMenu.ChildByName("Edit", 1).(*gi.Action)
+	emen.Menu.AddCopyCutPaste(win)
+
+	// note: Command in shortcuts is automatically translated into Control for
+	// Linux, Windows or Meta for MacOS
+	// fmen := win.MainMenu.ChildByName("File", 0).(*gi.Action)
+	// fmen.Menu.AddAction(gi.ActOpts{Label: "Open", Shortcut: "Command+O"},
+	// 	win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+	// 		FileViewOpenSVG(vp)
+	// 	})
+	// fmen.Menu.AddSeparator("csep")
+	// fmen.Menu.AddAction(gi.ActOpts{Label: "Close Window", Shortcut: "Command+W"},
+	// 	win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+	// 		win.Close()
+	// 	})
+
+	inQuitPrompt := false
+	gi.SetQuitReqFunc(func() {
+		if inQuitPrompt {
+			return
+		}
+		inQuitPrompt = true
+		gi.PromptDialog(vp, gi.DlgOpts{Title: "Really Quit?",
+			Prompt: "Are you <i>sure</i> you want to quit and lose any unsaved params, weights, logs, etc?"}, gi.AddOk, gi.AddCancel,
+			win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig == int64(gi.DialogAccepted) {
+					gi.Quit()
+				} else {
+					inQuitPrompt = false
+				}
+			})
+	})
+
+	// gi.SetQuitCleanFunc(func() {
+	// 	fmt.Printf("Doing final Quit cleanup here..\n")
+	// })
+
+	inClosePrompt := false
+	win.SetCloseReqFunc(func(w *gi.Window) {
+		if inClosePrompt {
+			return
+		}
+		inClosePrompt = true
+		gi.PromptDialog(vp, gi.DlgOpts{Title: "Really Close Window?",
+			Prompt: "Are you <i>sure</i> you want to close the window?  This will Quit the App as well, losing all unsaved params, weights, logs, etc"}, gi.AddOk, gi.AddCancel,
+			win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig == int64(gi.DialogAccepted) {
+					gi.Quit()
+				} else {
+					inClosePrompt = false
+				}
+			})
+	})
+
+	win.SetCloseCleanFunc(func(w *gi.Window) {
+		go gi.Quit() // once main window is closed, quit
+	})
+
+	win.MainMenuUpdated()
+	return win
+}
+
+// guirun runs TheSim in the gui
+func guirun() {
+	gimain.Main(func() { // this starts gui -- requires valid OpenGL display connection (e.g., X11)
+		TheSim.Init()
+		win := TheSim.ConfigGui()
+		win.StartEventLoop()
+	})
+}
@@ -0,0 +1,717 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/emer/etable/agg"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/etable/split"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// 		Logging
+
+// RunName returns a name for this run that combines Tag and Params -- add this to
+// any file names that are saved.
+func (ss *Sim) RunName() string {
+	return runName(ss.Tag, ss.ParamsName())
+}
+
+// runName returns the RunName of a run with the given Tag and ParamsName
+func runName(tag, pnm string) string {
+	if tag != "" {
+		if pnm == "Base" {
+			return tag
+		} else {
+			return tag + "_" + pnm
+		}
+	} else {
+		return pnm
+	}
+}
+
+// RunEpochName returns a string with the run and epoch numbers with leading zeros, suitable
+// for using in weights file names.  Uses 3, 5 digits for each.
+func (ss *Sim) RunEpochName(run, epc int) string {
+	return fmt.Sprintf("%03d_%05d", run, epc)
+}
+
+// WeightsFileName returns default current weights file name
+func (ss *Sim) WeightsFileName() string {
+	return ss.OutFile(ss.Net.Nm + "_" + ss.RunName() + "_" + ss.RunEpochName(ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur) + ".wts")
+}
+
+// AppendRow adds a copy of the given row of src to the end of dt, which must
+// have the same columns
+func AppendRow(dt, src *etable.Table, row int) {
+	dr := dt.Rows
+	dt.SetNumRows(dr + 1)
+	for _, cn := range src.ColNames {
+		dt.CopyCell(cn, dr, src, cn, row)
+	}
+}
+
+// LogFileName returns default log file name, in OutDir
+func (ss *Sim) LogFileName(lognm string) string {
+	return ss.OutFile(ss.Net.Nm + "_" + ss.RunName() + "_" + lognm + ".tsv")
+}
+
+//////////////////////////////////////////////
+//  TrnTrlLog
+
+// LogTrnTrl adds data from current trial to the TrnTrlLog table.
+// log always contains number of testing items
+func (ss *Sim) LogTrnTrl(dt *etable.Table) {
+	epc := ss.TrainEnv.Epoch.Cur
+	trl := ss.TrainEnv.Trial.Cur
+
+	row := dt.Rows
+	if trl == 0 { // reset at start
+		row = 0
+	}
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Epoch", row, float64(epc))
+	dt.SetCellFloat("Trial", row, float64(trl))
+	dt.SetCellString("TrialName", row, ss.TrainEnv.TrialName.Cur)
+	dt.SetCellFloat("SSE", row, ss.TrlSSE)
+	dt.SetCellFloat("AvgSSE", row, ss.TrlAvgSSE)
+	dt.SetCellFloat("CosDiff", row, ss.TrlCosDiff)
+
+	dt.SetCellFloat("Mem", row, ss.Mem)
+	dt.SetCellFloat("TrgOnWasOff", row, ss.TrgOnWasOffAll)
+	dt.SetCellFloat("TrgOffWasOn", row, ss.TrgOffWasOn)
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("TrnTrlLog")
+}
+
+func (ss *Sim) ConfigTrnTrlLog(dt *etable.Table) {
+	// inLay := ss.Net.LayerByName("Input").(leabra.LeabraLayer).AsLeabra()
+	// outLay := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+
+	dt.SetMetaData("name", "TrnTrlLog")
+	dt.SetMetaData("desc", "Record of training per input pattern")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	nt := ss.TestEnv.Table.Len() // number in view
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Trial", etensor.INT64, nil, nil},
+		{"TrialName", etensor.STRING, nil, nil},
+		{"SSE", etensor.FLOAT64, nil, nil},
+		{"AvgSSE", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+		{"TrgOnWasOff", etensor.FLOAT64, nil, nil},
+		{"TrgOffWasOn", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, nt)
+}
+
+//////////////////////////////////////////////
+//  TrnEpcLog
+
+// LogTrnEpc adds data from current epoch to the TrnEpcLog table.
+// computes epoch averages prior to logging.
+func (ss *Sim) LogTrnEpc(dt *etable.Table) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	epc := ss.TrainEnv.Epoch.Prv           // this is triggered by increment so use previous value
+	nt := float64(ss.TrainEnv.Table.Len()) // number of trials in view
+
+	ss.EpcSSE = ss.SumSSE / nt
+	ss.SumSSE = 0
+	ss.EpcAvgSSE = ss.SumAvgSSE / nt
+	ss.SumAvgSSE = 0
+	ss.EpcPctErr = float64(ss.CntErr) / nt
+	ss.CntErr = 0
+	ss.EpcPctCor = 1 - ss.EpcPctErr
+	ss.EpcCosDiff = ss.SumCosDiff / nt
+	ss.SumCosDiff = 0
+
+	trlog := ss.TrnTrlLog
+	tix := etable.NewIdxView(trlog)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Epoch", row, float64(epc))
+	dt.SetCellFloat("SSE", row, ss.EpcSSE)
+	dt.SetCellFloat("AvgSSE", row, ss.EpcAvgSSE)
+	dt.SetCellFloat("PctErr", row, ss.EpcPctErr)
+	dt.SetCellFloat("PctCor", row, ss.EpcPctCor)
+	dt.SetCellFloat("CosDiff", row, ss.EpcCosDiff)
+	dt.SetCellFloat("CA312", row, float64(ss.CA312))
+	dt.SetCellFloat("CA323", row, float64(ss.CA323))
+	dt.SetCellFloat("CA334", row, float64(ss.CA334))
+	mem := agg.Mean(tix, "Mem")[0]
+	dt.SetCellFloat("Mem", row, mem)
+	dt.SetCellFloat("TrgOnWasOff", row, agg.Mean(tix, "TrgOnWasOff")[0])
+	dt.SetCellFloat("TrgOffWasOn", row, agg.Mean(tix, "TrgOffWasOn")[0])
+
+	for _, lnm := range ss.LayStatNms {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		dt.SetCellFloat(ly.Nm+" ActAvg", row, float64(ly.Pools[0].ActAvg.ActPAvgEff))
+	}
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("TrnEpcLog")
+	if ss.TrnEpcFile != nil {
+		if !ss.TrnEpcHdrs {
+			dt.WriteCSVHeaders(ss.TrnEpcFile, etable.Tab)
+			ss.TrnEpcHdrs = true
+		}
+		dt.WriteCSVRow(ss.TrnEpcFile, row, etable.Tab)
+	}
+}
+
+func (ss *Sim) ConfigTrnEpcLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TrnEpcLog")
+	dt.SetMetaData("desc", "Record of performance over epochs of training")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"SSE", etensor.FLOAT64, nil, nil},
+		{"AvgSSE", etensor.FLOAT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+		{"TrgOnWasOff", etensor.FLOAT64, nil, nil},
+		{"TrgOffWasOn", etensor.FLOAT64, nil, nil},
+		{"CA312", etensor.FLOAT64, nil, nil},
+		{"CA323", etensor.FLOAT64, nil, nil},
+		{"CA334", etensor.FLOAT64, nil, nil},
+	}
+	for _, lnm := range ss.LayStatNms {
+		sch = append(sch, etable.Column{lnm + " ActAvg", etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+//////////////////////////////////////////////
+//  TstTrlLog
+
+// LogTstTrl adds data from current trial to the TstTrlLog table.
+// log always contains number of testing items
+func (ss *Sim) LogTstTrl(dt *etable.Table) {
+	epc := ss.TrainEnv.Epoch.Prv // this is triggered by increment so use previous value
+	trl := ss.TestEnv.Trial.Cur
+
+	row := dt.Rows
+	if len(ss.TstNms) > 0 && ss.TestNm == ss.TstNms[0] && trl == 0 { // reset at start
+		row = 0
+	}
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellString("Params", row, ss.RunName())
+	dt.SetCellFloat("Epoch", row, float64(epc))
+	dt.SetCellString("TestNm", row, ss.TestNm)
+	dt.SetCellString("Stage", row, ss.Stage)
+	dt.SetCellFloat("Delay", row, float64(ss.Delay))
+	dt.SetCellFloat("Trial", row, float64(row))
+	dt.SetCellString("TrialName", row, ss.TestEnv.TrialName.Cur)
+	dt.SetCellFloat("SSE", row, ss.TrlSSE)
+	dt.SetCellFloat("AvgSSE", row, ss.TrlAvgSSE)
+	dt.SetCellFloat("CosDiff", row, ss.TrlCosDiff)
+
+	dt.SetCellFloat("Mem", row, ss.Mem)
+	dt.SetCellFloat("TrgOnWasOff", row, ss.TrgOnWasOffAll)
+	dt.SetCellFloat("TrgOffWasOn", row, ss.TrgOffWasOn)
+	for pi := 0; pi < ss.NPools(); pi++ {
+		dt.SetCellTensorFloat1D("PoolMem", row, pi, poolVal(ss.PoolMem, pi))
+		dt.SetCellTensorFloat1D("PoolTrgOnWasOff", row, pi, poolVal(ss.PoolTrgOnWasOff, pi))
+		dt.SetCellTensorFloat1D("PoolTrgOffWasOn", row, pi, poolVal(ss.PoolTrgOffWasOn, pi))
+	}
+	if ss.Model.FamScore.On() {
+		dt.SetCellFloat("MemFam", row, ss.MemFam)
+		dt.SetCellFloat("TrgOnWasOffFam", row, ss.TrgOnWasOffAllFam)
+		dt.SetCellFloat("TrgOffWasOnFam", row, ss.TrgOffWasOnFam)
+		dt.SetCellFloat("Famil", row, ss.Famil)
+	}
+
+	for _, lnm := range ss.LayStatNms {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		dt.SetCellFloat(ly.Nm+" ActM.Avg", row, float64(ly.Pools[0].ActM.Avg))
+	}
+
+	if ss.TstTrlAll != nil {
+		AppendRow(ss.TstTrlAll, dt, row)
+	}
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("TstTrlLog")
+
+	if ss.TstTrialFile != nil {
+		if row == 0 {
+			dt.WriteCSVHeaders(ss.TstTrialFile, etable.Tab)
+		}
+		dt.WriteCSVRow(ss.TstTrialFile, row, etable.Tab)
+	}
+}
+
+func (ss *Sim) ConfigTstTrlLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstTrlLog")
+	dt.SetMetaData("desc", "Record of testing per input pattern")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	nt := ss.TestEnv.Table.Len() // number in view
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"TestNm", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Delay", etensor.INT64, nil, nil},
+		{"Trial", etensor.INT64, nil, nil},
+		{"TrialName", etensor.STRING, nil, nil},
+		{"SSE", etensor.FLOAT64, nil, nil},
+		{"AvgSSE", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+		{"TrgOnWasOff", etensor.FLOAT64, nil, nil},
+		{"TrgOffWasOn", etensor.FLOAT64, nil, nil},
+		{"CA312", etensor.FLOAT64, nil, nil},
+		{"CA323", etensor.FLOAT64, nil, nil},
+		{"CA334", etensor.FLOAT64, nil, nil},
+	}
+	for _, ps := range ss.TstPoolNms {
+		sch = append(sch, etable.Column{ps, etensor.FLOAT64, []int{ss.NPools()}, []string{"Pool"}})
+	}
+	if ss.Model.FamScore.On() {
+		sch = append(sch, etable.Schema{
+			{"MemFam", etensor.FLOAT64, nil, nil},
+			{"TrgOnWasOffFam", etensor.FLOAT64, nil, nil},
+			{"TrgOffWasOnFam", etensor.FLOAT64, nil, nil},
+			{"Famil", etensor.FLOAT64, nil, nil},
+		}...)
+	}
+	for _, lnm := range ss.LayStatNms {
+		sch = append(sch, etable.Column{lnm + " ActM.Avg", etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, nt)
+}
+
+//////////////////////////////////////////////
+//  RPTrlLog
+
+// LogRPTrl adds a row for the current retrieval practice attempt to the
+// RPTrlLog table, which has all the attempts of the run: whether the item was
+// retrieved, the CA3 stability over the quarters, and the learning that
+// followed -- the mean |DWt| of each of the Model.DecayPrjns, before it is
+// applied at the start of the next trial
+func (ss *Sim) LogRPTrl(dt *etable.Table) {
+	item := ss.TrainEnv.TrialName.Cur
+	att := 1
+	for r := 0; r < dt.Rows; r++ {
+		if dt.CellString("TrialName", r) == item {
+			att++
+		}
+	}
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellString("Params", row, ss.RunName())
+	dt.SetCellFloat("Epoch", row, float64(ss.TrainEnv.Epoch.Cur))
+	dt.SetCellFloat("Trial", row, float64(ss.TrainEnv.Trial.Cur))
+	dt.SetCellString("TrialName", row, item)
+	dt.SetCellFloat("Attempt", row, float64(att))
+	dt.SetCellString("Feedback", row, ss.RP.Feedback)
+	dt.SetCellFloat("Mem", row, ss.Mem)
+	dt.SetCellFloat("TrgOnWasOff", row, ss.TrgOnWasOffAll)
+	dt.SetCellFloat("TrgOffWasOn", row, ss.TrgOffWasOn)
+	dt.SetCellFloat("CA312", row, float64(ss.CA312))
+	dt.SetCellFloat("CA323", row, float64(ss.CA323))
+	dt.SetCellFloat("CA334", row, float64(ss.CA334))
+	for _, pnm := range ss.Model.DecayPrjns {
+		dt.SetCellFloat(pnm+" dWt", row, ss.PrjnDWt(pnm))
+	}
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("RPTrlLog")
+}
+
+func (ss *Sim) ConfigRPTrlLog(dt *etable.Table) {
+	dt.SetMetaData("name", "RPTrlLog")
+	dt.SetMetaData("desc", "Record of retrieval practice per attempt")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Trial", etensor.INT64, nil, nil},
+		{"TrialName", etensor.STRING, nil, nil},
+		{"Attempt", etensor.INT64, nil, nil},
+		{"Feedback", etensor.STRING, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+		{"TrgOnWasOff", etensor.FLOAT64, nil, nil},
+		{"TrgOffWasOn", etensor.FLOAT64, nil, nil},
+		{"CA312", etensor.FLOAT64, nil, nil},
+		{"CA323", etensor.FLOAT64, nil, nil},
+		{"CA334", etensor.FLOAT64, nil, nil},
+	}
+	for _, pnm := range ss.Model.DecayPrjns {
+		sch = append(sch, etable.Column{pnm + " dWt", etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+//////////////////////////////////////////////
+//  TstEpcLog
+
+func (ss *Sim) LogTstEpc(dt *etable.Table) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	trl := ss.TstTrlLog
+	tix := etable.NewIdxView(trl)
+	epc := ss.TrainEnv.Epoch.Prv // ?
+
+	if ss.LastEpcTime.IsZero() {
+		ss.EpcPerTrlMSec = 0
+	} else {
+		iv := time.Now().Sub(ss.LastEpcTime)
+		nt := ss.TrainAB.Rows * 4 // 1 train and 3 tests
+		ss.EpcPerTrlMSec = float64(iv) / (float64(nt) * float64(time.Millisecond))
+	}
+	ss.LastEpcTime = time.Now()
+
+	// note: this shows how to use agg methods to compute summary data from another
+	// data table, instead of incrementing on the Sim
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Epoch", row, float64(epc))
+	dt.SetCellString("Stage", row, ss.Stage)
+	dt.SetCellFloat("Delay", row, float64(ss.Delay))
+	dt.SetCellFloat("PerTrlMSec", row, ss.EpcPerTrlMSec)
+	dt.SetCellFloat("SSE", row, agg.Sum(tix, "SSE")[0])
+	dt.SetCellFloat("AvgSSE", row, agg.Mean(tix, "AvgSSE")[0])
+	dt.SetCellFloat("PctErr", row, agg.PropIf(tix, "SSE", func(idx int, val float64) bool {
+		return val > 0
+	})[0])
+	dt.SetCellFloat("PctCor", row, agg.PropIf(tix, "SSE", func(idx int, val float64) bool {
+		return val == 0
+	})[0])
+	dt.SetCellFloat("CosDiff", row, agg.Mean(tix, "CosDiff")[0])
+
+	trix := etable.NewIdxView(trl)
+	spl := split.GroupBy(trix, []string{"TestNm"})
+	for _, ts := range ss.TstStatNms {
+		split.Agg(spl, ts, agg.AggMean)
+	}
+	for _, ps := range ss.TstPoolNms {
+		split.Agg(spl, ps, agg.AggMean)
+	}
+	ss.TstStats = spl.AggsToTable(etable.ColNameOnly)
+
+	for ri := 0; ri < ss.TstStats.Rows; ri++ {
+		tst := ss.TstStats.CellString("TestNm", ri)
+		for _, ts := range ss.TstStatNms {
+			dt.SetCellFloat(tst+" "+ts, row, ss.TstStats.CellFloat(ts, ri))
+		}
+		for _, ps := range ss.TstPoolNms {
+			dt.CopyCell(tst+" "+ps, row, ss.TstStats, ps, ri)
+		}
+	}
+
+	if ss.OldNew() {
+		ss.LogSDT(dt, row)
+	}
+
+	// base zero on testing performance of the list being trained!
+	var mem float64
+	if tn := ss.TrainTestNm(); tn != "" {
+		mem = dt.CellFloat(tn+" Mem", row)
+	}
+	if ss.FirstZero < 0 && mem == 1 {
+		ss.FirstZero = epc
+	}
+	if mem == 1 {
+		ss.NZero++
+	} else {
+		ss.NZero = 0
+	}
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("TstEpcLog")
+	if ss.TstEpcFile != nil {
+		if !ss.TstEpcHdrs {
+			dt.WriteCSVHeaders(ss.TstEpcFile, etable.Tab)
+			ss.TstEpcHdrs = true
+		}
+		dt.WriteCSVRow(ss.TstEpcFile, row, etable.Tab)
+	}
+}
+
+// TrainTestNm returns the name of the test of the list being trained: AC when
+// training on TrainAC, and otherwise the first test (AB)
+func (ss *Sim) TrainTestNm() string {
+	if ss.TrainEnv.Table != nil && ss.TrainEnv.Table.Table == ss.TrainAC && ss.Model.Test("AC") != nil {
+		return "AC"
+	}
+	if len(ss.TstNms) > 0 {
+		return ss.TstNms[0]
+	}
+	return ""
+}
+
+func (ss *Sim) ConfigTstEpcLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstEpcLog")
+	dt.SetMetaData("desc", "Summary stats for testing trials")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Delay", etensor.INT64, nil, nil},
+		{"PerTrlMSec", etensor.FLOAT64, nil, nil},
+		{"SSE", etensor.FLOAT64, nil, nil},
+		{"AvgSSE", etensor.FLOAT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+	}
+	for _, tn := range ss.TstNms {
+		for _, ts := range ss.TstStatNms {
+			sch = append(sch, etable.Column{tn + " " + ts, etensor.FLOAT64, nil, nil})
+		}
+		for _, ps := range ss.TstPoolNms {
+			sch = append(sch, etable.Column{tn + " " + ps, etensor.FLOAT64, []int{ss.NPools()}, []string{"Pool"}})
+		}
+	}
+	if ss.OldNew() {
+		sch = append(sch, SDTSchema()...)
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// NPools returns the number of pools in the EC-sized layers, for the per-pool stats
+func (ss *Sim) NPools() int {
+	return ss.Hip.ECSize.Y * ss.Hip.ECSize.X
+}
+
+// poolVal returns vals[pi], or NaN if the pool was not scored
+func poolVal(vals []float64, pi int) float64 {
+	if pi >= len(vals) {
+		return math.NaN()
+	}
+	return vals[pi]
+}
+
+//////////////////////////////////////////////
+//  TstCycLog
+
+// LogTstCyc adds data from current trial to the TstCycLog table.
+// log just has 100 cycles, is overwritten
+func (ss *Sim) LogTstCyc(dt *etable.Table, cyc int) {
+	if dt.Rows <= cyc {
+		dt.SetNumRows(cyc + 1)
+	}
+
+	dt.SetCellFloat("Cycle", cyc, float64(cyc))
+	for _, lnm := range ss.LayStatNms {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		dt.SetCellFloat(ly.Nm+" Ge.Avg", cyc, float64(ly.Pools[0].Inhib.Ge.Avg))
+		dt.SetCellFloat(ly.Nm+" Act.Avg", cyc, float64(ly.Pools[0].Inhib.Act.Avg))
+	}
+
+	if cyc%10 == 0 { // too slow to do every cyc
+		// note: essential to use Go version of update when called from another goroutine
+		ss.UpdatePlot("TstCycLog")
+	}
+}
+
+func (ss *Sim) ConfigTstCycLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstCycLog")
+	dt.SetMetaData("desc", "Record of activity etc over one trial by cycle")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	np := 100 // max cycles
+	sch := etable.Schema{
+		{"Cycle", etensor.INT64, nil, nil},
+	}
+	for _, lnm := range ss.LayStatNms {
+		sch = append(sch, etable.Column{lnm + " Ge.Avg", etensor.FLOAT64, nil, nil})
+		sch = append(sch, etable.Column{lnm + " Act.Avg", etensor.FLOAT64, nil, nil})
+	}
+	dt.SetFromSchema(sch, np)
+}
+
+//////////////////////////////////////////////
+//  RunLog
+
+// LogRun adds data from current run to the RunLog table, with the stats of
+// the FinalStage test if set, and otherwise of the last test.  A run without
+// any test still gets its row, with its seeds.
+func (ss *Sim) LogRun(dt *etable.Table) {
+
+	epclog := ss.TstEpcLog
+	epcix := etable.NewIdxView(epclog)
+	if ss.FinalStage != "" {
+		epcix.Filter(func(et *etable.Table, row int) bool {
+			return et.CellString("Stage", row) == ss.FinalStage
+		})
+	}
+	run := ss.TrainEnv.Run.Cur // this is NOT triggered by increment yet -- use Cur
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	params := ss.RunName() // includes tag
+
+	fzero := ss.FirstZero
+	if fzero < 0 {
+		fzero = ss.MaxEpcs
+	}
+
+	dt.SetCellFloat("Run", row, float64(run))
+	dt.SetCellString("Params", row, params)
+	dt.SetCellString("Stage", row, ss.FinalStage)
+	ss.LogSeeds(dt, row)
+	dt.SetCellFloat("NEpochs", row, float64(ss.TstEpcLog.Rows))
+	dt.SetCellFloat("FirstZero", row, float64(fzero))
+	if epcix.Len() > 0 {
+		ss.LogRunTest(dt, row, epcix)
+	}
+	ss.LogRunEffect(dt, row)
+	if ss.Acts.RSA {
+		ss.LogRunRSA(dt, row)
+	}
+
+	ss.LogRunStats()
+
+	// note: essential to use Go version of update when called from another goroutine
+	ss.UpdatePlot("RunLog")
+	if ss.RunFile != nil {
+		if row == 0 {
+			dt.WriteCSVHeaders(ss.RunFile, etable.Tab)
+		}
+		dt.WriteCSVRow(ss.RunFile, row, etable.Tab)
+	}
+}
+
+// LogRunTest records the test stats of the RunLog row: their mean over the
+// last test epoch in epcix
+func (ss *Sim) LogRunTest(dt *etable.Table, row int, epcix *etable.IdxView) {
+	// compute mean over last N epochs for run level
+	nlast := 1
+	if nlast > epcix.Len() {
+		nlast = epcix.Len()
+	}
+	epcix.Idxs = epcix.Idxs[epcix.Len()-nlast:]
+
+	dt.SetCellFloat("SSE", row, agg.Mean(epcix, "SSE")[0])
+	dt.SetCellFloat("AvgSSE", row, agg.Mean(epcix, "AvgSSE")[0])
+	dt.SetCellFloat("PctErr", row, agg.Mean(epcix, "PctErr")[0])
+	dt.SetCellFloat("PctCor", row, agg.Mean(epcix, "PctCor")[0])
+	dt.SetCellFloat("CosDiff", row, agg.Mean(epcix, "CosDiff")[0])
+
+	for _, tn := range ss.TstNms {
+		for _, ts := range ss.TstStatNms {
+			nm := tn + " " + ts
+			dt.SetCellFloat(nm, row, agg.Mean(epcix, nm)[0])
+		}
+	}
+	if ss.OldNew() {
+		for _, st := range SDTStats {
+			dt.SetCellFloat(st, row, agg.Mean(epcix, st)[0])
+		}
+		for _, rc := range []string{"ROC HR", "ROC FAR"} {
+			for ci, v := range agg.Mean(epcix, rc) {
+				dt.SetCellTensorFloat1D(rc, row, ci, v)
+			}
+		}
+	}
+}
+
+func (ss *Sim) ConfigRunLog(dt *etable.Table) {
+	dt.SetMetaData("name", "RunLog")
+	dt.SetMetaData("desc", "Record of performance at end of training")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"NEpochs", etensor.FLOAT64, nil, nil},
+		{"FirstZero", etensor.FLOAT64, nil, nil},
+		{"SSE", etensor.FLOAT64, nil, nil},
+		{"AvgSSE", etensor.FLOAT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+	}
+	sch = append(sch, SeedsSchema()...)
+	for _, tn := range ss.TstNms {
+		for _, ts := range ss.TstStatNms {
+			sch = append(sch, etable.Column{tn + " " + ts, etensor.FLOAT64, nil, nil})
+		}
+	}
+	if ss.OldNew() {
+		sch = append(sch, SDTSchema()...)
+	}
+	sch = append(sch, ss.Conds.Schema()...)
+	if ss.Acts.RSA {
+		sch = append(sch, ss.Conds.RSASchema(ss.Acts.RSALays)...)
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+//////////////////////////////////////////////
+//  RunStats
+
+// LogRunStats computes RunStats from RunLog data -- can be used for looking at prelim results
+func (ss *Sim) LogRunStats() {
+	dt := ss.RunLog
+	runix := etable.NewIdxView(dt)
+	spl := split.GroupBy(runix, []string{"Params"})
+	for _, tn := range ss.TstNms {
+		nm := tn + " " + "Mem"
+		split.Desc(spl, nm)
+	}
+	if ss.OldNew() {
+		split.Desc(spl, "DPrime")
+		split.Desc(spl, "AUC")
+	}
+	for _, cn := range ss.Conds.Cols() {
+		split.Desc(spl, cn)
+	}
+	rcs := ss.Conds.RSACols(ss.Acts.RSALays)
+	rsa := ss.Acts.RSA && len(rcs) > 0 && dt.ColIdx(rcs[0]) >= 0
+	if rsa {
+		for _, cn := range rcs {
+			split.Desc(spl, cn)
+		}
+	}
+	split.Desc(spl, "FirstZero")
+	split.Desc(spl, "NEpochs")
+	ss.RunStats = spl.AggsToTable(etable.AddAggName)
+	AddCI95(ss.RunStats, ss.Conds.Cols())
+	if rsa {
+		AddCI95(ss.RunStats, ss.Conds.RSATECols(ss.Acts.RSALays))
+	}
+	ss.UpdatePlot("RunStats")
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/params"
+	"github.com/emer/leabra/leabra"
+)
+
+// PoolSpec selects a contiguous range of pools for a pool-wise projection
+type PoolSpec struct {
+	SendStart int `desc:"starting pool index for sending connections"`
+	RecvStart int `desc:"starting pool index for recv connections"`
+	NPools    int `desc:"number of pools to connect"`
+}
+
+// Score selects the pools of a layer that MemStats compares against their targets.
+// Pools are numbered as in the pattern tables (PatMix), in the Hip.ECSize pool layout.
+type Score struct {
+	Layer string `desc:"layer whose ActM is compared to its Targ: ECout or Output -- empty = not scored"`
+	Pools []int  `desc:"pools to score -- nil = the completion pools of the pattern table being run, which are filled in its output but empty in its input"`
+	Cue   string `desc:"when Pools is nil, the pattern table whose completion pools are scored when the table being run has none (e.g., training on full patterns) -- empty = TestAB"`
+}
+
+// On returns true if this score is in use
+func (sc *Score) On() bool {
+	return sc.Layer != ""
+}
+
+// PatMix is the pool-wise vocabulary mix for the input and output columns of
+// one pattern table, as passed to patgen.MixPats
+type PatMix struct {
+	InCol  string   `desc:"name of the input column"`
+	OutCol string   `desc:"name of the output column"`
+	In     []string `desc:"vocab names for each input pool"`
+	Out    []string `desc:"vocab names for each output pool"`
+}
+
+// CmpPools returns the completion pools of the mix: those that are filled in
+// the output but empty in the input, so recall must complete them
+func (pm *PatMix) CmpPools() []int {
+	var pools []int
+	for pi, out := range pm.Out {
+		if out == "empty" {
+			continue
+		}
+		if pi >= len(pm.In) || pm.In[pi] == "empty" {
+			pools = append(pools, pi)
+		}
+	}
+	return pools
+}
+
+// Test is a named set of test items.  TestAll runs each of the Model.Tests in
+// turn, logging its trials under its Name, which prefixes its columns in
+// TstEpcLog, RunLog and RunStats (e.g., AB Mem).
+type Test struct {
+	Name string `desc:"name of the test, recorded as TestNm"`
+	Pats string `desc:"pattern table of the test items, mixed as given in Model.Pats"`
+	Long string `desc:"pattern table of the long-cue version of the test, run by TestAllLong -- empty = Pats"`
+}
+
+// LongPats returns the pattern table for the long-cue test
+func (ts *Test) LongPats() string {
+	if ts.Long == "" {
+		return ts.Pats
+	}
+	return ts.Long
+}
+
+// Model declares how one bench variant differs from the shared hippocampus +
+// cortex model.  NewModel returns the standard (hip) configuration, and each
+// main package only overrides what it does differently before calling Main.
+type Model struct {
+	Name       string                    `desc:"short name, used for the app and window"`
+	Title      string                    `desc:"main window title"`
+	Params     params.Sets               `desc:"full collection of param sets"`
+	NetParams  params.Sheet              `desc:"extra Network selectors applied after Base, for params that differ from the shared defaults"`
+	OutType    emer.LayerType            `desc:"layer type of the Output layer"`
+	InToCtx    PoolSpec                  `desc:"pools projected from Input to Cortex"`
+	CtxToOut   PoolSpec                  `desc:"pools connected between Cortex and Output"`
+	OutRel     float32                   `desc:"ECout -> Output WtScale.Rel used when recall routes through the hippocampus"`
+	RPLays     []string                  `desc:"layers that retrieval practice trials apply from the env"`
+	DecayPrjns []string                  `desc:"learning projections whose weights decay in a retention interval (see DecayWts) -- not the non-learning mossy fibers (DGToCA3)"`
+	MemScore   Score                     `desc:"units scored for Mem"`
+	FamScore   Score                     `desc:"units scored for familiarity (MemFam) -- off if Layer is empty"`
+	Pats       map[string]PatMix         `desc:"pool mixes for each pattern table, by table name"`
+	Tests      []Test                    `desc:"test sets run by TestAll, in order -- the first is studied (AB) and starts a new TstTrlLog"`
+	Phases     map[string]*PhaseSchedule `desc:"alpha cycle schedules, by name -- see DefaultPhases"`
+	PreEpcs    int                       `desc:"default number of pretraining epochs"`
+	AEEpcs     int                       `desc:"default number of auto-encoder training epochs"`
+	Actions    []Action                  `desc:"model-specific toolbar actions"`
+
+	ConfigNet  func(ss *Sim, net *leabra.Network) `view:"-" desc:"adds model-specific layers and projections, before the network is built"`
+	ConfigPats func(ss *Sim)                      `view:"-" desc:"generates any model-specific pattern tables, after the shared ones"`
+	Route      func(ss *Sim)                      `view:"-" desc:"sets the Output projection scales for the final recall test -- defaults to Sim.RouteRecall"`
+	CmdRun     func(ss *Sim) error                `view:"-" desc:"what to run from the command line, without a protocol -- defaults to doing the runs of training"`
+}
+
+// NewModel returns the standard hippocampus + cortex configuration
+func NewModel(name string) *Model {
+	m := &Model{Name: name}
+	m.Title = "Hippocampus AB-AC"
+	m.Params = ParamSets
+	m.OutType = emer.Input
+	m.InToCtx = PoolSpec{SendStart: 0, RecvStart: 0, NPools: 2}
+	m.CtxToOut = PoolSpec{SendStart: 0, RecvStart: 1, NPools: 1}
+	m.OutRel = 0.3
+	m.RPLays = []string{"Input", "Output"}
+	m.DecayPrjns = []string{"ECinToCA1", "CA1ToECout", "ECoutToCA1", "ECinToDG", "ECinToCA3", "CA3ToCA3", "CA3ToCA1",
+		"InputToCortex", "CortexToOutput", "OutputToCortex"}
+	m.MemScore = Score{Layer: "Output"}
+	m.Phases = DefaultPhases()
+	m.PreEpcs = 1
+	m.AEEpcs = 10
+	m.Pats = map[string]PatMix{
+		"TrainAB":     {"Input", "Output", []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}, []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}},
+		"TrainNoise":  {"Input", "Cortex", []string{"A", "empty", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}, []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}},
+		"TestAB":      {"Input", "Output", []string{"A", "empty", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}, []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}},
+		"TestLong":    {"Input", "Output", []string{"A", "B", "empty", "ctxt2", "empty", "ctxt4"}, []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}},
+		"TrainRP":     {"Input", "Output", []string{"A", "empty", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}, []string{"A", "B", "ctxt1", "ctxt2", "ctxt3", "ctxt4"}},
+		"TrainAC":     {"Input", "Output", []string{"A", "C", "ctxt5", "ctxt6", "ctxt7", "ctxt8"}, []string{"A", "C", "ctxt5", "ctxt6", "ctxt7", "ctxt8"}},
+		"TestAC":      {"Input", "Output", []string{"A", "empty", "ctxt5", "ctxt6", "ctxt7", "ctxt8"}, []string{"A", "C", "ctxt5", "ctxt6", "ctxt7", "ctxt8"}},
+		"TestLure":    {"Input", "Output", []string{"lA", "empty", "ctxt9", "ctxt10", "ctxt11", "ctxt12"}, []string{"lA", "lB", "ctxt9", "ctxt10", "ctxt11", "ctxt12"}},
+		"TrainFiller": {"Input", "Output", []string{"fA", "fB", "fctxt1", "fctxt2", "fctxt3", "fctxt4"}, []string{"fA", "fB", "fctxt1", "fctxt2", "fctxt3", "fctxt4"}},
+	}
+	m.Tests = []Test{
+		{Name: "AB", Pats: "TestAB", Long: "TestLong"},
+		{Name: "AC", Pats: "TestAC"},
+		{Name: "Lure", Pats: "TestLure"},
+	}
+	return m
+}
+
+// AddTest registers a test set, replacing any test of the same name
+func (m *Model) AddTest(ts Test) {
+	for i := range m.Tests {
+		if m.Tests[i].Name == ts.Name {
+			m.Tests[i] = ts
+			return
+		}
+	}
+	m.Tests = append(m.Tests, ts)
+}
+
+// Test returns the test set of given name, or nil if there is none
+func (m *Model) Test(name string) *Test {
+	for i := range m.Tests {
+		if m.Tests[i].Name == name {
+			return &m.Tests[i]
+		}
+	}
+	return nil
+}
+
+// SetIn sets the input pool mix for the named pattern table
+func (m *Model) SetIn(tbl string, in ...string) {
+	pm := m.Pats[tbl]
+	pm.In = in
+	m.Pats[tbl] = pm
+}
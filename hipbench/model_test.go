@@ -0,0 +1,62 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCmpPools(t *testing.T) {
+	m := NewModel("hip_bench")
+	tests := []struct {
+		name string
+		pm   PatMix
+		want []int
+	}{
+		{"TestAB", m.Pats["TestAB"], []int{1}},
+		{"TestLong", m.Pats["TestLong"], []int{2, 4}},
+		{"TrainAB", m.Pats["TrainAB"], nil},
+		{"TestLure", m.Pats["TestLure"], []int{1}},
+		{"short input", PatMix{In: []string{"A"}, Out: []string{"A", "B", "ctxt1"}}, []int{1, 2}},
+		{"empty output", PatMix{In: []string{"A", "empty"}, Out: []string{"A", "empty"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pm.CmpPools(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CmpPools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScorePools(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	tests := []struct {
+		name  string
+		sc    Score
+		tbl   string
+		pools []int
+		cmp   map[int]bool
+	}{
+		{"test", Score{Layer: "Output"}, "TestAB", []int{1}, map[int]bool{1: true}},
+		{"long test", Score{Layer: "Output"}, "TestLong", []int{2, 4}, map[int]bool{2: true, 4: true}},
+		{"training default cue", Score{Layer: "Output"}, "TrainAB", []int{1}, map[int]bool{}},
+		{"training cue", Score{Layer: "Output", Cue: "TestLong"}, "TrainAB", []int{2, 4}, map[int]bool{}},
+		{"given pools", Score{Layer: "Output", Pools: []int{0, 1}}, "TestLong", []int{0, 1}, map[int]bool{2: true, 4: true}},
+		{"unknown table", Score{Layer: "Output"}, "NoSuchPats", []int{1}, map[int]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pools, cmp := ss.ScorePools(&tt.sc, tt.tbl)
+			if !reflect.DeepEqual(pools, tt.pools) {
+				t.Errorf("pools = %v, want %v", pools, tt.pools)
+			}
+			if !reflect.DeepEqual(cmp, tt.cmp) {
+				t.Errorf("cmp = %v, want %v", cmp, tt.cmp)
+			}
+		})
+	}
+}
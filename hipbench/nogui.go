@@ -0,0 +1,18 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !cgo || nogui
+// +build !cgo nogui
+
+package hipbench
+
+import (
+	"fmt"
+)
+
+// guirun runs TheSim from the command line, as there is no gui in this build
+func guirun() {
+	fmt.Printf("%s was built without the gui (CGO_ENABLED=0 or -tags nogui) -- running from the command line\n", TheSim.Model.Name)
+	cmdrun()
+}
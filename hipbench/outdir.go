@@ -0,0 +1,150 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/emer/emergent/params"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Output directory
+//
+// Every file that a command-line invocation writes -- logs, weights, checkpoints
+// and snapshots -- goes to its own output directory (Sim.OutDir), through
+// OutFile, along with a Manifest of what it ran.
+
+// OutFile returns the path of the file fnm in OutDir -- fnm itself if it is
+// absolute or OutDir is not set (as in the gui)
+func (ss *Sim) OutFile(fnm string) string {
+	if ss.OutDir == "" || filepath.IsAbs(fnm) {
+		return fnm
+	}
+	return filepath.Join(ss.OutDir, fnm)
+}
+
+// OutDirName returns the default OutDir of an invocation started at the given
+// time: <net>_<runname>_<yyyymmdd-hhmmss>
+func (ss *Sim) OutDirName(start time.Time) string {
+	return ss.Net.Nm + "_" + ss.RunName() + "_" + start.Format("20060102-150405")
+}
+
+// MakeOutDir creates OutDir, set to OutDirName(start) if it is empty
+func (ss *Sim) MakeOutDir(start time.Time) error {
+	if ss.OutDir == "" {
+		ss.OutDir = ss.OutDirName(start)
+	}
+	return os.MkdirAll(ss.OutDir, 0755)
+}
+
+// Manifest records what one command-line invocation ran, and how: it is saved
+// as manifest.json in its OutDir when it starts, and again when it ends.
+type Manifest struct {
+	Command     []string          `desc:"command line"`
+	Dir         string            `desc:"working directory"`
+	OutDir      string            `desc:"output directory"`
+	Model       string            `desc:"name of the Model"`
+	Module      string            `desc:"path of the main module"`
+	Version     string            `desc:"version of the main module -- (devel) if built from a source tree"`
+	GoVersion   string            `desc:"version of Go it was built with"`
+	Deps        map[string]string `desc:"versions of the modules it was built with, by path"`
+	Tag         string            `desc:"Tag of the runs"`
+	Protocol    string            `desc:"protocol run, if any"`
+	ParamSet    string            `desc:"ParamSet applied after Base"`
+	ExtraParams []string          `desc:"ParamSets applied after ParamSet"`
+	Params      []*params.Set     `desc:"the ParamSets applied, in order: Base, ParamSet and ExtraParams"`
+	NetParams   params.Sheet      `desc:"the Model's own Network params, applied after Base"`
+	Hip         HipParams         `desc:"effective hippocampus sizing parameters"`
+	Pat         PatParams         `desc:"effective pattern parameters"`
+	Ret         RetParams         `desc:"effective retention interval parameters"`
+	RP          RPParams          `desc:"effective retrieval practice parameters"`
+	Acts        ActParams         `desc:"activity patterns recorded"`
+	Cyc         CycParams         `desc:"cycle-level variables recorded"`
+	WtChg       WtChgParams       `desc:"weight change accounting"`
+	Settings    map[string]string `desc:"other effective run settings, by Sim field name"`
+	Seed        int64             `desc:"master random seed -- the seeds of each run are derived from it and the run number (see NewSeeds)"`
+	Seeds       []Seeds           `desc:"seeds of runs 0 to MaxRuns-1"`
+	Start       string            `desc:"time the invocation started (RFC 3339)"`
+	End         string            `desc:"time it ended -- empty while it runs"`
+	Error       string            `desc:"error it ended with, if any"`
+}
+
+// NewManifest returns the Manifest of the invocation started at the given time,
+// with the Sim's current (effective) settings
+func (ss *Sim) NewManifest(start time.Time) *Manifest {
+	mf := &Manifest{Command: os.Args, OutDir: ss.OutDir, Model: ss.Model.Name, GoVersion: runtime.Version(),
+		Tag: ss.Tag, Protocol: ss.Protocol, ParamSet: ss.ParamSet, ExtraParams: ss.ExtraParams,
+		NetParams: ss.Model.NetParams, Hip: ss.Hip, Pat: ss.Pat, Ret: ss.Ret, RP: ss.RP, Acts: ss.Acts, Cyc: ss.Cyc,
+		WtChg: ss.WtChg, Seed: ss.RndSeed, Start: start.Format(time.RFC3339)}
+	mf.Dir, _ = os.Getwd()
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		mf.Module, mf.Version = bi.Main.Path, bi.Main.Version
+		mf.Deps = map[string]string{}
+		for _, dp := range bi.Deps {
+			mf.Deps[dp.Path] = dp.Version
+		}
+	}
+	names := []string{"Base"}
+	if ss.ParamSet != "" && ss.ParamSet != "Base" {
+		names = append(names, ss.ParamSet)
+	}
+	for _, nm := range append(names, ss.ExtraParams...) {
+		if ps, err := ss.Params.SetByNameTry(nm); err == nil {
+			mf.Params = append(mf.Params, ps)
+		}
+	}
+	mf.Settings = map[string]string{
+		"MaxRuns":      fmt.Sprint(ss.MaxRuns),
+		"MaxEpcs":      fmt.Sprint(ss.MaxEpcs),
+		"PreTrainEpcs": fmt.Sprint(ss.PreTrainEpcs),
+		"MemLay":       ss.MemLay,
+		"MemThr":       fmt.Sprint(ss.MemThr),
+		"FamThr":       fmt.Sprint(ss.FamThr),
+		"CkptDir":      ss.CkptDir,
+		"SnapFile":     ss.SnapFile,
+	}
+	for run := 0; run < ss.MaxRuns; run++ {
+		mf.Seeds = append(mf.Seeds, NewSeeds(ss.RndSeed, run))
+	}
+	return mf
+}
+
+// Done records the end of the invocation at the given time, with its error, if any
+func (mf *Manifest) Done(end time.Time, err error) {
+	mf.End = end.Format(time.RFC3339)
+	if err != nil {
+		mf.Error = err.Error()
+	}
+}
+
+// OpenManifest loads a Manifest from a JSON file
+func OpenManifest(fname string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	mf := &Manifest{}
+	if err := json.Unmarshal(b, mf); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return mf, nil
+}
+
+// SaveManifest saves the Manifest to a JSON file
+func (mf *Manifest) SaveManifest(fname string) error {
+	b, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, b, 0644)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutFile(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "a.tsv")
+	tests := []struct {
+		dir  string
+		fnm  string
+		want string
+	}{
+		{"", "a.tsv", "a.tsv"},
+		{"out", "a.tsv", filepath.Join("out", "a.tsv")},
+		{"out", filepath.Join("sub", "a.tsv"), filepath.Join("out", "sub", "a.tsv")},
+		{"out", abs, abs},
+	}
+	for _, tt := range tests {
+		ss := &Sim{OutDir: tt.dir}
+		if f := ss.OutFile(tt.fnm); f != tt.want {
+			t.Errorf("OutDir %q: OutFile(%q) = %q, want %q", tt.dir, tt.fnm, f, tt.want)
+		}
+	}
+}
+
+func TestMakeOutDir(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	start := time.Date(2020, 3, 4, 5, 6, 7, 0, time.Local)
+	if err := ss.MakeOutDir(start); err != nil {
+		t.Fatal(err)
+	}
+	if want := "hip_bench_T_20200304-050607"; ss.OutDir != want {
+		t.Errorf("OutDir = %q, want %q", ss.OutDir, want)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, ss.OutDir)); err != nil || !fi.IsDir() {
+		t.Errorf("OutDir not made: %v", err)
+	}
+	ss.OutDir = filepath.Join(dir, "given")
+	if err := ss.MakeOutDir(start); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(ss.OutDir); err != nil || !fi.IsDir() {
+		t.Errorf("given OutDir not made: %v", err)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.MaxRuns = 3
+	ss.ParamSet = "RP"
+	ss.OutDir = t.TempDir()
+	start := time.Now()
+	mf := ss.NewManifest(start)
+	if len(mf.Seeds) != 3 || mf.Seeds[2] != NewSeeds(ss.RndSeed, 2) {
+		t.Errorf("Seeds = %v, want those of runs 0 to 2", mf.Seeds)
+	}
+	if len(mf.Params) != 2 || mf.Params[0].Name != "Base" || mf.Params[1].Name != "RP" {
+		t.Errorf("Params are not Base and RP")
+	}
+	if mf.End != "" {
+		t.Errorf("End = %q before Done", mf.End)
+	}
+	mf.Done(start.Add(time.Minute), os.ErrNotExist)
+	fnm := ss.OutFile("manifest.json")
+	if err := mf.SaveManifest(fnm); err != nil {
+		t.Fatal(err)
+	}
+	om, err := OpenManifest(fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(om.Command, mf.Command) || om.Tag != "T" || om.Model != "hip_bench" || om.Seed != ss.RndSeed ||
+		!reflect.DeepEqual(om.Seeds, mf.Seeds) || om.Hip != ss.Hip || om.Settings["MaxEpcs"] != "1" {
+		t.Errorf("opened manifest differs from the saved one")
+	}
+	if om.Start != mf.Start || om.End == "" || om.Error != os.ErrNotExist.Error() {
+		t.Errorf("Start, End, Error = %q, %q, %q", om.Start, om.End, om.Error)
+	}
+	if _, err := OpenManifest(filepath.Join(ss.OutDir, "none.json")); err == nil {
+		t.Errorf("OpenManifest of a missing file: no error")
+	}
+}
+
+func TestOutDirFiles(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.CkptDir = ""
+	ss.NoSave = false
+	ss.OutDir = t.TempDir()
+	ss.SnapFile = "s.snap"
+	ss.Init()
+	if err := ss.RunProtocol(testProtocol); err != nil {
+		t.Fatal(err)
+	}
+	for _, fnm := range []string{"T_final.tsv", filepath.Base(ss.CkptFileName("study")), filepath.Base(ss.SnapFileName())} {
+		if _, err := os.Stat(filepath.Join(ss.OutDir, fnm)); err != nil {
+			t.Errorf("%s not in OutDir: %v", fnm, err)
+		}
+	}
+	if fnm := ss.LogFileName("run"); !strings.HasPrefix(fnm, ss.OutDir) {
+		t.Errorf("LogFileName = %q, not in OutDir", fnm)
+	}
+}
@@ -0,0 +1,472 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"strconv"
+
+	"github.com/emer/emergent/evec"
+	"github.com/emer/emergent/params"
+)
+
+// LogPrec is precision for saving float values in logs
+const LogPrec = 4
+
+// ParamSets is the default collection of param sets shared by all models.
+// Base is always applied first, and RP is used for retrieval practice.
+var ParamSets = params.Sets{
+	{Name: "Base", Desc: "these are the best params", Sheets: params.Sheets{
+		"Network": &params.Sheet{
+			{Sel: "Prjn", Desc: "keeping default params for generic prjns",
+				Params: params.Params{
+					"Prjn.Learn.Momentum.On": "true",
+					"Prjn.Learn.Norm.On":     "true",
+					"Prjn.Learn.WtBal.On":    "false",
+				}},
+			{Sel: ".EcCa1Prjn", Desc: "encoder projections -- no norm, moment",
+				Params: params.Params{
+					"Prjn.Learn.Lrate":        "0.04",
+					"Prjn.Learn.Momentum.On":  "false",
+					"Prjn.Learn.Norm.On":      "false",
+					"Prjn.Learn.WtBal.On":     "true",
+					"Prjn.Learn.XCal.SetLLrn": "false", // using bcm now, better
+				}},
+			{Sel: ".HippoCHL", Desc: "hippo CHL projections -- no norm, moment, but YES wtbal = sig better",
+				Params: params.Params{
+					"Prjn.CHL.Hebb": "0.01",
+					//"Prjn.Learn.Lrate":       "0.2",
+					"Prjn.Learn.Lrate":       "0.1",
+					"Prjn.Learn.Momentum.On": "false",
+					"Prjn.Learn.Norm.On":     "false",
+					"Prjn.Learn.WtBal.On":    "true",
+				}},
+			{Sel: ".PPath", Desc: "perforant path, new Dg error-driven EcCa1Prjn prjns",
+				Params: params.Params{
+					"Prjn.Learn.Momentum.On": "false",
+					"Prjn.Learn.Norm.On":     "false",
+					"Prjn.Learn.WtBal.On":    "true",
+					"Prjn.Learn.Lrate":       "0.15", // err driven: .15 > .2 > .25 > .1
+					// moss=4, delta=4, lr=0.2, test = 3 are best
+				}},
+			{Sel: "#CA1ToECout", Desc: "extra strong from CA1 to ECout",
+				Params: params.Params{
+					"Prjn.WtScale.Abs": "4.0",
+				}},
+			{Sel: "#InputToECin", Desc: "one-to-one input to EC",
+				Params: params.Params{
+					"Prjn.Learn.Learn": "false",
+					"Prjn.WtInit.Mean": "0.8",
+					"Prjn.WtInit.Var":  "0.0",
+				}},
+			{Sel: "#ECoutToECin", Desc: "one-to-one out to in",
+				Params: params.Params{
+					"Prjn.Learn.Learn": "false",
+					"Prjn.WtInit.Mean": "0.9",
+					"Prjn.WtInit.Var":  "0.01",
+					"Prjn.WtScale.Rel": "0.5",
+				}},
+			{Sel: "#ECoutToOutput", Desc: "ECtoOUTPUT",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "0.3",
+					"Prjn.Learn.Learn": "false",
+				}},
+			{Sel: "#DGToCA3", Desc: "Mossy fibers: strong, non-learning",
+				Params: params.Params{
+					"Prjn.Learn.Learn": "false",
+					"Prjn.WtInit.Mean": "0.9",
+					"Prjn.WtInit.Var":  "0.01",
+					"Prjn.WtScale.Rel": "4",
+				}},
+			{Sel: "#CA3ToCA3", Desc: "CA3 recurrent cons",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "0.1",
+					"Prjn.Learn.Lrate": "0.05",
+					//"Prjn.Learn.Lrate": "0.1",
+				}},
+
+			{Sel: "#ECinToDG", Desc: "DG learning is surprisingly critical: maxed out fast, hebbian works best",
+				Params: params.Params{
+					"Prjn.Learn.Learn":       "true", // absolutely essential to have on!
+					"Prjn.CHL.Hebb":          ".2",   // .5 > 1 overall
+					"Prjn.CHL.SAvgCor":       "0.1",  // .1 > .2 > .3 > .4 ?
+					"Prjn.CHL.MinusQ1":       "true", // dg self err?
+					"Prjn.Learn.Lrate":       "0.1",  // .4 > .3 > .2
+					"Prjn.Learn.Momentum.On": "false",
+					"Prjn.Learn.Norm.On":     "false",
+					"Prjn.Learn.WtBal.On":    "true",
+				}},
+			{Sel: "#CA3ToCA1", Desc: "Schaffer collaterals -- slower, less hebb",
+				Params: params.Params{
+					"Prjn.CHL.Hebb":    "0.01",
+					"Prjn.CHL.SAvgCor": "0.4",
+					//"Prjn.Learn.Lrate":       "0.1",
+					"Prjn.Learn.Lrate":       "0.05",
+					"Prjn.Learn.Momentum.On": "false",
+					"Prjn.Learn.Norm.On":     "false",
+					"Prjn.Learn.WtBal.On":    "true",
+				}},
+			{Sel: ".EC", Desc: "all EC layers: only pools, no layer-level",
+				Params: params.Params{
+					"Layer.Act.Gbar.L":        ".1",
+					"Layer.Inhib.ActAvg.Init": "0.2",
+					"Layer.Inhib.Layer.On":    "false",
+					"Layer.Inhib.Pool.Gi":     "2.0",
+					"Layer.Inhib.Pool.On":     "true",
+				}},
+			{Sel: "#DG", Desc: "very sparse = high inibhition",
+				Params: params.Params{
+					"Layer.Inhib.ActAvg.Init": "0.01",
+					"Layer.Inhib.Layer.Gi":    "3.8",
+				}},
+			{Sel: "#CA3", Desc: "sparse = high inibhition",
+				Params: params.Params{
+					"Layer.Inhib.ActAvg.Init": "0.02",
+					"Layer.Inhib.Layer.Gi":    "2.8",
+				}},
+			{Sel: "#CA1", Desc: "CA1 only Pools",
+				Params: params.Params{
+					"Layer.Inhib.ActAvg.Init": "0.1",
+					"Layer.Inhib.Layer.On":    "false",
+					"Layer.Inhib.Pool.Gi":     "2.4",
+					"Layer.Inhib.Pool.On":     "true",
+				}},
+			{Sel: "#Output", Desc: "only Pools",
+				Params: params.Params{
+					"Layer.Inhib.ActAvg.Init": "0.1",
+					"Layer.Inhib.Layer.On":    "false",
+					"Layer.Inhib.Pool.Gi":     "2.0",
+					"Layer.Inhib.Pool.On":     "true",
+				}},
+			{Sel: "#Cortex", Desc: "using default 1.8 inhib for all of network -- can explore",
+				Params: params.Params{
+					"Layer.Inhib.Layer.Gi":     "1.8",
+					"Layer.Inhib.ActAvg.Init":  "0.088",
+					"Layer.Inhib.ActAvg.Fixed": "false",
+				}},
+			{Sel: "#OutputToECin", Desc: "cortical interference",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "0",
+					"Prjn.Learn.Learn": "false",
+				}},
+			{Sel: "#CortexToOutput", Desc: "cortical contribution",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "1",
+					"Prjn.Learn.Lrate": "0.1",
+				}},
+			{Sel: "#OutputToCortex", Desc: "cortical contribution",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "1",
+					"Prjn.Learn.Lrate": "0.1",
+				}},
+			{Sel: "#InputToCortex", Desc: "cortical contribution",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "1",
+					"Prjn.Learn.Lrate": "0.1",
+				}},
+		},
+	}},
+	{Name: "RP", Desc: "RP Param", Sheets: params.Sheets{
+		"Network": &params.Sheet{
+			{Sel: "#CA3ToCA1", Desc: "Schaffer collaterals -- slower, less hebb",
+				Params: params.Params{
+					"Prjn.CHL.MinusQ2": "true", // dg self err?
+				}},
+		},
+	}},
+}
+
+// HipParams have the hippocampus size and connectivity parameters
+type HipParams struct {
+	ECSize       evec.Vec2i `desc:"size of EC in terms of overall pools (outer dimension)"`
+	ECPool       evec.Vec2i `desc:"size of one EC pool"`
+	CA1Pool      evec.Vec2i `desc:"size of one CA1 pool"`
+	CA3Size      evec.Vec2i `desc:"size of CA3"`
+	DGRatio      float32    `desc:"size of DG / CA3"`
+	DGSize       evec.Vec2i `inactive:"+" desc:"size of DG"`
+	DGPCon       float32    `desc:"percent connectivity into DG"`
+	CA3PCon      float32    `desc:"percent connectivity into CA3"`
+	MossyPCon    float32    `desc:"percent connectivity into CA3 from DG"`
+	ECPctAct     float32    `desc:"percent activation in EC pool"`
+	MossyDel     float32    `desc:"delta in mossy effective strength between minus and plus phase"`
+	MossyDelTest float32    `desc:"delta in mossy strength for testing (relative to base param)"`
+}
+
+func (hp *HipParams) Update() {
+	hp.DGSize.X = int(float32(hp.CA3Size.X) * hp.DGRatio)
+	hp.DGSize.Y = int(float32(hp.CA3Size.Y) * hp.DGRatio)
+}
+
+// PatParams have the pattern parameters
+type PatParams struct {
+	ListSize    int     `desc:"number of A-B, A-C patterns each"`
+	MinDiffPct  float32 `desc:"minimum difference between item random patterns, as a proportion (0-1) of total active"`
+	DriftCtxt   bool    `desc:"use drifting context representations -- otherwise does bit flips from prototype"`
+	CtxtFlipPct float32 `desc:"proportion (0-1) of active bits to flip for each context pattern, relative to a prototype, for non-drifting"`
+	DriftPct    float32 `desc:"proportion (0-1) of active bits that drift, per item, for drifting context"`
+	Permute     bool    `desc:"study the items in a new random order each epoch -- ignored with DriftCtxt, where items are always studied (and tested) in the temporal order of their contexts"`
+}
+
+// Sequential returns true if items are to be studied in the order of the pattern tables
+func (pp *PatParams) Sequential() bool {
+	return pp.DriftCtxt || !pp.Permute
+}
+
+// RetParams are the parameters of the retention interval between practice and
+// the final test.  Each step of a delay stage studies a new filler list, and / or
+// decays the learned weights.
+type RetParams struct {
+	FillerEpcs int     `desc:"number of epochs of study of each filler list"`
+	Decay      float32 `desc:"proportion (0-1) by which each learned weight decays toward its initial mean, per delay step"`
+}
+
+func (rp *RetParams) Defaults() {
+	rp.FillerEpcs = 1
+	rp.Decay = 0.05
+}
+
+// RPParams are the parameters of retrieval practice
+type RPParams struct {
+	Feedback string `desc:"feedback after each retrieval attempt: none (the plus phase is the network's own recall), full (the target is clamped for the plus phase) or delayed (no feedback on the attempt, then the targets of all the items are clamped after each epoch of practice) -- see Feedbacks"`
+	ParamSet string `desc:"ParamSet whose Network sheet is applied during retrieval practice, and reverted after it -- empty for none"`
+}
+
+func (rp *RPParams) Defaults() {
+	rp.Feedback = "none"
+	rp.ParamSet = "RP"
+}
+
+// ActParams say which activity patterns are recorded in the ActLog
+type ActParams struct {
+	On      bool     `desc:"if true, record the activity of each of Lays on each trial of the study, rp, restudy and test stages of a protocol in the ActLog"`
+	Lays    []string `desc:"layers whose activity is recorded"`
+	Vars    []string `desc:"unit variables recorded for each layer"`
+	Format  string   `desc:"format the ActLog of each run is saved in, at the end of the run: tsv (etable CSV) or bin (etable binary, see SaveTableBin) -- empty = not saved -- see ActFormats"`
+	RSA     bool     `desc:"if true, compute the representational similarity analysis of the RSALays at the end of each run, in the RSALog and the RunLog (see RunRSA) -- their RSAVar is recorded in the ActLog even if not On"`
+	RSALays []string `desc:"layers of the representational similarity analysis"`
+	RSAVar  string   `desc:"unit variable of the representational similarity analysis"`
+	SaveRSA bool     `desc:"if true, save the RSALog of each run at the end of the run, to <net>_<tag>_run<run>_rsa.tsv"`
+}
+
+func (ap *ActParams) Defaults() {
+	ap.Lays = []string{"DG", "CA3", "CA1"}
+	ap.Vars = []string{"ActQ1", "ActM", "ActP"}
+	ap.RSALays = []string{"DG", "CA3", "CA1", "ECout", "Cortex"}
+	ap.RSAVar = "ActM"
+}
+
+// CycParams say what the CycLog records on each cycle, and in which stages
+type CycParams struct {
+	Vars   []string `desc:"layer variables recorded, as <layer> <var>: the mean over the units of the layer of a unit variable, e.g., CA3 Act, Output ActAvg, ECout Ge -- empty = none"`
+	Stages []string `desc:"protocol stages recorded in, by Do: study, rp, restudy, test, pretrain, delay"`
+	Save   bool     `desc:"if true, stream the CycLog rows of each run to <net>_<tag>_run<run>_cyc.tsv"`
+}
+
+// On returns true if the CycLog records in protocol stages that do Do
+func (cp *CycParams) On(do string) bool {
+	if len(cp.Vars) == 0 {
+		return false
+	}
+	for _, st := range cp.Stages {
+		if st == do {
+			return true
+		}
+	}
+	return false
+}
+
+// WtChgParams say how the WtChgLog accounts for the weight changes of each
+// stage
+type WtChgParams struct {
+	On     bool    `desc:"if true, record the weight changes of each of the Model.DecayPrjns in each pretrain, study, rp, restudy and delay stage of a protocol in the WtChgLog, saved at the end of each run to <net>_<tag>_run<run>_wtchg.tsv"`
+	Thr    float64 `desc:"a synapse counts as changed in a stage if its |dWt| over the stage is above this"`
+	ActThr float64 `desc:"a synapse is one of the practiced items if its sending and receiving units both have ActP above this on a training trial of one of them"`
+}
+
+func (wp *WtChgParams) Defaults() {
+	wp.Thr = 0.001
+	wp.ActThr = 0.5
+}
+
+// Recording returns true if the ActLog records activity: if On or RSA
+func (ap *ActParams) Recording() bool {
+	return ap.On || ap.RSA
+}
+
+// RecLays returns the layers whose activity the ActLog records: Lays if On,
+// and RSALays if RSA
+func (ap *ActParams) RecLays() []string {
+	var lays []string
+	if ap.On {
+		lays = append(lays, ap.Lays...)
+	}
+	if ap.RSA {
+		lays = append(lays, ap.RSALays...)
+	}
+	return uniqueNames(lays)
+}
+
+// RecVars returns the unit variables that the ActLog records: Vars if On,
+// and RSAVar if RSA
+func (ap *ActParams) RecVars() []string {
+	var vars []string
+	if ap.On {
+		vars = append(vars, ap.Vars...)
+	}
+	if ap.RSA {
+		vars = append(vars, ap.RSAVar)
+	}
+	return uniqueNames(vars)
+}
+
+// uniqueNames returns the names without repeats, in order
+func uniqueNames(names []string) []string {
+	var un []string
+	has := map[string]bool{}
+	for _, nm := range names {
+		if !has[nm] {
+			has[nm] = true
+			un = append(un, nm)
+		}
+	}
+	return un
+}
+
+func (pp *PatParams) Defaults() {
+	pp.ListSize = 30 // 10 is too small to see issues..
+	pp.MinDiffPct = 0.5
+	pp.CtxtFlipPct = 0.5
+	pp.DriftPct = .2
+}
+
+func (hp *HipParams) Defaults() {
+	// size
+	hp.ECSize.Set(2, 3)
+	hp.ECPool.Set(7, 7)
+	hp.CA1Pool.Set(10, 10)
+	hp.CA3Size.Set(20, 20)
+	hp.DGRatio = 1.5
+
+	// ratio
+	hp.DGPCon = 0.25 // .35 is sig worse, .2 learns faster but AB recall is worse
+	hp.CA3PCon = 0.25
+	hp.MossyPCon = 0.02 // .02 > .05 > .01 (for small net)
+	hp.ECPctAct = 0.2
+
+	hp.MossyDel = 4     // 4 > 2 -- best is 4 del on 4 rel baseline
+	hp.MossyDelTest = 3 // for rel = 4: 3 > 2 > 0 > 4 -- 4 is very bad -- need a small amount..
+}
+
+/////////////////////////////////////////////////////////////////////////
+//   Params setting
+
+// ParamsName returns name of current set of parameters
+func (ss *Sim) ParamsName() string {
+	if ss.ParamSet == "" {
+		return "Base"
+	}
+	return ss.ParamSet
+}
+
+// SetParams sets the params for "Base", then current ParamSet, then any ExtraParams.
+// If sheet is empty, then it applies all avail sheets (e.g., Network, Sim)
+// otherwise just the named sheet
+// if setMsg = true then we output a message for each param that was set.
+func (ss *Sim) SetParams(sheet string, setMsg bool) error {
+	if sheet == "" {
+		// this is important for catching typos and ensuring that all sheets can be used
+		ss.Params.ValidateSheets([]string{"Network", "Sim", "Hip", "Pat"})
+	}
+	err := ss.SetParamsSet("Base", sheet, setMsg)
+	if (sheet == "" || sheet == "Network") && len(ss.Model.NetParams) > 0 {
+		ss.Net.ApplyParams(&ss.Model.NetParams, setMsg)
+	}
+	if ss.ParamSet != "" && ss.ParamSet != "Base" {
+		err = ss.SetParamsSet(ss.ParamSet, sheet, setMsg)
+	}
+	for _, ps := range ss.ExtraParams {
+		err = ss.SetParamsSet(ps, sheet, setMsg)
+	}
+	return err
+}
+
+// ApplyParamsRevert applies the Network sheet of the given params.Set, and returns
+// a sheet that sets each parameter that it applied back to its prior value
+func (ss *Sim) ApplyParamsRevert(setNm string) (*params.Sheet, error) {
+	revert := &params.Sheet{}
+	pset, err := ss.Params.SetByNameTry(setNm)
+	if err != nil {
+		return revert, err
+	}
+	netp, ok := pset.Sheets["Network"]
+	if !ok {
+		return revert, nil
+	}
+	save := func(obj interface{}, name string) {
+		for _, sl := range *netp {
+			if !sl.TargetTypeMatch(obj) || !sl.SelMatch(obj) {
+				continue
+			}
+			rs := &params.Sel{Sel: "#" + name, Desc: "revert " + setNm, Params: params.Params{}}
+			for pt := range sl.Params {
+				v, err := params.GetParam(obj, sl.Params.Path(pt))
+				if err != nil {
+					continue
+				}
+				rs.Params[pt] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			*revert = append(*revert, rs)
+		}
+	}
+	for _, ly := range ss.Net.Layers {
+		save(ly, ly.Name())
+		for _, pj := range *ly.RecvPrjns() {
+			save(pj, pj.Name())
+		}
+	}
+	_, err = ss.Net.ApplyParams(netp, ss.LogSetParams)
+	return revert, err
+}
+
+// SetParamsSet sets the params for given params.Set name.
+// If sheet is empty, then it applies all avail sheets (e.g., Network, Sim)
+// otherwise just the named sheet
+// if setMsg = true then we output a message for each param that was set.
+func (ss *Sim) SetParamsSet(setNm string, sheet string, setMsg bool) error {
+	pset, err := ss.Params.SetByNameTry(setNm)
+	if err != nil {
+		return err
+	}
+	if sheet == "" || sheet == "Network" {
+		netp, ok := pset.Sheets["Network"]
+		if ok {
+			ss.Net.ApplyParams(netp, setMsg)
+		}
+	}
+
+	if sheet == "" || sheet == "Sim" {
+		simp, ok := pset.Sheets["Sim"]
+		if ok {
+			simp.Apply(ss, setMsg)
+		}
+	}
+
+	if sheet == "" || sheet == "Hip" {
+		simp, ok := pset.Sheets["Hip"]
+		if ok {
+			simp.Apply(&ss.Hip, setMsg)
+		}
+	}
+
+	if sheet == "" || sheet == "Pat" {
+		simp, ok := pset.Sheets["Pat"]
+		if ok {
+			simp.Apply(&ss.Pat, setMsg)
+		}
+	}
+
+	// note: if you have more complex environments with parameters, definitely add
+	// sheets for them, e.g., "TrainEnv", "TestEnv" etc
+	return err
+}
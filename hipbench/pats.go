@@ -0,0 +1,133 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/emer/emergent/patgen"
+	"github.com/emer/etable/etable"
+)
+
+// OpenPat opens a pattern table from a tab-separated file
+func (ss *Sim) OpenPat(dt *etable.Table, fname, name, desc string) {
+	err := OpenCSV(dt, fname)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	dt.SetMetaData("name", name)
+	dt.SetMetaData("desc", desc)
+}
+
+// ConfigPats generates the pool vocabulary and all the pattern tables
+func (ss *Sim) ConfigPats() {
+	hp := &ss.Hip
+	plY := hp.ECPool.Y // good idea to get shorter vars when used frequently
+	plX := hp.ECPool.X // makes much more readable
+	npats := ss.Pat.ListSize
+	pctAct := hp.ECPctAct
+	minDiff := ss.Pat.MinDiffPct
+	nOn := patgen.NFmPct(pctAct, plY*plX)
+	ctxtflip := patgen.NFmPct(ss.Pat.CtxtFlipPct, nOn)
+	patgen.AddVocabEmpty(ss.PoolVocab, "empty", npats, plY, plX)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "A", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "B", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "C", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "lA", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "lB", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "ctxt", 3, plY, plX, pctAct, minDiff) // totally diff
+
+	for i := 0; i < 12; i++ { // 12 contexts!
+		list := i / 4
+		ctxtNm := fmt.Sprintf("ctxt%d", i+1)
+		if ss.Pat.DriftCtxt {
+			// each item's context drifts from the previous item's, starting from
+			// the list prototype -- items are studied and tested in this order
+			patgen.AddVocabDrift(ss.PoolVocab, ctxtNm, npats, ss.Pat.DriftPct, "ctxt", list)
+			continue
+		}
+		tsr, _ := patgen.AddVocabRepeat(ss.PoolVocab, ctxtNm, npats, "ctxt", list)
+		patgen.FlipBitsRows(tsr, ctxtflip, ctxtflip, 1, 0)
+	}
+
+	ss.MixPats(ss.TrainAB, "TrainAB", "TrainAB Pats")
+	ss.MixPats(ss.TrainNoise, "TrainNoise", "TrainAB Noise")
+	ss.MixPats(ss.TestAB, "TestAB", "TestAB Pats")
+	ss.MixPats(ss.TestLong, "TestLong", "Test Long")
+	ss.MixPats(ss.TrainRP, "TrainRP", "RP Pats")
+	if _, ok := ss.Model.Pats["TrainAC"]; ok {
+		ss.MixPats(ss.TrainAC, "TrainAC", "TrainAC Pats")
+	}
+	done := map[string]bool{"TrainAB": true, "TrainNoise": true, "TestAB": true, "TestLong": true, "TrainRP": true}
+	for _, ts := range ss.Model.Tests {
+		for _, nm := range []string{ts.Pats, ts.LongPats()} {
+			if done[nm] {
+				continue
+			}
+			done[nm] = true
+			ss.MixPats(ss.PatsTable(nm), nm, ts.Name+" Test Pats")
+		}
+	}
+
+	ss.TrainAll = ss.TrainAB.Clone()
+
+	if ss.Model.ConfigPats != nil {
+		ss.Model.ConfigPats(ss)
+	}
+}
+
+// MixPats configures pattern table dt from the PoolVocab, using the
+// Model.Pats mix registered under name
+func (ss *Sim) MixPats(dt *etable.Table, name, desc string) {
+	hp := &ss.Hip
+	pm, ok := ss.Model.Pats[name]
+	if !ok {
+		log.Printf("hipbench: no pattern mix for table: %s\n", name)
+		return
+	}
+	patgen.InitPats(dt, name, desc, pm.InCol, pm.OutCol, ss.Pat.ListSize, hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X)
+	patgen.MixPats(dt, ss.PoolVocab, pm.InCol, pm.In)
+	patgen.MixPats(dt, ss.PoolVocab, pm.OutCol, pm.Out)
+}
+
+// PatsByName returns the pattern table of given name, or nil if there is none
+func (ss *Sim) PatsByName(name string) *etable.Table {
+	switch name {
+	case "TrainAB":
+		return ss.TrainAB
+	case "TrainAll":
+		return ss.TrainAll
+	case "TrainNoise":
+		return ss.TrainNoise
+	case "TrainRP":
+		return ss.TrainRP
+	case "TestAB":
+		return ss.TestAB
+	case "TestLong":
+		return ss.TestLong
+	case "TrainAC":
+		return ss.TrainAC
+	case "TrainFiller":
+		return ss.TrainFiller
+	case "TestAC":
+		return ss.TestAC
+	case "TestLure":
+		return ss.TestLure
+	}
+	return ss.MorePats[name]
+}
+
+// PatsTable returns the pattern table of given name, adding it to MorePats
+// if there is none
+func (ss *Sim) PatsTable(name string) *etable.Table {
+	dt := ss.PatsByName(name)
+	if dt == nil {
+		dt = &etable.Table{}
+		ss.MorePats[name] = dt
+	}
+	return dt
+}
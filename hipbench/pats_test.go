@@ -0,0 +1,86 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"testing"
+)
+
+// overlap returns the number of units active in both rows a and b of the
+// vocabulary item
+func overlap(vals []float32, n, a, b int) int {
+	ov := 0
+	for i := 0; i < n; i++ {
+		if vals[a*n+i] > 0 && vals[b*n+i] > 0 {
+			ov++
+		}
+	}
+	return ov
+}
+
+// isIdentity returns true if order is 0, 1, 2, ...
+func isIdentity(order []int) bool {
+	for i, o := range order {
+		if o != i {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDriftOrder(t *testing.T) {
+	const npats = 10
+	tests := []struct {
+		name    string
+		drift   bool
+		permute bool
+		seq     bool // items are studied in order
+	}{
+		{"drift", true, false, true},
+		{"drift permute", true, true, true},
+		{"fixed", false, false, true},
+		{"permute", false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &Sim{Model: NewModel("hip_bench")}
+			ss.New()
+			ss.Pat.ListSize = npats
+			ss.Pat.DriftCtxt = tt.drift
+			ss.Pat.Permute = tt.permute
+			ss.ViewOn = false
+			ss.NoThreads = true
+			ss.Config()
+			ss.Init()
+
+			for epc := 0; epc < 3; epc++ {
+				if isIdentity(ss.TrainEnv.Order) != tt.seq {
+					t.Errorf("epoch %d: order %v, sequential = %v", epc, ss.TrainEnv.Order, tt.seq)
+				}
+				cur := ss.TrainEnv.Epoch.Cur
+				for ss.TrainEnv.Epoch.Cur == cur {
+					ss.StepTrain()
+				}
+			}
+
+			if !tt.drift {
+				return
+			}
+			ctxt := ss.PoolVocab["ctxt1"]
+			n := ctxt.Len() / npats
+			near, far := 0, 0
+			for i := 0; i+1 < npats; i++ {
+				near += overlap(ctxt.Values, n, i, i+1)
+			}
+			for i := 0; i+npats/2 < npats; i++ {
+				far += overlap(ctxt.Values, n, i, i+npats/2)
+			}
+			nnear, nfar := float64(near)/float64(npats-1), float64(far)/float64(npats-npats/2)
+			if nnear <= nfar {
+				t.Errorf("mean context overlap of neighbouring items %g <= that of items %d apart %g", nnear, npats/2, nfar)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"github.com/emer/emergent/emer"
+)
+
+// Mossy selects the strength of the DG -> CA3 mossy fiber input (WtScale.Rel),
+// relative to its value at the start of the alpha cycle
+type Mossy int
+
+const (
+	// MossyKeep leaves the mossy fiber strength as it is
+	MossyKeep Mossy = iota
+
+	// MossyFull restores the full mossy fiber strength
+	MossyFull
+
+	// MossyDel reduces it by Hip.MossyDel
+	MossyDel
+
+	// MossyDelTest reduces it by Hip.MossyDelTest
+	MossyDelTest
+)
+
+// StatFunc computes stats at the end of a quarter
+type StatFunc func(ss *Sim, train bool)
+
+// Clamp copies the activity of one layer into the external input of another
+// during the alpha cycle, or clamps a layer to the pattern of the current
+// training trial.  Clamps are only applied when training.
+type Clamp struct {
+	From  string `desc:"layer whose Act values are copied -- if empty, To is instead clamped to the Col pattern of the current TrainEnv trial"`
+	To    string `desc:"layer that they are applied to"`
+	Cyc   int    `desc:"apply after this many cycles into the quarter -- 0 = at the end of the quarter, after the projection scales are updated"`
+	Table string `desc:"if set, To is instead clamped to the row of this pattern table (see PatsByName) that is closest to the From activity"`
+	Col   string `desc:"column of Table to search for the closest row, or of the TrainEnv table if From is empty"`
+}
+
+// Quarter is what happens within, and at the end of, one quarter of a PhaseSchedule
+type Quarter struct {
+	Cycles    int                `desc:"number of cycles in this quarter -- 0 = Time.CycPerQtr"`
+	Abs       map[string]float32 `desc:"WtScale.Abs for projections (by name, e.g., ECinToCA1), set at the end of the quarter"`
+	Mossy     Mossy              `desc:"mossy fiber strength set at the end of the quarter when training"`
+	TestMossy Mossy              `desc:"mossy fiber strength set at the end of the quarter when testing"`
+	Clamps    []Clamp            `desc:"layers clamped during or at the end of the quarter, in order"`
+	Stats     []StatFunc         `view:"-" desc:"stats computed after QuarterFinal"`
+}
+
+// PhaseSchedule declares one kind of alpha cycle: which projections learn,
+// which layers are on and clamped, and how the hippocampal projection scales
+// change from quarter to quarter.  Sim.AlphaCycPhase runs it.
+type PhaseSchedule struct {
+	Learn     map[string]bool           `desc:"Learn.Learn for projections, by name"`
+	Off       map[string]bool           `desc:"Off flag for layers, by name"`
+	Abs       map[string]float32        `desc:"WtScale.Abs for projections at the start of the cycle"`
+	Rel       map[string]float32        `desc:"WtScale.Rel for projections at the start of the cycle"`
+	TrainRel  map[string]float32        `desc:"WtScale.Rel for projections at the start of the cycle, only when training"`
+	Mossy     Mossy                     `desc:"mossy fiber strength at the start of the cycle"`
+	Types     map[string]emer.LayerType `desc:"layer types when training"`
+	TestTypes map[string]emer.LayerType `desc:"layer types when testing"`
+	Start     func(ss *Sim, train bool) `view:"-" desc:"called after the above are set, for anything that depends on the Sim state"`
+	Quarters  [4]Quarter                `desc:"each of the four quarters"`
+	EndAbs    map[string]float32        `desc:"WtScale.Abs for projections once the cycle is done"`
+}
+
+// HipLearn returns Learn flags for the hippocampal projections, with the
+// EC <-> CA1 encoder learning only if ec is true
+func HipLearn(ec bool) map[string]bool {
+	return map[string]bool{
+		"CA1ToECout": ec,
+		"ECinToCA1":  ec,
+		"ECoutToCA1": ec,
+		"ECinToDG":   true,
+		"ECinToCA3":  true,
+		"DGToCA3":    true,
+		"CA3ToCA1":   true,
+		"CA3ToCA3":   true,
+	}
+}
+
+// HipOff returns Off flags for the hippocampal layers and Cortex
+func HipOff(hip, cortex bool) map[string]bool {
+	return map[string]bool{
+		"CA1":    false,
+		"CA3":    hip,
+		"DG":     hip,
+		"ECin":   false,
+		"Cortex": cortex,
+	}
+}
+
+// StudySchedule is the standard study alpha cycle: CA1 is driven by ECin in
+// the first quarter, by CA3 recall in the second and third, and ECout and Output
+// are clamped from ECin for the plus phase
+func StudySchedule() *PhaseSchedule {
+	ps := &PhaseSchedule{
+		Learn:     HipLearn(true),
+		Off:       HipOff(false, false),
+		Abs:       map[string]float32{"ECinToCA1": 1, "CA3ToCA1": 0},
+		TrainRel:  map[string]float32{"ECoutToOutput": 0},
+		Mossy:     MossyDel,
+		Types:     map[string]emer.LayerType{"ECout": emer.Target, "Output": emer.Target},
+		TestTypes: map[string]emer.LayerType{"ECout": emer.Compare, "Output": emer.Target},
+		EndAbs:    map[string]float32{"CA3ToCA1": 1},
+	}
+	ps.Quarters[0] = Quarter{Abs: map[string]float32{"ECinToCA1": 0, "CA3ToCA1": 1}, Mossy: MossyFull, TestMossy: MossyDelTest}
+	ps.Quarters[2] = Quarter{Abs: map[string]float32{"ECinToCA1": 1, "CA3ToCA1": 0},
+		Clamps: []Clamp{{From: "ECin", To: "ECout"}, {From: "ECin", To: "Output"}},
+		Stats:  []StatFunc{(*Sim).MemStats}}
+	ps.Quarters[3] = Quarter{Stats: []StatFunc{func(ss *Sim, train bool) { ss.CA3COR() }}}
+	return ps
+}
+
+// PreTrainSchedule pretrains the EC <-> CA1 encoder, with DG, CA3 and Cortex off
+func PreTrainSchedule() *PhaseSchedule {
+	ps := StudySchedule()
+	ps.Off = HipOff(true, true)
+	ps.TrainRel = nil
+	ps.Types = map[string]emer.LayerType{"ECout": emer.Target}
+	ps.TestTypes = map[string]emer.LayerType{"ECout": emer.Compare}
+	ps.Quarters[2].Clamps = []Clamp{{From: "ECin", To: "ECout"}}
+	return ps
+}
+
+// RestudySchedule is hippocampal restudy, with Cortex off and the
+// EC <-> CA1 encoder not learning
+func RestudySchedule() *PhaseSchedule {
+	ps := PreTrainSchedule()
+	ps.Learn = HipLearn(false)
+	ps.Off = HipOff(false, true)
+	return ps
+}
+
+// RPSchedule is retrieval practice: ECout is recalled from a partial cue and then
+// clamped to the closest studied pattern for the plus phase, Output is driven by
+// Cortex and ECout recall, and the EC <-> CA1 encoder does not learn
+func RPSchedule() *PhaseSchedule {
+	ps := &PhaseSchedule{
+		Learn:     HipLearn(false),
+		Off:       HipOff(false, false),
+		Abs:       map[string]float32{"ECinToCA1": 1, "CA3ToCA1": 0},
+		Rel:       map[string]float32{"ECoutToOutput": 0, "CortexToOutput": 1},
+		Mossy:     MossyDel,
+		Types:     map[string]emer.LayerType{"ECout": emer.Target, "Output": emer.Target},
+		TestTypes: map[string]emer.LayerType{"ECout": emer.Compare, "Output": emer.Compare},
+		EndAbs:    map[string]float32{"CA3ToCA1": 1},
+	}
+	ps.Quarters[0] = Quarter{Abs: map[string]float32{"ECinToCA1": 0, "CA3ToCA1": 1}, Mossy: MossyDelTest, TestMossy: MossyDelTest}
+	ps.Quarters[2] = Quarter{Abs: map[string]float32{"ECinToCA1": 1, "CA3ToCA1": 0},
+		Clamps: []Clamp{{From: "ECout", To: "Output"}, {From: "ECout", To: "ECout", Table: "TrainAB", Col: "Output"}},
+		Stats:  []StatFunc{(*Sim).MemStats}}
+	ps.Quarters[3] = Quarter{Stats: []StatFunc{func(ss *Sim, train bool) { ss.CA3COR() }}}
+	return ps
+}
+
+// RPFullSchedule is retrieval practice with full feedback: as RPSchedule, but
+// ECout is clamped to the target pattern for the plus phase, and Output keeps
+// its target from the env
+func RPFullSchedule() *PhaseSchedule {
+	ps := RPSchedule()
+	ps.Quarters[2].Clamps = []Clamp{{To: "ECout", Col: "Output"}}
+	return ps
+}
+
+// DefaultPhases returns the standard schedules, by the names that
+// Sim.AlphaCycPhase is called with
+func DefaultPhases() map[string]*PhaseSchedule {
+	return map[string]*PhaseSchedule{
+		"Study":    StudySchedule(),
+		"PreTrain": PreTrainSchedule(),
+		"Restudy":  RestudySchedule(),
+		"RP":       RPSchedule(),
+		"RPFull":   RPFullSchedule(),
+	}
+}
+
+// Feedbacks are the phase schedules of each retrieval practice feedback
+// condition: that of each practice trial, and that of the feedback trials on
+// all of the items after each epoch of practice, if any
+var Feedbacks = map[string][2]string{
+	"none":    {"RP", ""},
+	"full":    {"RPFull", ""},
+	"delayed": {"RP", "RPFull"},
+}
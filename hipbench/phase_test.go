@@ -0,0 +1,102 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"testing"
+
+	"github.com/emer/emergent/emer"
+)
+
+func TestPhaseSchedules(t *testing.T) {
+	tests := []struct {
+		name     string
+		ps       *PhaseSchedule
+		ecLearn  bool               // EC <-> CA1 encoder learns
+		off      map[string]bool    // layers off
+		q2clamps []Clamp            // plus phase clamps
+		outType  emer.LayerType     // Output type when training (emer.Hidden = not set)
+		rel      map[string]float32 // Rel at the start of the cycle
+	}{
+		{"Study", StudySchedule(), true, map[string]bool{"DG": false, "CA3": false, "Cortex": false},
+			[]Clamp{{From: "ECin", To: "ECout"}, {From: "ECin", To: "Output"}}, emer.Target, nil},
+		{"PreTrain", PreTrainSchedule(), true, map[string]bool{"DG": true, "CA3": true, "Cortex": true},
+			[]Clamp{{From: "ECin", To: "ECout"}}, emer.Hidden, nil},
+		{"Restudy", RestudySchedule(), false, map[string]bool{"DG": false, "CA3": false, "Cortex": true},
+			[]Clamp{{From: "ECin", To: "ECout"}}, emer.Hidden, nil},
+		{"RP", RPSchedule(), false, map[string]bool{"DG": false, "CA3": false, "Cortex": false},
+			[]Clamp{{From: "ECout", To: "Output"}, {From: "ECout", To: "ECout", Table: "TrainAB", Col: "Output"}}, emer.Target,
+			map[string]float32{"ECoutToOutput": 0, "CortexToOutput": 1}},
+		{"RPFull", RPFullSchedule(), false, map[string]bool{"DG": false, "CA3": false, "Cortex": false},
+			[]Clamp{{To: "ECout", Col: "Output"}}, emer.Target,
+			map[string]float32{"ECoutToOutput": 0, "CortexToOutput": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := tt.ps
+			for _, pj := range []string{"CA1ToECout", "ECinToCA1", "ECoutToCA1"} {
+				if ps.Learn[pj] != tt.ecLearn {
+					t.Errorf("Learn[%s] = %v, want %v", pj, ps.Learn[pj], tt.ecLearn)
+				}
+			}
+			for _, pj := range []string{"ECinToDG", "ECinToCA3", "CA3ToCA1", "CA3ToCA3"} {
+				if !ps.Learn[pj] {
+					t.Errorf("Learn[%s] = false, want true", pj)
+				}
+			}
+			for ly, off := range tt.off {
+				if ps.Off[ly] != off {
+					t.Errorf("Off[%s] = %v, want %v", ly, ps.Off[ly], off)
+				}
+			}
+			cl := ps.Quarters[2].Clamps
+			if len(cl) != len(tt.q2clamps) {
+				t.Fatalf("quarter 2 clamps = %v, want %v", cl, tt.q2clamps)
+			}
+			for i := range cl {
+				if cl[i] != tt.q2clamps[i] {
+					t.Errorf("quarter 2 clamp %d = %v, want %v", i, cl[i], tt.q2clamps[i])
+				}
+			}
+			if typ, ok := ps.Types["Output"]; tt.outType == emer.Hidden && ok || tt.outType != emer.Hidden && typ != tt.outType {
+				t.Errorf("Types[Output] = %v, want %v", typ, tt.outType)
+			}
+			for pj, rel := range tt.rel {
+				if ps.Rel[pj] != rel {
+					t.Errorf("Rel[%s] = %v, want %v", pj, ps.Rel[pj], rel)
+				}
+			}
+			if ps.Abs["CA3ToCA1"] != 0 || ps.EndAbs["CA3ToCA1"] != 1 {
+				t.Errorf("CA3ToCA1 Abs = %v, EndAbs = %v, want 0, 1", ps.Abs["CA3ToCA1"], ps.EndAbs["CA3ToCA1"])
+			}
+			if ps.Quarters[0].Abs["CA3ToCA1"] != 1 || ps.Quarters[2].Abs["ECinToCA1"] != 1 {
+				t.Errorf("CA1 is not driven by CA3 in quarter 0 and by ECin in quarter 2")
+			}
+			if len(ps.Quarters[2].Stats) == 0 {
+				t.Errorf("no stats after quarter 2")
+			}
+		})
+	}
+}
+
+func TestDefaultPhases(t *testing.T) {
+	phs := DefaultPhases()
+	for fb, sch := range Feedbacks {
+		for _, nm := range sch {
+			if nm == "" {
+				continue
+			}
+			if _, ok := phs[nm]; !ok {
+				t.Errorf("feedback %s: no schedule %s", fb, nm)
+			}
+		}
+	}
+	// each call returns new schedules, which models can change on their own
+	a, b := DefaultPhases(), DefaultPhases()
+	a["Study"].Learn["ECinToDG"] = false
+	if !b["Study"].Learn["ECinToDG"] {
+		t.Errorf("DefaultPhases schedules are shared")
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build cgo && !nogui
+// +build cgo,!nogui
+
+package hipbench
+
+import (
+	"github.com/emer/etable/eplot"
+	"github.com/emer/etable/etable"
+)
+
+func (ss *Sim) ConfigTrnTrlPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Train Trial Plot"
+	plt.Params.XAxisCol = "Trial"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Trial", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("TrialName", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("SSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	plt.SetColParams("Mem", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOnWasOff", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOffWasOn", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	return plt
+}
+
+func (ss *Sim) ConfigTrnEpcPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Epoch Plot"
+	plt.Params.XAxisCol = "Epoch"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("SSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("PctErr", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("PctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	plt.SetColParams("Mem", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)         // default plot
+	plt.SetColParams("TrgOnWasOff", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+	plt.SetColParams("TrgOffWasOn", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+
+	for _, lnm := range ss.LayStatNms {
+		plt.SetColParams(lnm+" ActAvg", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 0.5)
+	}
+	return plt
+}
+
+func (ss *Sim) ConfigTstTrlPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Test Trial Plot"
+	plt.Params.XAxisCol = "TrialName"
+	plt.Params.Type = eplot.Bar
+	plt.SetTable(dt) // this sets defaults so set params after
+	plt.Params.BarWidth = 5
+	plt.Params.XAxisRot = 45
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("TestNm", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Trial", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("TrialName", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("SSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	plt.SetColParams("Mem", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOnWasOff", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOffWasOn", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	for _, lnm := range ss.LayStatNms {
+		plt.SetColParams(lnm+" ActM.Avg", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 0.5)
+	}
+
+	// plt.SetColParams("InAct", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	// plt.SetColParams("OutActM", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	// plt.SetColParams("OutActP", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	return plt
+}
+
+// ConfigRPTrlPlot plots each item's retrieval over its practice attempts
+func (ss *Sim) ConfigRPTrlPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Retrieval Practice Trial Plot"
+	plt.Params.XAxisCol = "Attempt"
+	plt.Params.LegendCol = "TrialName"
+	plt.SetTable(dt) // this sets defaults so set params after
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Trial", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Attempt", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+
+	plt.SetColParams("Mem", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOnWasOff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TrgOffWasOn", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CA312", eplot.Off, eplot.FloatMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CA323", eplot.Off, eplot.FloatMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CA334", eplot.Off, eplot.FloatMin, 0, eplot.FixMax, 1)
+	for _, pnm := range ss.Model.DecayPrjns {
+		plt.SetColParams(pnm+" dWt", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	}
+	return plt
+}
+
+// ConfigRSAPlot plots the stability and differentiation of the items in each
+// stage, by layer, from the RSALog -- its similarity matrices are shown as
+// grids in the RSALog table view
+func (ss *Sim) ConfigRSAPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus RSA Plot"
+	plt.Params.XAxisCol = "Stage"
+	plt.Params.LegendCol = "Layer"
+	plt.Params.Type = eplot.Bar
+	plt.Params.XAxisRot = 45
+	plt.SetTable(dt) // this sets defaults so set params after
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Stab", eplot.Off, eplot.FixMin, -1, eplot.FixMax, 1)
+	plt.SetColParams("Diff", eplot.On, eplot.FixMin, 0, eplot.FixMax, 2)
+	return plt
+}
+
+func (ss *Sim) ConfigTstEpcPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Testing Epoch Plot"
+	plt.Params.XAxisCol = "Epoch"
+	plt.SetTable(dt) // this sets defaults so set params after
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("PerTrlMSec", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("SSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("PctErr", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("PctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	for _, tn := range ss.TstNms {
+		for _, ts := range ss.TstStatNms {
+			if ts == "Mem" {
+				plt.SetColParams(tn+" "+ts, eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+			} else {
+				plt.SetColParams(tn+" "+ts, eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+			}
+		}
+	}
+	if ss.OldNew() {
+		plt.SetColParams("AUC", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+		plt.SetColParams("DPrime", eplot.On, eplot.FixMin, 0, eplot.FloatMax, 0)
+	}
+	return plt
+}
+
+func (ss *Sim) ConfigTstCycPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Test Cycle Plot"
+	plt.Params.XAxisCol = "Cycle"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Cycle", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	for _, lnm := range ss.LayStatNms {
+		plt.SetColParams(lnm+" Ge.Avg", eplot.On, eplot.FixMin, 0, eplot.FixMax, .5)
+		plt.SetColParams(lnm+" Act.Avg", eplot.On, eplot.FixMin, 0, eplot.FixMax, .5)
+	}
+	return plt
+}
+
+func (ss *Sim) ConfigRunPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Run Plot"
+	plt.Params.XAxisCol = "Run"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("NEpochs", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("FirstZero", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("SSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("PctErr", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("PctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+
+	for _, tn := range ss.TstNms {
+		for _, ts := range ss.TstStatNms {
+			if ts == "Mem" {
+				plt.SetColParams(tn+" "+ts, eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+			} else {
+				plt.SetColParams(tn+" "+ts, eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+			}
+		}
+	}
+	if ss.OldNew() {
+		plt.SetColParams("AUC", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+		plt.SetColParams("DPrime", eplot.On, eplot.FixMin, 0, eplot.FloatMax, 0)
+	}
+	return plt
+}
+
+func (ss *Sim) ConfigRunStatsPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Run Stats Plot"
+	plt.Params.XAxisCol = "Params"
+	plt.SetTable(dt)
+	plt.Params.BarWidth = 10
+	plt.Params.Type = eplot.Bar
+	plt.Params.XAxisRot = 45
+
+	for _, tn := range ss.TstNms {
+		cp := plt.SetColParams(tn+" Mem:Mean", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+		cp.ErrCol = tn + " Mem:Sem"
+	}
+	cp := plt.SetColParams("FirstZero:Mean", eplot.On, eplot.FixMin, 0, eplot.FixMax, 30)
+	cp.ErrCol = "FirstZero:Sem"
+	cp = plt.SetColParams("NEpochs:Mean", eplot.On, eplot.FixMin, 0, eplot.FixMax, 30)
+	cp.ErrCol = "NEpochs:Sem"
+	return plt
+}
+
+// ConfigEffectPlot plots the final-test Mem of each practice condition on each
+// route, and the testing effect, from RunStats, with their 95% confidence intervals
+func (ss *Sim) ConfigEffectPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Hippocampus Testing Effect Plot"
+	plt.Params.XAxisCol = "Params"
+	plt.SetTable(dt)
+	plt.Params.BarWidth = 10
+	plt.Params.Type = eplot.Bar
+	plt.Params.XAxisRot = 45
+
+	for _, cn := range ss.Conds.Cols() {
+		cp := plt.SetColParams(cn+":Mean", eplot.On, eplot.FixMin, -1, eplot.FixMax, 1)
+		cp.ErrCol = cn + ":CI95"
+	}
+	return plt
+}
@@ -0,0 +1,454 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// Stage is one step of an experiment Protocol.  Do is one of:
+// init, pretrain, study, rp, restudy, delay, test, load.
+type Stage struct {
+	Do       string `desc:"what to do: init (new weights), pretrain, study (TrainAB, or TrainAC), rp (retrieval practice on TrainRP), restudy (hippocampal restudy of TrainAB), delay (one step of retention interval), test, load (the weights of a checkpoint)"`
+	N        int    `desc:"number of times to repeat the stage -- 0 = 1 -- for delay, the number of delay steps"`
+	Mode     string `desc:"for delay: what each step does: filler (study a new filler list), decay (weight decay) or both -- default both"`
+	Feedback string `desc:"for rp: the feedback condition: none, full or delayed -- default Sim.RP.Feedback"`
+	Set      string `desc:"for study: which list to study: AB or AC -- for study, default AB -- for test: All runs all of the Model.Tests, Long their long-cue versions, and the name of one of the Model.Tests runs just that test -- default All"`
+	Route    string `desc:"for test: how recall reaches Output: full (hippocampus + cortex), hip (hippocampus only) or cortex (cortex only) -- default full"`
+	Save     string `desc:"for test: if set, the test trial log is saved to Tag_Save.tsv"`
+	Ckpt     string `desc:"for pretrain, study, rp, restudy: name of the checkpoint of the weights saved after the stage (see CkptStages) -- default the stage name (Do) -- for load: the checkpoint to load"`
+}
+
+// Protocol is a sequence of stages defining one experimental design, run by
+// Sim.RunProtocol.  Protocols can be saved and loaded as JSON.
+type Protocol struct {
+	Name   string  `desc:"name of the protocol"`
+	Desc   string  `desc:"description of the design"`
+	Stages []Stage `desc:"stages to run, in order"`
+}
+
+// TestSets are the test sets that a test stage can run, by name, besides each
+// of the Model.Tests, which runs on its own under its own name (see Sim.TestSet)
+var TestSets = map[string]func(ss *Sim){
+	"All":  (*Sim).TestAll,
+	"Long": (*Sim).TestAllLong,
+}
+
+// StudySets are the pattern tables that a study stage can train on, by set name
+var StudySets = map[string]string{
+	"AB": "TrainAB",
+	"AC": "TrainAC",
+}
+
+// DelayModes are the filler, decay settings for each delay mode name
+var DelayModes = map[string][2]bool{
+	"filler": {true, false},
+	"decay":  {false, true},
+	"both":   {true, true},
+}
+
+// Routes are the Hiponly, Coronly settings for each test route name
+var Routes = map[string][2]bool{
+	"full":   {false, false},
+	"hip":    {true, false},
+	"cortex": {false, true},
+}
+
+// RouteNames are the test routes, in order
+var RouteNames = []string{"full", "hip", "cortex"}
+
+// RouteSaves are the suffixes of the Save names of the tests of each route
+// made by TestStages
+var RouteSaves = map[string]string{
+	"full":   "full",
+	"hip":    "hip",
+	"cortex": "cor",
+}
+
+// TestStages returns test stages for each route, saved as prefix_full etc
+func TestStages(set, prefix string) []Stage {
+	var sts []Stage
+	for _, rt := range RouteNames {
+		sts = append(sts, Stage{Do: "test", Set: set, Route: rt, Save: prefix + "_" + RouteSaves[rt]})
+	}
+	return sts
+}
+
+// StudyStages returns the stages that train a new subject: init, pretrain and
+// study twice, saving the post-study checkpoint "study" that the practice
+// conditions fork from (see ForkStages)
+func StudyStages() []Stage {
+	return []Stage{{Do: "init"}, {Do: "pretrain"}, {Do: "study", N: 2}}
+}
+
+// ForkStages returns the stages of the restudy condition, forked from the
+// post-study checkpoint of StudyStages: a third study, checkpointed as "restudy"
+func ForkStages() []Stage {
+	return []Stage{{Do: "load", Ckpt: "study"}, {Do: "study", Ckpt: "restudy"}}
+}
+
+// TestingEffect returns the standard testing effect protocol on given test set:
+// study twice then test, retrieval practice then test, and, forked from the
+// same post-study network, study a third time then test.
+func TestingEffect(name, set string) *Protocol {
+	pr := &Protocol{Name: name, Desc: "testing effect: retrieval practice vs. restudy, tested on " + set}
+	pr.Stages = append(pr.Stages, StudyStages()...)
+	pr.Stages = append(pr.Stages, TestStages(set, "study")...)
+	pr.Stages = append(pr.Stages, Stage{Do: "rp"})
+	pr.Stages = append(pr.Stages, TestStages(set, "test")...)
+	pr.Stages = append(pr.Stages, ForkStages()...)
+	pr.Stages = append(pr.Stages, TestStages(set, "restudy")...)
+	return pr
+}
+
+// RetentionEffect returns the testing effect protocol of TestingEffect, with the
+// final tests after each of the given retention intervals (in delay steps of
+// given mode, in increasing order), saved as test_d4, restudy_d4 etc.
+func RetentionEffect(name, set, mode string, delays []int) *Protocol {
+	pr := &Protocol{Name: name, Desc: "testing effect: retrieval practice vs. restudy, tested on " + set + " after delays"}
+	final := func(prefix string) {
+		prv := 0
+		for _, d := range delays {
+			if d > prv {
+				pr.Stages = append(pr.Stages, Stage{Do: "delay", N: d - prv, Mode: mode})
+				prv = d
+			}
+			pr.Stages = append(pr.Stages, TestStages(set, fmt.Sprintf("%s_d%d", prefix, d))...)
+		}
+	}
+	pr.Stages = append(pr.Stages, StudyStages()...)
+	pr.Stages = append(pr.Stages, Stage{Do: "rp"})
+	final("test")
+	pr.Stages = append(pr.Stages, ForkStages()...)
+	final("restudy")
+	return pr
+}
+
+// FeedbackEffect returns the testing effect protocol of TestingEffect, with
+// retrieval practice in each of the given feedback conditions, each forked
+// from the same post-study network and saved as rp_none_full etc
+func FeedbackEffect(name, set string, fbs []string) *Protocol {
+	pr := &Protocol{Name: name, Desc: "testing effect: retrieval practice with each feedback vs. restudy, tested on " + set}
+	pr.Stages = append(pr.Stages, StudyStages()...)
+	for i, fb := range fbs {
+		if i > 0 {
+			pr.Stages = append(pr.Stages, Stage{Do: "load", Ckpt: "study"})
+		}
+		pr.Stages = append(pr.Stages, Stage{Do: "rp", Feedback: fb, Ckpt: "rp_" + fb})
+		pr.Stages = append(pr.Stages, TestStages(set, "rp_"+fb)...)
+	}
+	pr.Stages = append(pr.Stages, ForkStages()...)
+	pr.Stages = append(pr.Stages, TestStages(set, "restudy")...)
+	return pr
+}
+
+// Protocols are the built-in protocols, which can be run by name
+var Protocols = map[string]*Protocol{
+	"Short":    TestingEffect("Short", "All"),
+	"Long":     TestingEffect("Long", "Long"),
+	"Delay":    RetentionEffect("Delay", "All", "both", []int{0, 2, 8}),
+	"Feedback": FeedbackEffect("Feedback", "All", []string{"none", "full", "delayed"}),
+}
+
+// OpenProtocol loads a protocol from a JSON file
+func OpenProtocol(fname string) (*Protocol, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	pr := &Protocol{}
+	err = json.Unmarshal(b, pr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	if pr.Name == "" {
+		pr.Name = fname
+	}
+	return pr, pr.Validate()
+}
+
+// SaveProtocol saves the protocol to a JSON file
+func (pr *Protocol) SaveProtocol(fname string) error {
+	b, err := json.MarshalIndent(pr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, b, 0644)
+}
+
+// Validate returns an error for the first stage that cannot be run by any
+// model -- see also ValidateModel
+func (pr *Protocol) Validate() error {
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		switch st.Do {
+		case "init", "pretrain", "restudy":
+		case "load":
+			if st.Ckpt == "" {
+				return fmt.Errorf("protocol %s: stage %d: load needs a Ckpt name", pr.Name, i)
+			}
+		case "rp":
+			if _, ok := Feedbacks[st.Feedback]; st.Feedback != "" && !ok {
+				return fmt.Errorf("protocol %s: stage %d: unknown feedback: %s", pr.Name, i, st.Feedback)
+			}
+		case "delay":
+			if _, ok := DelayModes[st.modeName()]; !ok {
+				return fmt.Errorf("protocol %s: stage %d: unknown delay mode: %s", pr.Name, i, st.Mode)
+			}
+		case "study":
+			if _, ok := StudySets[st.setName()]; !ok {
+				return fmt.Errorf("protocol %s: stage %d: unknown study set: %s", pr.Name, i, st.Set)
+			}
+		case "test":
+			if _, ok := Routes[st.routeName()]; !ok {
+				return fmt.Errorf("protocol %s: stage %d: unknown route: %s", pr.Name, i, st.Route)
+			}
+		default:
+			return fmt.Errorf("protocol %s: stage %d: unknown stage: %s", pr.Name, i, st.Do)
+		}
+	}
+	return nil
+}
+
+// ValidateModel returns an error for the first stage that model m cannot run
+func (pr *Protocol) ValidateModel(m *Model) error {
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		if st.Do != "test" {
+			continue
+		}
+		if _, ok := TestSets[st.testSetName()]; !ok && m.Test(st.testSetName()) == nil {
+			return fmt.Errorf("protocol %s: stage %d: unknown test set: %s", pr.Name, i, st.Set)
+		}
+	}
+	return nil
+}
+
+func (st *Stage) setName() string {
+	if st.Set == "" {
+		return "AB"
+	}
+	return st.Set
+}
+
+func (st *Stage) testSetName() string {
+	if st.Set == "" {
+		return "All"
+	}
+	return st.Set
+}
+
+func (st *Stage) modeName() string {
+	if st.Mode == "" {
+		return "both"
+	}
+	return st.Mode
+}
+
+func (st *Stage) ckptName() string {
+	if st.Ckpt == "" {
+		return st.Do
+	}
+	return st.Ckpt
+}
+
+func (st *Stage) routeName() string {
+	if st.Route == "" {
+		return "full"
+	}
+	return st.Route
+}
+
+// FindProtocol returns the built-in protocol of given name, or else loads it
+// from the file of that name
+func FindProtocol(name string) (*Protocol, error) {
+	if pr, ok := Protocols[name]; ok {
+		return pr, nil
+	}
+	return OpenProtocol(name)
+}
+
+// FinalTest returns the Save name of the final test of the protocol: its last
+// full-route test stage, or else its last test stage
+func (pr *Protocol) FinalTest() string {
+	last := -1
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		if st.Do != "test" {
+			continue
+		}
+		if st.routeName() == "full" {
+			last = i
+		} else if last < 0 || pr.Stages[last].routeName() != "full" {
+			last = i
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+	return pr.Stages[last].Save
+}
+
+// RunProtocol runs each stage of the protocol in turn
+func (ss *Sim) RunProtocol(pr *Protocol) error {
+	return ss.RunProtocolFrom(pr, 0, 0)
+}
+
+// ValidateProtocol returns an error if the protocol cannot be run by this Sim:
+// see Validate and ValidateModel -- and its study sets must have patterns, and
+// its rp stages a known feedback (their own or RP.Feedback) and RP.ParamSet
+func (ss *Sim) ValidateProtocol(pr *Protocol) error {
+	if err := pr.Validate(); err != nil {
+		return err
+	}
+	if err := pr.ValidateModel(ss.Model); err != nil {
+		return err
+	}
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		switch st.Do {
+		case "study":
+			if dt := ss.PatsByName(StudySets[st.setName()]); dt == nil || dt.Rows == 0 {
+				return fmt.Errorf("protocol %s: stage %d: no patterns for study set: %s", pr.Name, i, st.setName())
+			}
+		case "rp":
+			if _, ok := Feedbacks[ss.RP.Feedback]; st.Feedback == "" && !ok {
+				return fmt.Errorf("protocol %s: stage %d: unknown retrieval practice feedback: %s", pr.Name, i, ss.RP.Feedback)
+			}
+			if ss.RP.ParamSet != "" {
+				if _, err := ss.Params.SetByNameTry(ss.RP.ParamSet); err != nil {
+					return fmt.Errorf("protocol %s: stage %d: retrieval practice ParamSet: %v", pr.Name, i, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RunProtocolFrom runs the stages of the protocol in turn, starting from the
+// given repetition of the given stage -- e.g., to resume from a Snapshot,
+// which is saved after each repetition if SnapFile is set.  The whole protocol
+// is one run: its RunLog row is written at the end, with the stats of its
+// FinalTest.  It stops, with an error, at the first stage that fails.
+func (ss *Sim) RunProtocolFrom(pr *Protocol, stage, rep int) error {
+	defer ss.Stopped()
+	defer ss.CloseCycFile()
+	if err := ss.ValidateProtocol(pr); err != nil {
+		return err
+	}
+	ss.SetConds(pr)
+	ss.InProtocol = true
+	ss.FinalStage = pr.FinalTest()
+	defer func() {
+		ss.InProtocol = false
+		ss.FinalStage = ""
+	}()
+	for i := stage; i < len(pr.Stages); i++ {
+		st := &pr.Stages[i]
+		n := st.N
+		if n == 0 {
+			n = 1
+		}
+		for ; rep < n; rep++ {
+			if err := ss.RunStage(st); err != nil {
+				return fmt.Errorf("protocol %s: stage %d: %v", pr.Name, i, err)
+			}
+			if rep+1 < n {
+				ss.SnapStage(pr, i, rep+1)
+			}
+		}
+		rep = 0
+		if CkptStages[st.Do] {
+			if err := ss.SaveCkpt(st.ckptName()); err != nil {
+				return fmt.Errorf("protocol %s: stage %d: %v", pr.Name, i, err)
+			}
+		}
+		ss.SnapStage(pr, i+1, 0)
+	}
+	ss.RunEnd()
+	return nil
+}
+
+// RunStage runs one protocol stage, returning an error if it cannot be run
+func (ss *Sim) RunStage(st *Stage) error {
+	ss.CurStage = st
+	ss.ActStage = st.ActStageName()
+	defer func() {
+		ss.CurStage = nil
+		ss.ActStage = ""
+	}()
+	if ss.WtChg.On && WtChgStages[st.Do] {
+		ss.SnapStageWts()
+		defer ss.LogWtChg(ss.WtChgLog, st)
+	}
+	switch st.Do {
+	case "delay":
+		md := DelayModes[st.modeName()]
+		ss.DelayStep(md[0], md[1])
+		ss.Delay++
+		return nil
+	case "test":
+		ss.RunTestStage(st)
+		return nil
+	}
+	ss.Delay = 0 // delays count from the last study or practice
+	switch st.Do {
+	case "init":
+		ss.Init()
+	case "pretrain":
+		ss.PreTrain()
+	case "study":
+		dt := ss.PatsByName(StudySets[st.setName()])
+		if dt == nil || dt.Rows == 0 {
+			return fmt.Errorf("no patterns for study set: %s", st.setName())
+		}
+		ss.TrainTable(dt)
+	case "rp":
+		fb := ss.RP.Feedback
+		if st.Feedback != "" {
+			ss.RP.Feedback = st.Feedback
+		}
+		err := ss.RPRun()
+		ss.RP.Feedback = fb
+		return err
+	case "restudy":
+		ss.RestudyRun()
+	case "load":
+		return ss.LoadCkpt(st.Ckpt)
+	}
+	return nil
+}
+
+// RunTestStage runs the test set of the stage with its routing, saving the
+// test trial log if Save is set.  Routing is back to full afterward.
+func (ss *Sim) RunTestStage(st *Stage) {
+	rt := Routes[st.routeName()]
+	ss.Hiponly, ss.Coronly = rt[0], rt[1]
+	ss.Stage = st.Save
+	if st.Save != "" && !ss.NoSave {
+		var err error
+		fnm := ss.OutFile(ss.Tag + "_" + st.Save + ".tsv")
+		ss.TstTrialFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.TstTrialFile = nil
+		} else {
+			fmt.Printf("Saving test trial log to: %v\n", fnm)
+		}
+	}
+	ss.StopNow = false
+	ss.SetRoute()
+	ss.TestSet(st.testSetName())(ss)
+	if ss.TstTrialFile != nil {
+		ss.TstTrialFile.Close()
+		ss.TstTrialFile = nil
+	}
+	ss.Hiponly, ss.Coronly = false, false
+	ss.Stage = ""
+}
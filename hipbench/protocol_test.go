@@ -0,0 +1,161 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []Stage
+		ok     bool
+	}{
+		{"empty", nil, true},
+		{"defaults", []Stage{{Do: "init"}, {Do: "pretrain"}, {Do: "study"}, {Do: "rp"}, {Do: "restudy"}, {Do: "delay"}, {Do: "test"}}, true},
+		{"all options", []Stage{{Do: "study", Set: "AC"}, {Do: "rp", Feedback: "delayed"}, {Do: "delay", Mode: "decay", N: 3},
+			{Do: "load", Ckpt: "study"}, {Do: "test", Set: "Long", Route: "cortex", Save: "x"}}, true},
+		{"unknown stage", []Stage{{Do: "sleep"}}, false},
+		{"load without ckpt", []Stage{{Do: "load"}}, false},
+		{"unknown feedback", []Stage{{Do: "rp", Feedback: "some"}}, false},
+		{"unknown delay mode", []Stage{{Do: "delay", Mode: "nap"}}, false},
+		{"unknown study set", []Stage{{Do: "study", Set: "AD"}}, false},
+		{"unknown route", []Stage{{Do: "test", Route: "ca1"}}, false},
+		{"bad last stage", []Stage{{Do: "init"}, {Do: "test"}, {Do: "Test"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &Protocol{Name: tt.name, Stages: tt.stages}
+			if err := pr.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateModel(t *testing.T) {
+	m := NewModel("hip_bench")
+	tests := []struct {
+		set string
+		ok  bool
+	}{
+		{"", true},
+		{"All", true},
+		{"Long", true},
+		{"AB", true},
+		{"AC", true},
+		{"Lure", true},
+		{"AD", false},
+	}
+	for _, tt := range tests {
+		pr := &Protocol{Name: "test " + tt.set, Stages: []Stage{{Do: "test", Set: tt.set}}}
+		if err := pr.ValidateModel(m); (err == nil) != tt.ok {
+			t.Errorf("test set %q: ValidateModel() = %v, want ok = %v", tt.set, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateRP(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback string
+		paramSet string
+		stage    Stage
+		ok       bool
+	}{
+		{"defaults", "none", "RP", Stage{Do: "rp"}, true},
+		{"no ParamSet", "none", "", Stage{Do: "rp"}, true},
+		{"unknown ParamSet", "none", "NoSuchSet", Stage{Do: "rp"}, false},
+		{"unknown feedback", "some", "RP", Stage{Do: "rp"}, false},
+		{"stage feedback", "some", "RP", Stage{Do: "rp", Feedback: "full"}, true},
+		{"no rp stage", "some", "NoSuchSet", Stage{Do: "study"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := testSim(t)
+			ss.RP.Feedback = tt.feedback
+			ss.RP.ParamSet = tt.paramSet
+			pr := &Protocol{Name: tt.name, Stages: []Stage{{Do: "init"}, tt.stage}}
+			if err := ss.ValidateProtocol(pr); (err == nil) != tt.ok {
+				t.Errorf("ValidateProtocol() = %v, want ok = %v", err, tt.ok)
+			}
+			if tt.stage.Do == "rp" && tt.stage.Feedback == "" {
+				ss.Init()
+				if err := ss.RPRun(); (err == nil) != tt.ok {
+					t.Errorf("RPRun() = %v, want ok = %v", err, tt.ok)
+				}
+			}
+		})
+	}
+}
+
+func TestBuiltinProtocols(t *testing.T) {
+	m := NewModel("hip_bench")
+	for nm, pr := range Protocols {
+		if err := pr.Validate(); err != nil {
+			t.Errorf("%s: %v", nm, err)
+		}
+		if err := pr.ValidateModel(m); err != nil {
+			t.Errorf("%s: %v", nm, err)
+		}
+		if pr.FinalTest() == "" {
+			t.Errorf("%s: no saved final test", nm)
+		}
+	}
+}
+
+func TestFinalTest(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []Stage
+		want   string
+	}{
+		{"none", []Stage{{Do: "study"}}, ""},
+		{"last full", []Stage{{Do: "test", Save: "a"}, {Do: "study"}, {Do: "test", Save: "b"}}, "b"},
+		{"full before other routes", []Stage{{Do: "test", Save: "full"}, {Do: "test", Route: "hip", Save: "hip"}}, "full"},
+		{"no full", []Stage{{Do: "test", Route: "hip", Save: "hip"}, {Do: "test", Route: "cortex", Save: "cortex"}}, "cortex"},
+	}
+	for _, tt := range tests {
+		pr := &Protocol{Name: tt.name, Stages: tt.stages}
+		if got := pr.FinalTest(); got != tt.want {
+			t.Errorf("%s: FinalTest() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaveOpenProtocol(t *testing.T) {
+	fnm := filepath.Join(t.TempDir(), "pr.json")
+	pr := Protocols["Delay"]
+	if err := pr.SaveProtocol(fnm); err != nil {
+		t.Fatal(err)
+	}
+	op, err := OpenProtocol(fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(op, pr) {
+		t.Errorf("opened protocol differs from the saved one")
+	}
+}
+
+func TestProtocolFiles(t *testing.T) {
+	fnms, err := filepath.Glob(filepath.Join("..", "protocols", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel("hip_bench")
+	for _, fnm := range fnms {
+		pr, err := OpenProtocol(fnm)
+		if err == nil {
+			err = pr.ValidateModel(m)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", fnm, err)
+		}
+	}
+}
@@ -0,0 +1,557 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/emer/etable/etable"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Reports
+//
+// Reports are plotted with gonum plot, which is pure Go, so that they can be
+// made on headless machines, without the gui.
+
+// ReportLog is a log read by a Report
+type ReportLog struct {
+	File  string        `desc:"file the log was read from"`
+	Kind  string        `desc:"kind of log: epc (test epoch log), run (run log), trl (test trial log) or rsa (RSA log) -- see ReportLogKind"`
+	Table *etable.Table `desc:"the log"`
+}
+
+// Name returns the name of the log's file, without its directory and .tsv
+func (rl *ReportLog) Name() string {
+	return strings.TrimSuffix(filepath.Base(rl.File), ".tsv")
+}
+
+// Report renders the logs saved by a batch -- learning curves from the test
+// epoch logs, bar charts of each condition from the run logs, tables of each
+// item from the test trial logs, and similarity matrices from the RSA logs --
+// as plots and a Markdown summary, report.md, that shows them all.
+type Report struct {
+	Dir    string       `desc:"directory of the .tsv logs"`
+	Out    string       `desc:"directory of the report -- default Dir/report"`
+	Format string       `desc:"format of the plots: svg or png -- default svg"`
+	Logs   []*ReportLog `desc:"the logs read from Dir, in file name order"`
+}
+
+// ReportLogKind returns the kind of log dt is, by its columns: run for run
+// logs (also of designs), epc for test epoch logs, trl for test trial logs
+// (also those saved by test stages), rsa for RSA logs, and "" for the others
+// (e.g., RunStats)
+func ReportLogKind(dt *etable.Table) string {
+	switch {
+	case dt.ColIdx("Layer") >= 0 && dt.ColIdx("Sim") >= 0:
+		return "rsa"
+	case dt.ColIdx("Params") >= 0 && dt.ColIdx("NEpochs") >= 0:
+		return "run"
+	case dt.ColIdx("Epoch") >= 0 && dt.ColIdx("PerTrlMSec") >= 0:
+		return "epc"
+	case dt.ColIdx("TrialName") >= 0 && dt.ColIdx("TestNm") >= 0 && dt.ColIdx("Mem") >= 0:
+		return "trl"
+	}
+	return ""
+}
+
+// Open reads the .tsv logs in Dir that are of a kind the report renders.
+// Files that are not etable logs are skipped.
+func (rp *Report) Open() error {
+	fns, err := filepath.Glob(filepath.Join(rp.Dir, "*.tsv"))
+	if err != nil {
+		return err
+	}
+	rp.Logs = nil
+	for _, fn := range fns {
+		dt := &etable.Table{}
+		if err := OpenCSV(dt, fn); err != nil {
+			log.Printf("hipbench: report: skipping %s: %v\n", fn, err)
+			continue
+		}
+		if kind := ReportLogKind(dt); kind != "" {
+			rp.Logs = append(rp.Logs, &ReportLog{File: fn, Kind: kind, Table: dt})
+		}
+	}
+	if len(rp.Logs) == 0 {
+		return fmt.Errorf("hipbench: report: no logs in %s", rp.Dir)
+	}
+	return nil
+}
+
+// Run reads the logs (see Open), and writes the plots and report.md to Out
+func (rp *Report) Run() error {
+	if rp.Out == "" {
+		rp.Out = filepath.Join(rp.Dir, "report")
+	}
+	if rp.Format == "" {
+		rp.Format = "svg"
+	}
+	if rp.Format != "svg" && rp.Format != "png" {
+		return fmt.Errorf("hipbench: report: format %q is not svg or png", rp.Format)
+	}
+	if err := rp.Open(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rp.Out, 0755); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Report: %s\n", rp.Dir)
+	for _, sec := range []struct {
+		kind  string
+		title string
+		fun   func(b *bytes.Buffer, rl *ReportLog) error
+	}{
+		{"epc", "Learning curves", rp.Curves},
+		{"run", "Conditions", rp.Conditions},
+		{"trl", "Items", rp.Items},
+		{"rsa", "Similarity", rp.Similarity},
+	} {
+		first := true
+		for _, rl := range rp.Logs {
+			if rl.Kind != sec.kind {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&b, "\n## %s\n", sec.title)
+				first = false
+			}
+			fmt.Fprintf(&b, "\n### %s\n", rl.Name())
+			if err := sec.fun(&b, rl); err != nil {
+				return err
+			}
+		}
+	}
+	fnm := filepath.Join(rp.Out, "report.md")
+	fmt.Printf("Saving report to: %v\n", fnm)
+	return ioutil.WriteFile(fnm, b.Bytes(), 0644)
+}
+
+// Curves plots the learning curves of the test epoch log: the Mem of each test
+// by epoch, with its mean and SEM over the runs (and the tests of the epoch)
+func (rp *Report) Curves(b *bytes.Buffer, rl *ReportLog) error {
+	dt := rl.Table
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = rl.Name()
+	p.X.Label.Text = "Epoch"
+	p.Y.Label.Text = "Mem"
+	p.Legend.Top = true
+	for ci, cn := range MemCols(dt) {
+		epcs, vals := GroupVals(dt, "Epoch", cn)
+		var xys plotter.XYs
+		var errs plotter.YErrors
+		for ei, epc := range epcs {
+			m, sem, n := MeanSEM(vals[ei])
+			if n == 0 {
+				continue
+			}
+			x, err := strconv.ParseFloat(epc, 64)
+			if err != nil {
+				return err
+			}
+			xys = append(xys, plotter.XY{X: x, Y: m})
+			errs = append(errs, struct{ Low, High float64 }{sem, sem})
+		}
+		if len(xys) == 0 {
+			continue
+		}
+		ln, pts, err := plotter.NewLinePoints(xys)
+		if err != nil {
+			return err
+		}
+		ln.Color = plotutil.Color(ci)
+		pts.Color = plotutil.Color(ci)
+		eb, err := plotter.NewYErrorBars(struct {
+			plotter.XYs
+			plotter.YErrors
+		}{xys, errs})
+		if err != nil {
+			return err
+		}
+		eb.Color = plotutil.Color(ci)
+		p.Add(ln, pts, eb)
+		p.Legend.Add(cn, ln, pts)
+	}
+	return rp.savePlot(b, p, rl.Name()+"_curve")
+}
+
+// Conditions plots bar charts of the run log: the mean and SEM over the runs
+// of each Params (design cell) of the final-test Mem of each test, and, for
+// protocols with testing effect conditions, of each condition on each route,
+// with a table of the testing effect
+func (rp *Report) Conditions(b *bytes.Buffer, rl *ReportLog) error {
+	dt := rl.Table
+	if mcs := MemCols(dt); len(mcs) > 0 {
+		if err := rp.barPlot(b, dt, rl.Name()+"_mem", "final test", mcs, mcs); err != nil {
+			return err
+		}
+	}
+	cs := LogConds(dt)
+	if len(cs.Conditions) == 0 || !hasVals(dt, cs.Cols()) {
+		return nil
+	}
+	for _, rt := range RouteNames {
+		var cols, names []string
+		for _, cd := range cs.Conditions {
+			cols = append(cols, EffectMemCol(cd.Name, rt))
+			names = append(names, cd.Name)
+		}
+		if !hasVals(dt, cols) {
+			continue
+		}
+		if err := rp.barPlot(b, dt, rl.Name()+"_effect_"+rt, rt+" route", cols, names); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(b, "\nTesting effect (RP - Restudy Mem): mean, SEM, 95%% CI half-width, runs\n\n")
+	fmt.Fprintf(b, "| Params | Effect | Route | TE | SEM | CI95 | N |\n|---|---|---|---|---|---|---|\n")
+	for _, ef := range cs.Effects {
+		for _, rt := range RouteNames {
+			keys, vals := GroupVals(dt, "Params", EffectCol(ef.Name, rt))
+			for ki, key := range keys {
+				m, sem, n := MeanSEM(vals[ki])
+				if n == 0 {
+					continue
+				}
+				fmt.Fprintf(b, "| %s | %s | %s | %.4g | %.4g | %.4g | %d |\n", key, ef.Name, rt, m, sem, TCrit95(n-1)*sem, n)
+			}
+		}
+	}
+	return nil
+}
+
+// Items writes tables of the test trial log: the Mem of each item in each
+// test stage, averaged over the runs, for each Params and test
+func (rp *Report) Items(b *bytes.Buffer, rl *ReportLog) error {
+	dt := rl.Table
+	pcol := "Params"
+	if dt.ColIdx(pcol) < 0 {
+		pcol = "TestNm" // older logs, without Params
+	}
+	pkeys, _ := GroupVals(dt, pcol, "Mem")
+	tkeys, _ := GroupVals(dt, "TestNm", "Mem")
+	skeys, _ := GroupVals(dt, "Stage", "Mem")
+	for _, pk := range pkeys {
+		for _, tk := range tkeys {
+			var items []string
+			mems := map[string][]float64{} // by item and stage
+			for row := 0; row < dt.Rows; row++ {
+				if dt.CellString(pcol, row) != pk || dt.CellString("TestNm", row) != tk {
+					continue
+				}
+				item := dt.CellString("TrialName", row)
+				if _, ok := mems[item]; !ok {
+					items = append(items, item)
+					mems[item] = nil
+				}
+				key := item + "\t" + dt.CellString("Stage", row)
+				mems[key] = append(mems[key], dt.CellFloat("Mem", row))
+			}
+			if len(items) == 0 {
+				continue
+			}
+			fmt.Fprintf(b, "\n%s %s: mean Mem by item and stage\n\n| Item |", pk, tk)
+			for _, sk := range skeys {
+				fmt.Fprintf(b, " %s |", sk)
+			}
+			fmt.Fprintf(b, "\n|---|%s\n", strings.Repeat("---|", len(skeys)))
+			for _, item := range items {
+				fmt.Fprintf(b, "| %s |", item)
+				for _, sk := range skeys {
+					if m, _, n := MeanSEM(mems[item+"\t"+sk]); n > 0 {
+						fmt.Fprintf(b, " %.3g |", m)
+					} else {
+						fmt.Fprintf(b, " |")
+					}
+				}
+				fmt.Fprintf(b, "\n")
+			}
+		}
+	}
+	return nil
+}
+
+// Similarity plots the similarity matrices of the RSA log, averaged over the
+// runs of each Params, stage and layer, as heat maps, in a table of stages by
+// layers with the mean stability and differentiation of each
+func (rp *Report) Similarity(b *bytes.Buffer, rl *ReportLog) error {
+	dt := rl.Table
+	pkeys, _ := GroupVals(dt, "Params", "Diff")
+	skeys, _ := GroupVals(dt, "Stage", "Diff")
+	lkeys, _ := GroupVals(dt, "Layer", "Diff")
+	n := dt.ColByName("Sim").Shapes()[1]
+	for _, pk := range pkeys {
+		fmt.Fprintf(b, "\n%s: similarity of the items (mean over runs), stability and differentiation\n\n| Stage |", pk)
+		for _, lk := range lkeys {
+			fmt.Fprintf(b, " %s |", lk)
+		}
+		fmt.Fprintf(b, "\n|---|%s\n", strings.Repeat("---|", len(lkeys)))
+		for _, sk := range skeys {
+			var cells []string
+			for _, lk := range lkeys {
+				sim := make([][]float64, n*n)
+				var stabs, diffs []float64
+				for row := 0; row < dt.Rows; row++ {
+					if dt.CellString("Params", row) != pk || dt.CellString("Stage", row) != sk || dt.CellString("Layer", row) != lk {
+						continue
+					}
+					var vals []float64
+					dt.CellTensor("Sim", row).Floats(&vals)
+					for i, v := range vals {
+						sim[i] = append(sim[i], v)
+					}
+					stabs = append(stabs, dt.CellFloat("Stab", row))
+					diffs = append(diffs, dt.CellFloat("Diff", row))
+				}
+				if len(diffs) == 0 {
+					cells = append(cells, "")
+					continue
+				}
+				mean := make([]float64, n*n)
+				for i := range sim {
+					mean[i], _, _ = MeanSEM(sim[i])
+				}
+				fname := fileSafe(rl.Name() + "_" + pk + "_" + sk + "_" + lk + "_sim")
+				if err := rp.heatMap(fname, lk+" "+sk, mean, n); err != nil {
+					return err
+				}
+				stab, _, _ := MeanSEM(stabs)
+				diff, _, _ := MeanSEM(diffs)
+				cells = append(cells, fmt.Sprintf("![%s](%s) Stab %.3g, Diff %.3g", fname, fname+"."+rp.Format, stab, diff))
+			}
+			if strings.Join(cells, "") == "" {
+				continue
+			}
+			fmt.Fprintf(b, "| %s | %s |\n", sk, strings.Join(cells, " | "))
+		}
+	}
+	return nil
+}
+
+// simGrid is an n x n similarity matrix, in row-major order, as the grid of a
+// heat map, with item 0 at the top left -- NaNs (items without patterns) are 0
+type simGrid struct {
+	n    int
+	vals []float64
+}
+
+func (g *simGrid) Dims() (c, r int) { return g.n, g.n }
+func (g *simGrid) X(c int) float64  { return float64(c) }
+func (g *simGrid) Y(r int) float64  { return float64(r) }
+func (g *simGrid) Z(c, r int) float64 {
+	v := g.vals[(g.n-1-r)*g.n+c]
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(-1, math.Min(1, v))
+}
+
+// heatMap saves the n x n similarity matrix sim as a heat map, from -1 to 1,
+// to Out as fname, in Format
+func (rp *Report) heatMap(fname, title string, sim []float64, n int) error {
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = title
+	p.X.Label.Text = "Item"
+	p.Y.Label.Text = "Item"
+	if n > 1 { // a heat map needs at least 2 x 2 cells
+		hm := plotter.NewHeatMap(&simGrid{n: n, vals: sim}, palette.Heat(12, 1))
+		hm.Min, hm.Max = -1, 1
+		p.Add(hm)
+	}
+	return p.Save(3*vg.Inch, 3*vg.Inch, filepath.Join(rp.Out, fname+"."+rp.Format))
+}
+
+// fileSafe returns s with the characters other than letters, digits, - and _
+// replaced by _, for use in a file name
+func fileSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// barPlot plots the mean and SEM of each of the cols (named as names) over
+// the runs of each Params of dt, as bars grouped by Params
+func (rp *Report) barPlot(b *bytes.Buffer, dt *etable.Table, fname, title string, cols, names []string) error {
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = title
+	p.Y.Label.Text = "Mem"
+	p.Legend.Top = true
+	var groups []string
+	nc := len(cols)
+	for ci, cn := range cols {
+		keys, vals := GroupVals(dt, "Params", cn)
+		groups = keys
+		var xys plotter.XYs
+		var errs plotter.YErrors
+		for ki := range keys {
+			m, sem, n := MeanSEM(vals[ki])
+			if n == 0 {
+				continue
+			}
+			x := float64(ki*(nc+1) + ci)
+			bc, err := plotter.NewBarChart(plotter.Values{m}, barWidth(len(keys)*(nc+1)))
+			if err != nil {
+				return err
+			}
+			bc.XMin = x
+			bc.Color = plotutil.Color(ci)
+			bc.LineStyle.Width = 0
+			p.Add(bc)
+			if len(xys) == 0 {
+				p.Legend.Add(names[ci], bc)
+			}
+			xys = append(xys, plotter.XY{X: x, Y: m})
+			errs = append(errs, struct{ Low, High float64 }{sem, sem})
+		}
+		if len(xys) == 0 {
+			continue
+		}
+		eb, err := plotter.NewYErrorBars(struct {
+			plotter.XYs
+			plotter.YErrors
+		}{xys, errs})
+		if err != nil {
+			return err
+		}
+		p.Add(eb)
+	}
+	var ticks []plot.Tick
+	for gi, g := range groups {
+		ticks = append(ticks, plot.Tick{Value: float64(gi*(nc+1)) + float64(nc-1)/2, Label: g})
+	}
+	p.X.Tick.Marker = plot.ConstantTicks(ticks)
+	p.X.Min = -1
+	p.X.Max = float64(len(groups) * (nc + 1))
+	return rp.savePlot(b, p, fname)
+}
+
+// savePlot saves the plot to Out as fname, in Format, and shows it in b
+func (rp *Report) savePlot(b *bytes.Buffer, p *plot.Plot, fname string) error {
+	fname += "." + rp.Format
+	if err := p.Save(6*vg.Inch, 4*vg.Inch, filepath.Join(rp.Out, fname)); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "\n![%s](%s)\n", fname, fname)
+	return nil
+}
+
+// barWidth returns the width of the bars of a 6 inch wide bar plot with nx bar
+// positions
+func barWidth(nx int) vg.Length {
+	return vg.Length(.8 * 5 * float64(vg.Inch) / float64(nx+1))
+}
+
+// MemCols returns the "<test> Mem" columns of dt, e.g., AB Mem -- not those of
+// the testing effect conditions (see LogConds)
+func MemCols(dt *etable.Table) []string {
+	ecs := map[string]bool{}
+	for _, cn := range LogConds(dt).Cols() {
+		ecs[cn] = true
+	}
+	var cols []string
+	for _, cn := range dt.ColNames {
+		if strings.HasSuffix(cn, " Mem") && !ecs[cn] {
+			cols = append(cols, cn)
+		}
+	}
+	return cols
+}
+
+// GroupVals returns the values of col in the rows of dt with each value of the
+// key column, in the order in which the keys first appear
+func GroupVals(dt *etable.Table, key, col string) ([]string, [][]float64) {
+	var keys []string
+	var vals [][]float64
+	idx := map[string]int{}
+	for row := 0; row < dt.Rows; row++ {
+		k := dt.CellString(key, row)
+		ki, ok := idx[k]
+		if !ok {
+			ki = len(keys)
+			idx[k] = ki
+			keys = append(keys, k)
+			vals = append(vals, nil)
+		}
+		vals[ki] = append(vals[ki], dt.CellFloat(col, row))
+	}
+	return keys, vals
+}
+
+// MeanSEM returns the mean and standard error of the mean of the vals that are
+// not NaN, and their number
+func MeanSEM(vals []float64) (mean, sem float64, n int) {
+	var sum, ss float64
+	for _, v := range vals {
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN(), math.NaN(), 0
+	}
+	mean = sum / float64(n)
+	if n == 1 {
+		return mean, 0, 1
+	}
+	for _, v := range vals {
+		if !math.IsNaN(v) {
+			ss += (v - mean) * (v - mean)
+		}
+	}
+	return mean, math.Sqrt(ss / float64(n-1) / float64(n)), n
+}
+
+// hasVals returns true if any of the cols of dt has a value that is not NaN
+func hasVals(dt *etable.Table, cols []string) bool {
+	for _, cn := range cols {
+		for row := 0; row < dt.Rows; row++ {
+			if !math.IsNaN(dt.CellFloat(cn, row)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReportCmd runs the report command, given its args (after "report"):
+// -dir, -out and -format (see Report)
+func ReportCmd(args []string) error {
+	rp := &Report{}
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	fs.StringVar(&rp.Dir, "dir", ".", "directory of the .tsv logs of a batch")
+	fs.StringVar(&rp.Out, "out", "", "directory to write the plots and report.md to -- default <dir>/report")
+	fs.StringVar(&rp.Format, "format", "svg", "format of the plots: svg or png")
+	fs.Parse(args)
+	return rp.Run()
+}
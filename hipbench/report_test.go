@@ -0,0 +1,133 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+func TestMeanSEM(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		vals []float64
+		mean float64
+		sem  float64
+		n    int
+	}{
+		{[]float64{1, 2, 3}, 2, math.Sqrt(1.0 / 3), 3},
+		{[]float64{1, nan, 3}, 2, 1, 2},
+		{[]float64{.5}, .5, 0, 1},
+		{[]float64{nan}, nan, nan, 0},
+		{nil, nan, nan, 0},
+	}
+	eq := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-12 || (math.IsNaN(a) && math.IsNaN(b))
+	}
+	for _, tt := range tests {
+		m, sem, n := MeanSEM(tt.vals)
+		if !eq(m, tt.mean) || !eq(sem, tt.sem) || n != tt.n {
+			t.Errorf("MeanSEM(%v) = %g, %g, %d, want %g, %g, %d", tt.vals, m, sem, n, tt.mean, tt.sem, tt.n)
+		}
+	}
+}
+
+func TestGroupVals(t *testing.T) {
+	dt := &etable.Table{}
+	dt.SetFromSchema(etable.Schema{
+		{"Params", etensor.STRING, nil, nil},
+		{"AB Mem", etensor.FLOAT64, nil, nil},
+	}, 5)
+	for row, pv := range []struct {
+		p string
+		v float64
+	}{{"B", 1}, {"A", 2}, {"B", 3}, {"C", 4}, {"A", 5}} {
+		dt.SetCellString("Params", row, pv.p)
+		dt.SetCellFloat("AB Mem", row, pv.v)
+	}
+	keys, vals := GroupVals(dt, "Params", "AB Mem")
+	if want := []string{"B", "A", "C"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	if want := [][]float64{{1, 3}, {2, 5}, {4}}; !reflect.DeepEqual(vals, want) {
+		t.Errorf("vals = %v, want %v", vals, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	if err := ss.RunProtocol(TestingEffect("T", "AB")); err != nil {
+		t.Fatal(err)
+	}
+	ss.LogRunStats()
+	dir := t.TempDir()
+	logs := []struct {
+		dt   *etable.Table
+		fnm  string
+		kind string
+	}{
+		{ss.TstEpcLog, "hip_bench_T_epc.tsv", "epc"},
+		{ss.RunLog, "hip_bench_T_run.tsv", "run"},
+		{ss.TstTrlLog, "T_test_full.tsv", "trl"},
+		{ss.RunStats, "hip_bench_T_runstats.tsv", ""},
+	}
+	for _, lg := range logs {
+		if k := ReportLogKind(lg.dt); k != lg.kind {
+			t.Errorf("%s: ReportLogKind = %q, want %q", lg.fnm, k, lg.kind)
+		}
+		if err := SaveCSV(lg.dt, filepath.Join(dir, lg.fnm)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.tsv"), []byte("not\ta log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"svg", "png"} {
+		out := filepath.Join(dir, format)
+		rp := &Report{Dir: dir, Out: out, Format: format}
+		if err := rp.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if len(rp.Logs) != 3 {
+			t.Errorf("%s: %d logs read, want 3", format, len(rp.Logs))
+		}
+		b, err := ioutil.ReadFile(filepath.Join(out, "report.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		md := string(b)
+		for _, s := range []string{"## Learning curves", "## Conditions", "## Items", "| TE |", "mean Mem by item"} {
+			if !strings.Contains(md, s) {
+				t.Errorf("%s: report.md has no %q", format, s)
+			}
+		}
+		for _, fnm := range []string{"hip_bench_T_epc_curve", "hip_bench_T_run_mem", "hip_bench_T_run_effect_full"} {
+			fnm += "." + format
+			if fi, err := os.Stat(filepath.Join(out, fnm)); err != nil || fi.Size() == 0 {
+				t.Errorf("no plot %s: %v", fnm, err)
+			}
+			if !strings.Contains(md, "("+fnm+")") {
+				t.Errorf("report.md does not show %s", fnm)
+			}
+		}
+	}
+
+	if err := (&Report{Dir: dir, Format: "pdf"}).Run(); err == nil {
+		t.Errorf("Run with format pdf: no error")
+	}
+	if err := (&Report{Dir: t.TempDir()}).Run(); err == nil {
+		t.Errorf("Run without logs: no error")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+
+	"github.com/emer/emergent/env"
+	"github.com/emer/emergent/patgen"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Retention interval
+
+// DelayStep runs one step of the retention interval between practice and the
+// final test: study of a new, unrelated filler list (interference), and / or
+// decay of the learned weights.  The filler list, and the order it is studied
+// in, only depend on the run and the step (Delay + 1, counted from the last
+// study or practice), from their own streams (see FillerSeed) -- so that the
+// practice conditions forked from one network (e.g., the RP and restudy
+// branches of RetentionEffect) get the same interference after each delay.
+func (ss *Sim) DelayStep(filler, decay bool) {
+	if filler {
+		step := ss.Delay + 1
+		ss.WithRand(NewStream(ss.FillerSeed(step, "Filler")), ss.NewFillerPats)
+		ord := ss.Rnd.Order
+		ss.Rnd.Order = NewStream(ss.FillerSeed(step, "FillerOrder"))
+		ss.FillerRun()
+		ss.Rnd.Order = ord
+	}
+	if decay {
+		ss.DecayWts(ss.Ret.Decay)
+	}
+}
+
+// FillerSeed returns the seed of the named filler stream (Filler for the
+// patterns, FillerOrder for their order) of the given delay step of the
+// current run, derived from the master seed as Filler<step> etc
+func (ss *Sim) FillerSeed(step int, stream string) int64 {
+	return DeriveSeed(ss.RndSeed, ss.TrainEnv.Run.Cur, fmt.Sprintf("%s%d", stream, step))
+}
+
+// NewFillerPats generates a new filler list in TrainFiller, of new items in
+// their own context, unrelated to all the other lists
+func (ss *Sim) NewFillerPats() {
+	hp := &ss.Hip
+	plY := hp.ECPool.Y
+	plX := hp.ECPool.X
+	npats := ss.Pat.ListSize
+	pctAct := hp.ECPctAct
+	minDiff := ss.Pat.MinDiffPct
+	nOn := patgen.NFmPct(pctAct, plY*plX)
+	ctxtflip := patgen.NFmPct(ss.Pat.CtxtFlipPct, nOn)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "fA", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "fB", npats, plY, plX, pctAct, minDiff)
+	patgen.AddVocabPermutedBinary(ss.PoolVocab, "fctxt", 1, plY, plX, pctAct, minDiff)
+	for i := 0; i < 4; i++ {
+		tsr, _ := patgen.AddVocabRepeat(ss.PoolVocab, fmt.Sprintf("fctxt%d", i+1), npats, "fctxt", 0)
+		patgen.FlipBitsRows(tsr, ctxtflip, ctxtflip, 1, 0)
+	}
+	ss.MixPats(ss.TrainFiller, "TrainFiller", "Filler Pats")
+}
+
+// FillerRun studies the TrainFiller list for Ret.FillerEpcs epochs, without
+// logging, then sets the training env back to TrainAB
+func (ss *Sim) FillerRun() {
+	ss.SetTrainPats(ss.TrainFiller, ss.TrainEnv.Run.Cur)
+	ss.StopNow = false
+	for !ss.StopNow {
+		ss.StepTrain()
+		epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+		if chg && epc >= ss.Ret.FillerEpcs {
+			break
+		}
+		ss.ApplyInputs(&ss.TrainEnv)
+		ss.AlphaCyc(true) // train
+	}
+	if ss.ViewOn {
+		ss.UpdateView(true)
+	}
+	ss.SetTrainPats(ss.TrainAB, ss.TrainEnv.Run.Cur)
+}
+
+// DecayWts decays the linear weight of each synapse of the Model.DecayPrjns
+// by the given proportion toward the linear value of its initial mean weight.
+// The projections are a fixed list, rather than those that the last alpha
+// cycle schedule left learning and on.
+func (ss *Sim) DecayWts(decay float32) {
+	if decay <= 0 {
+		return
+	}
+	for _, nm := range ss.Model.DecayPrjns {
+		pj := ss.PrjnByName(nm)
+		if pj == nil {
+			continue
+		}
+		lw0 := pj.Learn.WtSig.LinFmSigWt(float32(pj.WtInit.Mean))
+		for si := range pj.Syns {
+			sy := &pj.Syns[si]
+			sy.LWt += decay * (lw0 - sy.LWt)
+			sy.Wt = sy.Scale * pj.Learn.WtSig.SigFmLinWt(sy.LWt)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/emer/leabra/leabra"
+)
+
+func TestDecayWts(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	pj := ss.PrjnByName("ECinToCA3")
+	for si := range pj.Syns {
+		pj.Syns[si].LWt = float32(si%5) / 4 // spread around the initial mean
+	}
+	lwts := make([]float32, len(pj.Syns))
+	for si := range pj.Syns {
+		lwts[si] = pj.Syns[si].LWt
+	}
+	mossy := ss.PrjnByName("DGToCA3")
+	msyns := append([]leabra.Synapse(nil), mossy.Syns...)
+
+	const decay = 0.25
+	ss.DecayWts(decay)
+	lw0 := pj.Learn.WtSig.LinFmSigWt(float32(pj.WtInit.Mean))
+	for si := range pj.Syns {
+		sy := &pj.Syns[si]
+		want := lwts[si] + decay*(lw0-lwts[si])
+		if math.Abs(float64(sy.LWt-want)) > 1e-6 {
+			t.Fatalf("synapse %d: LWt = %g, want %g", si, sy.LWt, want)
+		}
+		if wt := sy.Scale * pj.Learn.WtSig.SigFmLinWt(sy.LWt); sy.Wt != wt {
+			t.Fatalf("synapse %d: Wt = %g, want %g from LWt", si, sy.Wt, wt)
+		}
+	}
+	if !reflect.DeepEqual(mossy.Syns, msyns) {
+		t.Errorf("DecayWts changed the DGToCA3 mossy fibers")
+	}
+	ss.DecayWts(0)
+	if pj.Syns[1].LWt != lwts[1]+decay*(lw0-lwts[1]) {
+		t.Errorf("DecayWts(0) changed the weights")
+	}
+}
+
+func TestDelayStep(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	filler := func() []float64 {
+		var vals []float64
+		ss.TrainFiller.ColByName("Input").Floats(&vals)
+		return vals
+	}
+	ss.DelayStep(true, false)
+	f1 := filler()
+	if ss.TrainEnv.Table.Table != ss.TrainAB {
+		t.Errorf("FillerRun did not set the training env back to TrainAB")
+	}
+	ss.WithRand(ss.Rnd.Pats, ss.NewFillerPats) // moves the pattern stream on, as other branches do
+	ss.Rnd.Order.Perm(4)
+	ss.DelayStep(true, false)
+	if !reflect.DeepEqual(filler(), f1) {
+		t.Errorf("filler list of step 1 depends on the pattern stream")
+	}
+	ss.Delay = 1
+	ss.DelayStep(true, false)
+	if reflect.DeepEqual(filler(), f1) {
+		t.Errorf("filler lists of steps 1 and 2 are the same")
+	}
+	ss.TrainEnv.Run.Cur++
+	ss.Delay = 0
+	ss.DelayStep(true, false)
+	if reflect.DeepEqual(filler(), f1) {
+		t.Errorf("filler lists of step 1 of runs 0 and 1 are the same")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/etable/metric"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Representational similarity analysis
+//
+// With Acts.RSA, the ActLog records the Acts.RSAVar activity of the Acts.RSALays,
+// and at the end of each run RunRSA compares the patterns of the items (the
+// rows of the study list) in each stage: their item x item similarity matrix
+// (the correlation of their patterns), the stability of each item's pattern
+// since study, and the differentiation of the items from each other.
+
+// RSAStats are the stats of the RSA, in the RSALog and, for the final test of
+// each condition, in the RunLog: Stab is the mean correlation of each item's
+// pattern with its pattern in the study stage, and Diff is 1 minus the mean
+// similarity of the patterns of different items
+var RSAStats = []string{"Stab", "Diff"}
+
+// RSACol returns the RunLog column of the RSA stat of the layer in the final
+// test of the condition (e.g., "CA3 RP Diff"), or of a testing effect, RP
+// minus Restudy, for cond the name of the Effect (e.g., "CA3 TE Diff")
+func RSACol(lay, cond, stat string) string {
+	return lay + " " + cond + " " + stat
+}
+
+// RSACols returns the RunLog columns of the RSA of the layers: the stats of
+// the final test of each condition, and of each testing effect
+func (cs *Conds) RSACols(lays []string) []string {
+	var cols []string
+	for _, lnm := range lays {
+		for _, st := range RSAStats {
+			for _, cd := range cs.Conditions {
+				cols = append(cols, RSACol(lnm, cd.Name, st))
+			}
+			for _, ef := range cs.Effects {
+				cols = append(cols, RSACol(lnm, ef.Name, st))
+			}
+		}
+	}
+	return cols
+}
+
+// RSATECols returns the testing effect RunLog columns of the RSA of the layers
+func (cs *Conds) RSATECols(lays []string) []string {
+	var cols []string
+	for _, lnm := range lays {
+		for _, st := range RSAStats {
+			for _, ef := range cs.Effects {
+				cols = append(cols, RSACol(lnm, ef.Name, st))
+			}
+		}
+	}
+	return cols
+}
+
+// RSASchema returns the RunLog columns of the RSA of the layers
+func (cs *Conds) RSASchema(lays []string) etable.Schema {
+	var sch etable.Schema
+	for _, cn := range cs.RSACols(lays) {
+		sch = append(sch, etable.Column{cn, etensor.FLOAT64, nil, nil})
+	}
+	return sch
+}
+
+// RSAPats returns the Acts.RSAVar patterns of the items in the ActLog, by
+// stage and layer, with the stages in the order they were run: the last
+// pattern of each item in the stage (e.g., after its last attempt of
+// retrieval practice), nil for the items that it did not have.  Test stages
+// count just their trials of the first test (e.g., AB).
+func (ss *Sim) RSAPats() (stages []string, pats map[string]map[string][][]float64) {
+	al := ss.ActLog
+	n := ss.Pat.ListSize
+	tst := ""
+	if len(ss.TstNms) > 0 {
+		tst = ss.TstNms[0]
+	}
+	pats = map[string]map[string][][]float64{}
+	for row := 0; row < al.Rows; row++ {
+		if tn := al.CellString("TestNm", row); tn != "" && tn != tst {
+			continue
+		}
+		item := int(al.CellFloat("Item", row))
+		if item < 0 || item >= n {
+			continue
+		}
+		stg := al.CellString("Stage", row)
+		sp, ok := pats[stg]
+		if !ok {
+			stages = append(stages, stg)
+			sp = map[string][][]float64{}
+			pats[stg] = sp
+		}
+		for _, lnm := range ss.Acts.RSALays {
+			cl := al.ColByName(lnm + " " + ss.Acts.RSAVar)
+			if cl == nil {
+				continue
+			}
+			if sp[lnm] == nil {
+				sp[lnm] = make([][]float64, n)
+			}
+			var vals []float64
+			al.CellTensor(lnm+" "+ss.Acts.RSAVar, row).Floats(&vals)
+			sp[lnm][item] = vals
+		}
+	}
+	return
+}
+
+// SimMat returns the item x item similarity matrix of the patterns, n x n in
+// row-major order: the correlation of the patterns of each pair of items, NaN
+// for the items without a pattern
+func SimMat(pats [][]float64) []float64 {
+	n := len(pats)
+	sim := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if pats[i] == nil || pats[j] == nil {
+				sim[i*n+j] = math.NaN()
+				continue
+			}
+			sim[i*n+j] = metric.Correlation64(pats[i], pats[j])
+		}
+	}
+	return sim
+}
+
+// Stability returns the mean over the items that have both of the
+// correlation of their pattern in pats with that in base
+func Stability(pats, base [][]float64) float64 {
+	var sims []float64
+	for i := range pats {
+		if i < len(base) && pats[i] != nil && base[i] != nil {
+			sims = append(sims, metric.Correlation64(pats[i], base[i]))
+		}
+	}
+	m, _, _ := MeanSEM(sims)
+	return m
+}
+
+// Differentiation returns 1 minus the mean of the off-diagonal similarities
+// of the n x n similarity matrix sim (see SimMat), ignoring NaNs
+func Differentiation(sim []float64, n int) float64 {
+	var sims []float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j {
+				sims = append(sims, sim[i*n+j])
+			}
+		}
+	}
+	m, _, _ := MeanSEM(sims)
+	return 1 - m
+}
+
+// RunRSA computes the RSA of the ActLog of the run into the RSALog: a row for
+// each stage and each of the Acts.RSALays, with the similarity matrix of the
+// items and their stability since study and differentiation
+func (ss *Sim) RunRSA() {
+	dt := ss.RSALog
+	dt.SetNumRows(0)
+	if !ss.Acts.RSA {
+		return
+	}
+	n := ss.Pat.ListSize
+	stages, pats := ss.RSAPats()
+	for _, stg := range stages {
+		for _, lnm := range ss.Acts.RSALays {
+			lp := pats[stg][lnm]
+			if lp == nil {
+				continue
+			}
+			sim := SimMat(lp)
+			row := dt.Rows
+			dt.SetNumRows(row + 1)
+			dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+			dt.SetCellString("Params", row, ss.RunName())
+			dt.SetCellString("Stage", row, stg)
+			dt.SetCellString("Layer", row, lnm)
+			stab := math.NaN()
+			if base := pats["study"][lnm]; base != nil {
+				stab = Stability(lp, base)
+			}
+			dt.SetCellFloat("Stab", row, stab)
+			dt.SetCellFloat("Diff", row, Differentiation(sim, n))
+			for i, v := range sim {
+				dt.SetCellTensorFloat1D("Sim", row, i, v)
+			}
+		}
+	}
+	ss.UpdatePlot("RSALog")
+}
+
+// LogRunRSA records, in the RunLog row, the RSA stats of each of the
+// Acts.RSALays in the final full-route test of each of the Conds conditions,
+// and each of their testing effects -- NaN for the conditions without one
+func (ss *Sim) LogRunRSA(dt *etable.Table, row int) {
+	rl := ss.RSALog
+	for _, lnm := range ss.Acts.RSALays {
+		for _, st := range RSAStats {
+			vals := map[string]float64{}
+			for _, cd := range ss.Conds.Conditions {
+				vals[cd.Name] = math.NaN()
+				for r := rl.Rows - 1; r >= 0; r-- {
+					if rl.CellString("Layer", r) == lnm && cd.IsTest(rl.CellString("Stage", r), "full") {
+						vals[cd.Name] = rl.CellFloat(st, r)
+						break
+					}
+				}
+				dt.SetCellFloat(RSACol(lnm, cd.Name, st), row, vals[cd.Name])
+			}
+			for _, ef := range ss.Conds.Effects {
+				dt.SetCellFloat(RSACol(lnm, ef.Name, st), row, vals[ef.RP]-vals[ef.Restudy])
+			}
+		}
+	}
+}
+
+// RSAFileName returns the file that the RSALog of the current run is saved to
+func (ss *Sim) RSAFileName() string {
+	return ss.LogFileName(fmt.Sprintf("run%03d_rsa", ss.TrainEnv.Run.Cur))
+}
+
+// ConfigRSALog configures the RSALog for the current list size, with no rows
+func (ss *Sim) ConfigRSALog(dt *etable.Table) {
+	dt.SetMetaData("name", "RSALog")
+	dt.SetMetaData("desc", "Representational similarity of the items per stage and layer")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	n := ss.Pat.ListSize
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Layer", etensor.STRING, nil, nil},
+		{"Stab", etensor.FLOAT64, nil, nil},
+		{"Diff", etensor.FLOAT64, nil, nil},
+		{"Sim", etensor.FLOAT64, []int{n, n}, []string{"Item", "Item"}},
+	}
+	dt.SetFromSchema(sch, 0)
+}
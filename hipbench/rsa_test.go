@@ -0,0 +1,116 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSimMat(t *testing.T) {
+	pats := [][]float64{{1, 0, 0, 1}, {1, 0, 0, 1}, {0, 1, 1, 0}, nil}
+	sim := SimMat(pats)
+	want := []float64{
+		1, 1, -1, math.NaN(),
+		1, 1, -1, math.NaN(),
+		-1, -1, 1, math.NaN(),
+		math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+	}
+	for i, v := range sim {
+		if math.Abs(v-want[i]) > 1e-9 || math.IsNaN(v) != math.IsNaN(want[i]) {
+			t.Errorf("sim[%d][%d] = %g, want %g", i/4, i%4, v, want[i])
+		}
+	}
+	// off-diagonal pairs: 1 (twice), -1 (4 times), NaN (6 times, ignored)
+	if d, want := Differentiation(sim, 4), 1-(2.0-4.0)/6; math.Abs(d-want) > 1e-9 {
+		t.Errorf("Differentiation = %g, want %g", d, want)
+	}
+	base := [][]float64{{1, 0, 0, 1}, {0, 1, 1, 0}, nil, {1, 0, 0, 1}}
+	if s := Stability(pats, base); math.Abs(s) > 1e-9 {
+		t.Errorf("Stability = %g, want 0 (mean of 1 and -1)", s)
+	}
+	if s := Stability(pats, nil); !math.IsNaN(s) {
+		t.Errorf("Stability without base = %g, want NaN", s)
+	}
+}
+
+func TestRunRSA(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.OutDir = t.TempDir()
+	ss.Acts.RSA = true
+	ss.Acts.SaveRSA = true
+	ss.ConfigRunLog(ss.RunLog)
+	ss.Init()
+	if err := ss.RunProtocol(TestingEffect("T", "AB")); err != nil {
+		t.Fatal(err)
+	}
+	if ss.ActLog.ColIdx("Cortex ActM") < 0 || ss.ActLog.ColIdx("CA3 ActQ1") >= 0 {
+		t.Errorf("ActLog does not have just the RSA variable of the RSA layers")
+	}
+	rl := ss.RSALog
+	n := ss.Pat.ListSize
+	rows := map[string]int{}
+	for row := 0; row < rl.Rows; row++ {
+		stg, lnm := rl.CellString("Stage", row), rl.CellString("Layer", row)
+		rows[stg]++
+		if stg == "study" && math.Abs(rl.CellFloat("Stab", row)-1) > 1e-6 {
+			t.Errorf("%s study Stab = %g, want 1", lnm, rl.CellFloat("Stab", row))
+		}
+		for i := 0; i < n; i++ {
+			if v := rl.CellTensorFloat1D("Sim", row, i*n+i); !math.IsNaN(v) && math.Abs(v-1) > 1e-6 {
+				t.Errorf("%s %s: Sim[%d][%d] = %g, want 1", stg, lnm, i, i, v)
+			}
+		}
+	}
+	for _, stg := range []string{"study", "rp", "restudy", "study_full", "test_full", "restudy_full", "test_hip"} {
+		if rows[stg] != len(ss.Acts.RSALays) {
+			t.Errorf("stage %s: %d RSALog rows, want one per layer", stg, rows[stg])
+		}
+	}
+
+	dt := ss.RunLog
+	for _, lnm := range ss.Acts.RSALays {
+		for _, st := range RSAStats {
+			for _, cd := range ss.Conds.Conditions {
+				if v := dt.CellFloat(RSACol(lnm, cd.Name, st), 0); math.IsNaN(v) {
+					t.Errorf("%s = NaN", RSACol(lnm, cd.Name, st))
+				}
+			}
+			te := dt.CellFloat(RSACol(lnm, "TE", st), 0)
+			want := dt.CellFloat(RSACol(lnm, "RP", st), 0) - dt.CellFloat(RSACol(lnm, "Restudy", st), 0)
+			if math.Abs(te-want) > 1e-9 {
+				t.Errorf("%s = %g, want %g", RSACol(lnm, "TE", st), te, want)
+			}
+		}
+	}
+	if ss.RunStats.ColIdx("CA3 TE Diff:CI95") < 0 {
+		t.Errorf("RunStats has no RSA columns")
+	}
+
+	fnm := ss.RSAFileName()
+	if _, err := os.Stat(fnm); err != nil {
+		t.Fatal(err)
+	}
+	rp := &Report{Dir: ss.OutDir}
+	if err := rp.Run(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(rp.Out, "report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "## Similarity") {
+		t.Errorf("report.md has no Similarity section")
+	}
+	hm := fileSafe(strings.TrimSuffix(filepath.Base(fnm), ".tsv")+"_"+ss.RunName()+"_test_full_CA3_sim") + ".svg"
+	if _, err := os.Stat(filepath.Join(rp.Out, hm)); err != nil {
+		t.Errorf("no heat map %s: %v", hm, err)
+	}
+}
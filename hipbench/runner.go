@@ -0,0 +1,232 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// Job is one run (subject) of the model, which any worker goroutine or process
+// can do on its own Sim
+type Job struct {
+	Run    int      `desc:"run number -- the seeds of its random streams are derived from it and the template RndSeed (see Seeds)"`
+	Tag    string   `desc:"Tag for the run, if different from the template Sim's (e.g., the cell of a sweep)"`
+	Params []string `desc:"ParamSets applied after the template's, in order (e.g., the factors of a sweep)"`
+}
+
+// RunJobs returns jobs for runs 0..nruns-1
+func RunJobs(nruns int) []Job {
+	jobs := make([]Job, nruns)
+	for i := range jobs {
+		jobs[i].Run = i
+	}
+	return jobs
+}
+
+// Runner does Jobs, each with its own Sim and network, in a pool of at most
+// NWorkers goroutines, and merges their RunLog, test epoch, test trial and
+// retrieval practice trial rows in job order.
+// Each job's Sim copies its settings from the template Sim, and draws from its
+// own random streams (see Seeds and WithRand), so the results of each job are
+// the same however many workers there are, and however they are scheduled.
+type Runner struct {
+	Sim       *Sim                `desc:"template Sim whose settings each job copies"`
+	Jobs      []Job               `desc:"the jobs to do"`
+	NWorkers  int                 `desc:"maximum number of jobs at once -- 0 = number of CPUs"`
+	Run       func(ss *Sim) error `view:"-" desc:"what each job does, after its Sim is configured and initialized -- defaults to Train"`
+	RunLogs   []*etable.Table     `view:"-" desc:"RunLog of each job"`
+	TstEpcs   []*etable.Table     `view:"-" desc:"TstEpcLog of each job"`
+	TstTrlAll []*etable.Table     `view:"-" desc:"all TstTrlLog rows of each job"`
+	RPTrls    []*etable.Table     `view:"-" desc:"RPTrlLog of each job"`
+	RunLog    *etable.Table       `desc:"RunLog rows of all jobs, in job order"`
+	TstEpcLog *etable.Table       `desc:"TstEpcLog rows of all jobs, in job order"`
+	TstTrlLog *etable.Table       `desc:"TstTrlLog rows of all jobs, in job order"`
+	RPTrlLog  *etable.Table       `desc:"RPTrlLog rows of all jobs, in job order"`
+}
+
+// NewRunner returns a Runner for the jobs on copies of the template sim, using nworkers
+func NewRunner(tmpl *Sim, jobs []Job, nworkers int) *Runner {
+	return &Runner{Sim: tmpl, Jobs: jobs, NWorkers: nworkers}
+}
+
+// NewSim returns a new, configured and initialized Sim for given job,
+// with the settings of the template Sim
+func (rn *Runner) NewSim(job *Job) *Sim {
+	tm := rn.Sim
+	ss := &Sim{Model: tm.Model}
+	ss.New()
+	ss.Hip = tm.Hip
+	ss.Pat = tm.Pat
+	ss.Ret = tm.Ret
+	ss.RP = tm.RP
+	ss.Acts = tm.Acts
+	ss.Cyc = tm.Cyc
+	ss.Conds = tm.Conds
+	ss.WtChg = tm.WtChg
+	ss.ParamSet = tm.ParamSet
+	ss.ExtraParams = append(append([]string{}, tm.ExtraParams...), job.Params...)
+	ss.Tag = tm.Tag
+	if job.Tag != "" {
+		ss.Tag = job.Tag
+	}
+	ss.MaxEpcs = tm.MaxEpcs
+	ss.PreTrainEpcs = tm.PreTrainEpcs
+	ss.AETrainEpcs = tm.AETrainEpcs
+	ss.NZeroStop = tm.NZeroStop
+	ss.MemLay = tm.MemLay
+	ss.Record = tm.Record
+	ss.FamThr = tm.FamThr
+	ss.LogSetParams = tm.LogSetParams
+	ss.SaveWts = tm.SaveWts
+	ss.OutDir = tm.OutDir
+	ss.CkptDir = tm.CkptDir
+	ss.SnapFile = tm.SnapFile
+	ss.RndSeed = tm.RndSeed // master seed: the seeds of the run are derived from it and job.Run
+	ss.MaxRuns = 1
+	ss.StartRun = job.Run
+	ss.ViewOn = false
+	ss.NoThreads = true
+	ss.NoSave = true
+	ss.Config()
+	ss.TstTrlAll = &etable.Table{}
+	ss.ConfigTstTrlLog(ss.TstTrlAll)
+	ss.TstTrlAll.SetNumRows(0)
+	ss.Init()
+	return ss
+}
+
+// Exec does all the jobs and merges their logs into RunLog, TstEpcLog,
+// TstTrlLog and RPTrlLog, returning the error of the first job (in job order) that failed
+func (rn *Runner) Exec() error {
+	nw := rn.NWorkers
+	if nw <= 0 {
+		nw = runtime.NumCPU()
+	}
+	nj := len(rn.Jobs)
+	rn.RunLogs = make([]*etable.Table, nj)
+	rn.TstEpcs = make([]*etable.Table, nj)
+	rn.TstTrlAll = make([]*etable.Table, nj)
+	rn.RPTrls = make([]*etable.Table, nj)
+	errs := make([]error, nj)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < nw; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ji := range jobs {
+				job := &rn.Jobs[ji]
+				ss := rn.NewSim(job)
+				if rn.Run != nil {
+					errs[ji] = rn.Run(ss)
+				} else {
+					ss.Train()
+				}
+				ss.Net.StopThreads()
+				rn.RunLogs[ji] = ss.RunLog
+				rn.TstEpcs[ji] = ss.TstEpcLog
+				rn.TstTrlAll[ji] = ss.TstTrlAll
+				rn.RPTrls[ji] = ss.RPTrlLog
+			}
+		}()
+	}
+	for ji := 0; ji < nj; ji++ {
+		jobs <- ji
+	}
+	close(jobs)
+	wg.Wait()
+
+	rn.RunLog = MergeLogs(rn.RunLogs)
+	rn.TstEpcLog = MergeLogs(rn.TstEpcs)
+	rn.TstTrlLog = MergeLogs(rn.TstTrlAll)
+	rn.RPTrlLog = MergeLogs(rn.RPTrls)
+	for ji, err := range errs {
+		if err != nil {
+			return fmt.Errorf("run %d: %v", rn.Jobs[ji].Run, err)
+		}
+	}
+	return nil
+}
+
+// MergeLogs returns a table with the rows of each of the tables, in order
+func MergeLogs(dts []*etable.Table) *etable.Table {
+	if len(dts) == 0 {
+		return &etable.Table{}
+	}
+	dt := dts[0].Clone()
+	for _, d := range dts[1:] {
+		dt.AppendRows(d)
+	}
+	return dt
+}
+
+// JobLog returns the rows of each of the tables, in order, in a table configured
+// by cfg, with a Job column holding the index of their job in the full list of
+// jobs -- for gathering the logs of jobs done in different processes, and putting
+// them back in order with SortJobLog
+func JobLog(dts []*etable.Table, jobs []int, cfg func(dt *etable.Table)) *etable.Table {
+	dt := &etable.Table{}
+	cfg(dt)
+	dt.SetNumRows(0)
+	for _, d := range dts {
+		dt.AppendRows(d)
+	}
+	dt.AddCol(etensor.NewInt64([]int{dt.Rows}, nil, nil), "Job")
+	row := 0
+	for i, d := range dts {
+		for r := 0; r < d.Rows; r++ {
+			dt.SetCellFloat("Job", row, float64(jobs[i]))
+			row++
+		}
+	}
+	return dt
+}
+
+// SortJobLog returns the rows of a JobLog table in job order, without the
+// Job column, in a table configured by cfg.  Rows with a negative Job
+// (padding) are dropped.
+func SortJobLog(jl *etable.Table, cfg func(dt *etable.Table)) *etable.Table {
+	ix := etable.NewIdxView(jl)
+	ix.Filter(func(et *etable.Table, row int) bool {
+		return et.CellFloat("Job", row) >= 0
+	})
+	ix.SortStableColName("Job", true)
+	dt := &etable.Table{}
+	cfg(dt)
+	dt.SetNumRows(0)
+	dt.AppendRows(ix.NewTable())
+	return dt
+}
+
+// SaveStages saves the test trial rows of each protocol test stage in dt to
+// Tag_Save.tsv, as RunProtocol does for a single Sim
+func (ss *Sim) SaveStages(pr *Protocol, dt *etable.Table) {
+	for i := range pr.Stages {
+		st := &pr.Stages[i]
+		if st.Do != "test" || st.Save == "" {
+			continue
+		}
+		ix := etable.NewIdxView(dt)
+		ix.Filter(func(et *etable.Table, row int) bool {
+			return et.CellString("Stage", row) == st.Save
+		})
+		fnm := ss.OutFile(ss.Tag + "_" + st.Save + ".tsv")
+		f, err := os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		fmt.Printf("Saving test trial log to: %v\n", fnm)
+		ix.WriteCSV(f, etable.Tab, etable.Headers)
+		f.Close()
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// testSim returns a small, configured template Sim for tests
+func testSim(t *testing.T) *Sim {
+	t.Helper()
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	ss.Pat.ListSize = 4
+	ss.MaxEpcs = 1
+	ss.PreTrainEpcs = 1
+	ss.ViewOn = false
+	ss.NoThreads = true
+	ss.NoSave = true
+	ss.CkptDir = t.TempDir()
+	ss.Config()
+	return ss
+}
+
+// testProtocol is a short protocol with one study and one saved test
+var testProtocol = &Protocol{Name: "Test", Stages: []Stage{
+	{Do: "init"},
+	{Do: "study"},
+	{Do: "test", Save: "final"},
+}}
+
+func TestRunnerRunLog(t *testing.T) {
+	const nruns = 3
+	ss := testSim(t)
+	rn := NewRunner(ss, RunJobs(nruns), 2)
+	rn.Run = func(rs *Sim) error { return rs.RunProtocol(testProtocol) }
+	if err := rn.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if rn.RunLog.Rows != nruns {
+		t.Fatalf("RunLog has %d rows, want %d", rn.RunLog.Rows, nruns)
+	}
+	for row := 0; row < nruns; row++ {
+		if run := int(rn.RunLog.CellFloat("Run", row)); run != row {
+			t.Errorf("RunLog row %d: Run = %d, want %d", row, run, row)
+		}
+		if st := rn.RunLog.CellString("Stage", row); st != "final" {
+			t.Errorf("RunLog row %d: Stage = %q, want %q", row, st, "final")
+		}
+	}
+	for ji, dt := range rn.TstTrlAll {
+		if dt.Rows == 0 {
+			t.Errorf("job %d: no test trial rows", ji)
+		}
+		for row := 0; row < dt.Rows; row++ {
+			if run := int(dt.CellFloat("Run", row)); run != ji {
+				t.Fatalf("job %d: TstTrlLog row %d: Run = %d", ji, row, run)
+			}
+		}
+	}
+	prev := -1
+	for row := 0; row < rn.TstTrlLog.Rows; row++ {
+		run := int(rn.TstTrlLog.CellFloat("Run", row))
+		if run < prev {
+			t.Fatalf("merged TstTrlLog row %d: Run %d after %d", row, run, prev)
+		}
+		prev = run
+	}
+}
+
+func TestRunnerRPTrlLog(t *testing.T) {
+	const nruns = 2
+	ss := testSim(t)
+	rn := NewRunner(ss, RunJobs(nruns), 2)
+	pr := &Protocol{Name: "RP", Stages: []Stage{
+		{Do: "init"},
+		{Do: "study"},
+		{Do: "rp", N: 2},
+		{Do: "test", Save: "final"},
+	}}
+	rn.Run = func(rs *Sim) error { return rs.RunProtocol(pr) }
+	if err := rn.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	rl := rn.RPTrlLog
+	nitems := ss.TrainRP.Rows
+	if rl.Rows != nruns*2*nitems {
+		t.Fatalf("RPTrlLog has %d rows, want %d", rl.Rows, nruns*2*nitems)
+	}
+	learned := false
+	for row := 0; row < rl.Rows; row++ {
+		if run := int(rl.CellFloat("Run", row)); run != row/(2*nitems) {
+			t.Errorf("RPTrlLog row %d: Run = %d, want %d", row, run, row/(2*nitems))
+		}
+		if att := int(rl.CellFloat("Attempt", row)); att != 1+(row/nitems)%2 {
+			t.Errorf("RPTrlLog row %d (%s): Attempt = %d, want %d", row, rl.CellString("TrialName", row), att, 1+(row/nitems)%2)
+		}
+		if fb := rl.CellString("Feedback", row); fb != ss.RP.Feedback {
+			t.Errorf("RPTrlLog row %d: Feedback = %q, want %q", row, fb, ss.RP.Feedback)
+		}
+		for _, cn := range []string{"CA312", "CA323", "CA334"} {
+			if v := rl.CellFloat(cn, row); v == 0 || math.IsNaN(v) {
+				t.Errorf("RPTrlLog row %d: %s = %g", row, cn, v)
+			}
+		}
+		if rl.CellFloat("CA3ToCA3 dWt", row) > 0 {
+			learned = true
+		}
+		if v := rl.CellFloat("CA1ToECout dWt", row); v != 0 { // the encoder does not learn in practice
+			t.Errorf("RPTrlLog row %d: CA1ToECout dWt = %g, want 0", row, v)
+		}
+	}
+	if !learned {
+		t.Errorf("RPTrlLog: no CA3ToCA3 learning in practice")
+	}
+	for row := 0; row < rn.TstTrlLog.Rows; row++ {
+		if st := rn.TstTrlLog.CellString("Stage", row); st != "final" {
+			t.Fatalf("TstTrlLog row %d: Stage = %q, want final -- practice trials in the test log", row, st)
+		}
+	}
+}
+
+// configJobTest configures a small log table for the JobLog tests
+func configJobTest(dt *etable.Table) {
+	dt.SetFromSchema(etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Name", etensor.STRING, nil, nil},
+	}, 0)
+}
+
+// jobTestLog returns a log table with a row for each of the names, of the run
+func jobTestLog(run int, names ...string) *etable.Table {
+	dt := &etable.Table{}
+	configJobTest(dt)
+	dt.SetNumRows(len(names))
+	for i, nm := range names {
+		dt.SetCellFloat("Run", i, float64(run))
+		dt.SetCellString("Name", i, nm)
+	}
+	return dt
+}
+
+// jobTestNames returns the Run:Name of each row of dt
+func jobTestNames(dt *etable.Table) []string {
+	var nms []string
+	for row := 0; row < dt.Rows; row++ {
+		nms = append(nms, fmt.Sprintf("%s:%d", dt.CellString("Name", row), int(dt.CellFloat("Run", row))))
+	}
+	return nms
+}
+
+func TestMergeLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		dts  []*etable.Table
+		want []string
+	}{
+		{"none", nil, nil},
+		{"one", []*etable.Table{jobTestLog(0, "a", "b")}, []string{"a:0", "b:0"}},
+		{"in order", []*etable.Table{jobTestLog(0, "a"), jobTestLog(1), jobTestLog(2, "b", "c")}, []string{"a:0", "b:2", "c:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jobTestNames(MergeLogs(tt.dts)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeLogs rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards [][]int // jobs of each shard
+		want   []string
+	}{
+		{"one shard", [][]int{{0, 1, 2}}, []string{"r0:0", "r1:1", "r2:2"}},
+		{"two shards", [][]int{{0, 2, 4}, {1, 3}}, []string{"r0:0", "r1:1", "r2:2", "r3:3", "r4:4"}},
+		{"reversed shards", [][]int{{3}, {1, 2}, {0}}, []string{"r0:0", "r1:1", "r2:2", "r3:3"}},
+		{"empty shard", [][]int{{0, 1}, nil}, []string{"r0:0", "r1:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var jls []*etable.Table
+			for _, idx := range tt.shards {
+				var dts []*etable.Table
+				for _, ji := range idx {
+					dts = append(dts, jobTestLog(ji, fmt.Sprintf("r%d", ji)))
+				}
+				jl := JobLog(dts, idx, configJobTest)
+				for row := 0; row < jl.Rows; row++ {
+					if int(jl.CellFloat("Job", row)) != int(jl.CellFloat("Run", row)) {
+						t.Errorf("JobLog row %d: Job %g, want %g", row, jl.CellFloat("Job", row), jl.CellFloat("Run", row))
+					}
+				}
+				jls = append(jls, jl)
+			}
+			all := MergeLogs(jls)
+			// padding rows, as GatherJobLog adds, are dropped
+			n := all.Rows
+			all.SetNumRows(n + 2)
+			for row := n; row < all.Rows; row++ {
+				all.SetCellFloat("Job", row, -1)
+			}
+			dt := SortJobLog(all, configJobTest)
+			if dt.ColByName("Job") != nil {
+				t.Errorf("SortJobLog keeps the Job column")
+			}
+			if got := jobTestNames(dt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortJobLog rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
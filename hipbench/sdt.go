@@ -0,0 +1,166 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+// NROC is the number of criteria on Famil, evenly spaced from 0 to 1, at which
+// the ROC curve is computed
+const NROC = 21
+
+// SDTStats are the names of the signal detection stats of the old / new test
+var SDTStats = []string{"HR", "FAR", "DPrime", "C", "AUC"}
+
+// SDT is a signal detection analysis of an old / new recognition test, from the
+// familiarity of the old (studied) items and of the new items (lures)
+type SDT struct {
+	HR     float64   `desc:"hit rate: proportion of old items called old, with the log-linear correction (hits + .5) / (old + 1)"`
+	FAR    float64   `desc:"false alarm rate: proportion of new items called old, with the log-linear correction"`
+	DPrime float64   `desc:"sensitivity: z(HR) - z(FAR)"`
+	C      float64   `desc:"criterion: -(z(HR) + z(FAR)) / 2 -- positive is conservative"`
+	AUC    float64   `desc:"area under the ROC curve: the probability that an old item is more familiar than a new one, counting ties as half"`
+	ROCHR  []float64 `desc:"uncorrected hit rate at each of the NROC criteria"`
+	ROCFAR []float64 `desc:"uncorrected false alarm rate at each of the NROC criteria"`
+}
+
+// NewSDT returns the signal detection analysis of the familiarity of old and
+// new items, calling items old when their familiarity is >= thr
+func NewSDT(old, lure []float64, thr float64) *SDT {
+	sd := &SDT{}
+	no, nn := float64(len(old)), float64(len(lure))
+	sd.HR = (float64(nAtLeast(old, thr)) + .5) / (no + 1)
+	sd.FAR = (float64(nAtLeast(lure, thr)) + .5) / (nn + 1)
+	zh, zf := Probit(sd.HR), Probit(sd.FAR)
+	sd.DPrime = zh - zf
+	sd.C = -(zh + zf) / 2
+
+	sd.ROCHR = make([]float64, NROC)
+	sd.ROCFAR = make([]float64, NROC)
+	for ci := range sd.ROCHR {
+		crit := float64(ci) / float64(NROC-1)
+		if no > 0 {
+			sd.ROCHR[ci] = float64(nAtLeast(old, crit)) / no
+		}
+		if nn > 0 {
+			sd.ROCFAR[ci] = float64(nAtLeast(lure, crit)) / nn
+		}
+	}
+
+	if no > 0 && nn > 0 {
+		wins := 0.0
+		for _, o := range old {
+			for _, n := range lure {
+				switch {
+				case o > n:
+					wins += 1
+				case o == n:
+					wins += .5
+				}
+			}
+		}
+		sd.AUC = wins / (no * nn)
+	}
+	return sd
+}
+
+// nAtLeast returns the number of vals >= thr
+func nAtLeast(vals []float64, thr float64) int {
+	n := 0
+	for _, v := range vals {
+		if v >= thr {
+			n++
+		}
+	}
+	return n
+}
+
+// Probit returns the z score for proportion p: the inverse of the standard normal CDF
+func Probit(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// OldNew returns true if testing is an old / new recognition test: the studied
+// (AB) items and the items of the Lure test, with the familiarity of each
+// scored on Model.FamScore
+func (ss *Sim) OldNew() bool {
+	return ss.Model.Test("Lure") != nil && ss.Model.FamScore.On()
+}
+
+// FamilStats computes Famil: the cosine of ActM and Targ over the FamScore pools,
+// for the familiarity of the current item
+func (ss *Sim) FamilStats(tbl string) {
+	sc := &ss.Model.FamScore
+	ly := ss.Net.LayerByName(sc.Layer).(leabra.LeabraLayer).AsLeabra()
+	pools, _ := ss.ScorePools(sc, tbl)
+	npl := ly.Shp.Dim(0) * ly.Shp.Dim(1)
+	npu := ly.Shp.Dim(2) * ly.Shp.Dim(3)
+	actMi, _ := ly.UnitVarIdx("ActM")
+	targi, _ := ly.UnitVarIdx("Targ")
+	var ab, aa, bb float64
+	for _, pi := range pools {
+		if pi < 0 || pi >= npl {
+			continue
+		}
+		for ni := pi * npu; ni < (pi+1)*npu; ni++ {
+			actm := float64(ly.UnitVal1D(actMi, ni))
+			trg := float64(ly.UnitVal1D(targi, ni))
+			ab += actm * trg
+			aa += actm * actm
+			bb += trg * trg
+		}
+	}
+	ss.Famil = 0
+	if aa > 0 && bb > 0 {
+		ss.Famil = ab / math.Sqrt(aa*bb)
+	}
+}
+
+// TestSDT returns the signal detection analysis of the old / new test in
+// TstTrlLog: the AB items are old and the Lure items are new
+func (ss *Sim) TestSDT() *SDT {
+	dt := ss.TstTrlLog
+	var old, lure []float64
+	for row := 0; row < dt.Rows; row++ {
+		switch dt.CellString("TestNm", row) {
+		case "AB":
+			old = append(old, dt.CellFloat("Famil", row))
+		case "Lure":
+			lure = append(lure, dt.CellFloat("Famil", row))
+		}
+	}
+	return NewSDT(old, lure, ss.FamThr)
+}
+
+// LogSDT records the signal detection analysis of the old / new test in the
+// TstEpcLog row
+func (ss *Sim) LogSDT(dt *etable.Table, row int) {
+	sd := ss.TestSDT()
+	dt.SetCellFloat("HR", row, sd.HR)
+	dt.SetCellFloat("FAR", row, sd.FAR)
+	dt.SetCellFloat("DPrime", row, sd.DPrime)
+	dt.SetCellFloat("C", row, sd.C)
+	dt.SetCellFloat("AUC", row, sd.AUC)
+	for ci := 0; ci < NROC; ci++ {
+		dt.SetCellTensorFloat1D("ROC HR", row, ci, sd.ROCHR[ci])
+		dt.SetCellTensorFloat1D("ROC FAR", row, ci, sd.ROCFAR[ci])
+	}
+}
+
+// SDTSchema returns the TstEpcLog and RunLog columns of the old / new test
+func SDTSchema() etable.Schema {
+	var sch etable.Schema
+	for _, st := range SDTStats {
+		sch = append(sch, etable.Column{st, etensor.FLOAT64, nil, nil})
+	}
+	sch = append(sch, etable.Column{"ROC HR", etensor.FLOAT64, []int{NROC}, []string{"Crit"}})
+	sch = append(sch, etable.Column{"ROC FAR", etensor.FLOAT64, []int{NROC}, []string{"Crit"}})
+	return sch
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+	"testing"
+)
+
+const sdtTol = 1e-4
+
+func TestProbit(t *testing.T) {
+	tests := []struct {
+		p, z float64
+	}{
+		{0.5, 0},
+		{0.975, 1.959964},
+		{0.025, -1.959964},
+		{0.8413447, 1},
+		{0.1586553, -1},
+	}
+	for _, tt := range tests {
+		if z := Probit(tt.p); math.Abs(z-tt.z) > sdtTol {
+			t.Errorf("Probit(%g) = %g, want %g", tt.p, z, tt.z)
+		}
+	}
+}
+
+func TestNewSDT(t *testing.T) {
+	tests := []struct {
+		name                string
+		old, lure           []float64
+		thr                 float64
+		hr, far, dp, c, auc float64
+	}{
+		{"perfect", []float64{1, 1, 1}, []float64{0, 0, 0}, .5, .875, .125, 2.300698, 0, 1},
+		{"chance", []float64{.2, .8}, []float64{.2, .8}, .5, .5, .5, 0, 0, .5},
+		{"reversed", []float64{0}, []float64{1}, .5, .25, .75, -1.348980, 0, 0},
+		{"liberal", []float64{.6, .6}, []float64{.6, .6}, .5, .833333, .833333, 0, -0.967422, .5},
+		{"no lures", []float64{1}, nil, .5, .75, .5, 0.674490, -0.337245, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := NewSDT(tt.old, tt.lure, tt.thr)
+			for _, v := range []struct {
+				nm        string
+				got, want float64
+			}{{"HR", sd.HR, tt.hr}, {"FAR", sd.FAR, tt.far}, {"DPrime", sd.DPrime, tt.dp}, {"C", sd.C, tt.c}, {"AUC", sd.AUC, tt.auc}} {
+				if math.Abs(v.got-v.want) > sdtTol {
+					t.Errorf("%s = %g, want %g", v.nm, v.got, v.want)
+				}
+			}
+			if len(sd.ROCHR) != NROC || len(sd.ROCFAR) != NROC {
+				t.Fatalf("ROC has %d, %d points, want %d", len(sd.ROCHR), len(sd.ROCFAR), NROC)
+			}
+			for ci := 1; ci < NROC; ci++ {
+				if sd.ROCHR[ci] > sd.ROCHR[ci-1] || sd.ROCFAR[ci] > sd.ROCFAR[ci-1] {
+					t.Errorf("ROC rates increase with the criterion at %d", ci)
+				}
+			}
+			if len(tt.old) > 0 && sd.ROCHR[0] != 1 {
+				t.Errorf("ROC HR at criterion 0 = %g, want 1", sd.ROCHR[0])
+			}
+		})
+	}
+}
+
+func TestSDTAUCFromROC(t *testing.T) {
+	// with no ties within the criteria, the AUC is the area under the ROC
+	old := []float64{.9, .7, .55, .3}
+	lure := []float64{.62, .4, .2, .1}
+	sd := NewSDT(old, lure, .5)
+	area := 0.0
+	for ci := 1; ci < NROC; ci++ {
+		area += (sd.ROCFAR[ci-1] - sd.ROCFAR[ci]) * (sd.ROCHR[ci-1] + sd.ROCHR[ci]) / 2
+	}
+	area += sd.ROCFAR[NROC-1] * sd.ROCHR[NROC-1] / 2
+	if math.Abs(area-sd.AUC) > sdtTol {
+		t.Errorf("area under the ROC = %g, AUC = %g", area, sd.AUC)
+	}
+}
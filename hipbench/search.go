@@ -0,0 +1,534 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Parameter search
+
+// SearchMethods are the methods of Search
+var SearchMethods = map[string]bool{
+	"grid":    true, // each combination of the values of the params
+	"random":  true, // N random points within the Min, Max of each param
+	"halving": true, // successive halving: N random points, keeping the better half after each round, with twice the runs
+}
+
+// SearchParam is one parameter searched over, by its path: a numeric field of
+// Hip, Pat or Sim (e.g., "Hip.MossyDel", "Pat.ListSize", "Sim.MaxEpcs"), or a
+// Network param of the layers or projections matching a selector, as
+// "Network:<sel>:<param>" (e.g., "Network:.HippoCHL:Prjn.Learn.Lrate").
+// Values of integer fields are rounded.
+type SearchParam struct {
+	Path string    `desc:"path of the parameter: Hip.<field>, Pat.<field>, Sim.<field> or Network:<sel>:<param>"`
+	Min  float64   `desc:"smallest value of random and halving search, and of the default grid"`
+	Max  float64   `desc:"largest value of random and halving search, and of the default grid"`
+	Vals []float64 `desc:"values of grid search -- default N values evenly spaced from Min to Max"`
+	N    int       `desc:"number of grid values from Min to Max if Vals is empty -- default 3"`
+}
+
+// GridVals returns the values of the parameter in a grid search
+func (sp *SearchParam) GridVals() []float64 {
+	if len(sp.Vals) > 0 {
+		return sp.Vals
+	}
+	n := sp.N
+	if n <= 0 {
+		n = 3
+	}
+	if n == 1 {
+		return []float64{sp.Min}
+	}
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = sp.Min + (sp.Max-sp.Min)*float64(i)/float64(n-1)
+	}
+	return vals
+}
+
+// Objective is the score that a Search maximizes, for each run: the mean Stat
+// over the test trials of Test in the test stage saved as Stage, minus that in
+// the test stage saved as Base, if set.  The default is the testing effect of
+// the built-in protocols: final AB recall after retrieval practice minus that
+// after restudy.
+type Objective struct {
+	Stat  string `desc:"test trial stat -- e.g., Mem"`
+	Test  string `desc:"test (TestNm) of the trials scored -- e.g., AB"`
+	Stage string `desc:"Save name of the test stage scored"`
+	Base  string `desc:"Save name of the test stage whose mean is subtracted -- none if empty"`
+}
+
+func (ob *Objective) Defaults() {
+	ob.Stat = "Mem"
+	ob.Test = "AB"
+	ob.Stage = "test_full"
+	ob.Base = "restudy_full"
+}
+
+// Scores returns the objective of each run in the test trial log dt, of the
+// rows whose Params is params
+func (ob *Objective) Scores(dt *etable.Table, params string) map[int]float64 {
+	type sum struct {
+		sum [2]float64
+		n   [2]int
+	}
+	sums := map[int]*sum{}
+	for row := 0; row < dt.Rows; row++ {
+		if dt.CellString("Params", row) != params || dt.CellString("TestNm", row) != ob.Test {
+			continue
+		}
+		si := -1
+		switch dt.CellString("Stage", row) {
+		case ob.Stage:
+			si = 0
+		case ob.Base:
+			si = 1
+		}
+		if si < 0 || (si == 1 && ob.Base == "") {
+			continue
+		}
+		run := int(dt.CellFloat("Run", row))
+		sm, ok := sums[run]
+		if !ok {
+			sm = &sum{}
+			sums[run] = sm
+		}
+		sm.sum[si] += dt.CellFloat(ob.Stat, row)
+		sm.n[si]++
+	}
+	scs := map[int]float64{}
+	for run, sm := range sums {
+		if sm.n[0] == 0 || (ob.Base != "" && sm.n[1] == 0) {
+			continue
+		}
+		sc := sm.sum[0] / float64(sm.n[0])
+		if ob.Base != "" {
+			sc -= sm.sum[1] / float64(sm.n[1])
+		}
+		scs[run] = sc
+	}
+	return scs
+}
+
+// Search is a parameter search: each candidate set of values of the Params
+// runs the protocol for a number of runs (seeds), and the candidates are ranked
+// by the mean of the Objective over their runs.  The runs of each candidate
+// have the same seeds as those of the others (see Seeds), so candidates are
+// compared on the same stimuli and initial weights.
+// Searches can be saved and loaded as JSON.
+type Search struct {
+	Method    string        `desc:"grid, random or halving (see SearchMethods)"`
+	Params    []SearchParam `desc:"the parameters searched over"`
+	Objective Objective     `desc:"the score maximized"`
+	Runs      int           `desc:"number of runs of each candidate -- for halving, of the candidates of the last round"`
+	N         int           `desc:"number of candidates of random and halving search"`
+}
+
+func (sr *Search) Defaults() {
+	sr.Method = "random"
+	sr.Objective.Defaults()
+	sr.Runs = 5
+	sr.N = 20
+}
+
+// OpenSearch loads a Search from a JSON file, with the defaults for the
+// fields that it does not set
+func OpenSearch(fname string) (*Search, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	sr := &Search{}
+	sr.Defaults()
+	if err := json.Unmarshal(b, sr); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return sr, nil
+}
+
+// SaveSearch saves the Search to a JSON file
+func (sr *Search) SaveSearch(fname string) error {
+	b, err := json.MarshalIndent(sr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, b, 0644)
+}
+
+// Candidate is one set of values of the params of a Search, with the
+// objective of each of its runs so far
+type Candidate struct {
+	Name   string    `desc:"name of its ParamSet, which sets the values"`
+	Vals   []float64 `desc:"value of each of the Search Params"`
+	Scores []float64 `desc:"objective of each run, for runs 0..len-1"`
+	Score  float64   `desc:"mean of the Scores"`
+	SEM    float64   `desc:"standard error of the mean of the Scores"`
+}
+
+// SetScore computes Score and SEM from Scores
+func (cd *Candidate) SetScore() {
+	n := float64(len(cd.Scores))
+	cd.Score, cd.SEM = 0, 0
+	if n == 0 {
+		return
+	}
+	for _, sc := range cd.Scores {
+		cd.Score += sc
+	}
+	cd.Score /= n
+	if n < 2 {
+		return
+	}
+	vr := 0.0
+	for _, sc := range cd.Scores {
+		vr += (sc - cd.Score) * (sc - cd.Score)
+	}
+	cd.SEM = math.Sqrt(vr / (n - 1) / n)
+}
+
+// searchRoots returns the structs of the Sim whose fields can be searched, by path prefix
+func (ss *Sim) searchRoots() map[string]reflect.Value {
+	return map[string]reflect.Value{
+		"Hip": reflect.ValueOf(&ss.Hip).Elem(),
+		"Pat": reflect.ValueOf(&ss.Pat).Elem(),
+		"Sim": reflect.ValueOf(ss).Elem(),
+	}
+}
+
+// searchField returns the sheet, the params path and the field of a Hip, Pat
+// or Sim search path
+func (ss *Sim) searchField(path string) (sheet, ppath string, fv reflect.Value, err error) {
+	flds := strings.Split(path, ".")
+	rv, ok := ss.searchRoots()[flds[0]]
+	if !ok || len(flds) < 2 {
+		return "", "", fv, fmt.Errorf("hipbench: search param %s: not Hip.<field>, Pat.<field>, Sim.<field> or Network:<sel>:<param>", path)
+	}
+	fv = rv
+	for _, fn := range flds[1:] {
+		if fv.Kind() != reflect.Struct {
+			return "", "", fv, fmt.Errorf("hipbench: search param %s: %s is not a struct", path, fv.Type())
+		}
+		fv = fv.FieldByName(fn)
+		if !fv.IsValid() {
+			return "", "", fv, fmt.Errorf("hipbench: search param %s: no field %s", path, fn)
+		}
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+	default:
+		return "", "", fv, fmt.Errorf("hipbench: search param %s: %s is not a number", path, fv.Type())
+	}
+	// params are applied to an object whose type name is the first element of the path
+	return flds[0], rv.Type().Name() + "." + strings.Join(flds[1:], "."), fv, nil
+}
+
+// networkPath returns the selector and param of a Network search path
+func networkPath(path string) (sel, param string, ok bool) {
+	flds := strings.Split(path, ":")
+	if len(flds) != 3 || flds[0] != "Network" || flds[1] == "" || flds[2] == "" {
+		return "", "", false
+	}
+	return flds[1], flds[2], true
+}
+
+// Validate returns an error if the search cannot be run on the Sim
+func (sr *Search) Validate(ss *Sim) error {
+	if !SearchMethods[sr.Method] {
+		return fmt.Errorf("hipbench: unknown search method: %s", sr.Method)
+	}
+	if len(sr.Params) == 0 {
+		return fmt.Errorf("hipbench: search has no params")
+	}
+	if sr.Runs <= 0 {
+		return fmt.Errorf("hipbench: search needs at least 1 run per candidate")
+	}
+	if sr.Method != "grid" && sr.N <= 0 {
+		return fmt.Errorf("hipbench: %s search needs at least 1 candidate", sr.Method)
+	}
+	if sr.Objective.Stat == "" || sr.Objective.Test == "" || sr.Objective.Stage == "" {
+		return fmt.Errorf("hipbench: search objective needs a Stat, Test and Stage")
+	}
+	for i := range sr.Params {
+		sp := &sr.Params[i]
+		if _, _, ok := networkPath(sp.Path); !ok {
+			if _, _, _, err := ss.searchField(sp.Path); err != nil {
+				return err
+			}
+		}
+		if sr.Method != "grid" && !(sp.Min < sp.Max) {
+			return fmt.Errorf("hipbench: search param %s: Min %g is not less than Max %g", sp.Path, sp.Min, sp.Max)
+		}
+	}
+	return nil
+}
+
+// Candidates returns the initial candidates of the search, named prefix000 etc.
+// The random points are drawn from a stream seeded from the master seed of the
+// Sim, so every process of a distributed search has the same candidates.
+func (sr *Search) Candidates(ss *Sim, prefix string) []*Candidate {
+	var vals [][]float64
+	if sr.Method == "grid" {
+		vals = [][]float64{nil}
+		for i := range sr.Params {
+			var nv [][]float64
+			for _, v := range vals {
+				for _, gv := range sr.Params[i].GridVals() {
+					nv = append(nv, append(append([]float64{}, v...), gv))
+				}
+			}
+			vals = nv
+		}
+	} else {
+		rs := NewStream(DeriveSeed(ss.RndSeed, 0, "Search"))
+		for ci := 0; ci < sr.N; ci++ {
+			v := make([]float64, len(sr.Params))
+			for i := range sr.Params {
+				sp := &sr.Params[i]
+				v[i] = sp.Min + (sp.Max-sp.Min)*rs.Float64()
+			}
+			vals = append(vals, v)
+		}
+	}
+	cds := make([]*Candidate, len(vals))
+	for i, v := range vals {
+		cds[i] = &Candidate{Name: fmt.Sprintf("%s%03d", prefix, i), Vals: v}
+	}
+	return cds
+}
+
+// ParamSet returns the ParamSet of the given name that sets the params of the
+// search to the values -- one Sel per Network param, and one for each of the
+// Hip, Pat and Sim sheets
+func (sr *Search) ParamSet(ss *Sim, name string, vals []float64) (*params.Set, error) {
+	ps := &params.Set{Name: name, Desc: "parameter search values", Sheets: params.Sheets{}}
+	sheet := func(nm string) *params.Sheet {
+		sh, ok := ps.Sheets[nm]
+		if !ok {
+			sh = &params.Sheet{}
+			ps.Sheets[nm] = sh
+		}
+		return sh
+	}
+	for i := range sr.Params {
+		sp := &sr.Params[i]
+		if sel, pp, ok := networkPath(sp.Path); ok {
+			sl := &params.Sel{Sel: sel, Desc: "search", Params: params.Params{}}
+			sl.Params[pp] = strconv.FormatFloat(vals[i], 'g', -1, 64)
+			sh := sheet("Network")
+			*sh = append(*sh, sl)
+			continue
+		}
+		shnm, pp, fv, err := ss.searchField(sp.Path)
+		if err != nil {
+			return nil, err
+		}
+		val := strconv.FormatFloat(vals[i], 'g', -1, 64)
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			val = strconv.Itoa(int(math.Round(vals[i])))
+		}
+		sh := sheet(shnm)
+		if len(*sh) == 0 {
+			*sh = append(*sh, &params.Sel{Sel: shnm, Desc: "search", Params: params.Params{}})
+		}
+		(*sh)[0].Params[pp] = val
+	}
+	return ps, nil
+}
+
+// AddParamSets adds the param sets to those of the model of the Sim -- a copy
+// of the model, so other Sims of the model are not affected -- where they can
+// be used as ParamSet, ExtraParams or the Params of Jobs
+func (ss *Sim) AddParamSets(sets ...*params.Set) error {
+	for _, ps := range sets {
+		if _, err := ss.Model.Params.SetByNameTry(ps.Name); err == nil {
+			return fmt.Errorf("hipbench: there is already a ParamSet named %s", ps.Name)
+		}
+	}
+	m := *ss.Model
+	m.Params = append(append(params.Sets{}, ss.Model.Params...), sets...)
+	ss.Model = &m
+	ss.Params = m.Params
+	return nil
+}
+
+// OpenParamSet adds the ParamSet in a JSON file (e.g., the best of a search)
+// to those of the model (see AddParamSets)
+func (ss *Sim) OpenParamSet(fname string) error {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	ps := &params.Set{}
+	if err := json.Unmarshal(b, ps); err != nil {
+		return fmt.Errorf("%s: %v", fname, err)
+	}
+	return ss.AddParamSets(ps)
+}
+
+// SaveParamSet saves the ParamSet to a JSON file, which OpenParamSet loads
+func SaveParamSet(ps *params.Set, fname string) error {
+	b, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, b, 0644)
+}
+
+// RunSearch runs the search, running the protocol for each candidate as set
+// by Dist, and returns all the candidates, best first: those that got the most
+// runs (that survived the most rounds of halving search), by Score.
+// Halving needs the scores of each round in every process, so it cannot be
+// spread over -procs worker processes (MPI is fine).
+func (ss *Sim) RunSearch(sr *Search, pr *Protocol) ([]*Candidate, error) {
+	if err := sr.Validate(ss); err != nil {
+		return nil, err
+	}
+	if sr.Method == "halving" && (ss.Dist.Procs > 1 || ss.Dist.Shard != "") {
+		return nil, fmt.Errorf("hipbench: halving search needs the scores of each round in every process -- use -mpi or -workers, not -procs")
+	}
+	cds := sr.Candidates(ss, "Search")
+	sets := make([]*params.Set, len(cds))
+	for i, cd := range cds {
+		var err error
+		sets[i], err = sr.ParamSet(ss, cd.Name, cd.Vals)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := ss.AddParamSets(sets...); err != nil {
+		return nil, err
+	}
+	usetag := ss.Tag
+	if usetag != "" {
+		usetag += "_"
+	}
+	runs := sr.Runs
+	if sr.Method == "halving" {
+		for n := len(cds); n > 1 && runs > 1; n = (n + 1) / 2 {
+			runs = (runs + 1) / 2
+		}
+	}
+	live := cds
+	for {
+		fmt.Printf("Search: %d candidates, %d runs each\n", len(live), runs)
+		var jobs []Job
+		for _, cd := range live {
+			for run := len(cd.Scores); run < runs; run++ {
+				jobs = append(jobs, Job{Run: run, Tag: usetag + cd.Name, Params: []string{cd.Name}})
+			}
+		}
+		if err := ss.DoJobs(jobs, func(rs *Sim) error { return rs.RunProtocol(pr) }); err != nil {
+			return nil, err
+		}
+		if ss.Dist.Shard != "" { // the parent scores
+			return nil, nil
+		}
+		for _, cd := range live {
+			scs := sr.Objective.Scores(ss.TstTrlLog, runName(usetag+cd.Name, ss.ParamsName()))
+			for run := len(cd.Scores); run < runs; run++ {
+				sc, ok := scs[run]
+				if !ok {
+					return nil, fmt.Errorf("hipbench: search candidate %s: run %d: no %s trials of %s in stage %s (or %s)", cd.Name, run, sr.Objective.Stat, sr.Objective.Test, sr.Objective.Stage, sr.Objective.Base)
+				}
+				cd.Scores = append(cd.Scores, sc)
+			}
+			cd.SetScore()
+		}
+		SortCandidates(live)
+		if sr.Method != "halving" || len(live) == 1 || runs >= sr.Runs {
+			break
+		}
+		live = live[:(len(live)+1)/2]
+		runs *= 2
+		if runs > sr.Runs {
+			runs = sr.Runs
+		}
+	}
+	SortCandidates(cds)
+	return cds, nil
+}
+
+// SortCandidates sorts the candidates, best first: by number of runs, then Score
+func SortCandidates(cds []*Candidate) {
+	sort.SliceStable(cds, func(i, j int) bool {
+		if len(cds[i].Scores) != len(cds[j].Scores) {
+			return len(cds[i].Scores) > len(cds[j].Scores)
+		}
+		return cds[i].Score > cds[j].Score
+	})
+}
+
+// SearchTable returns a table of the ranked candidates, with the value of
+// each param (named by its path), and the runs, Score and SEM of each
+func (sr *Search) SearchTable(cds []*Candidate) *etable.Table {
+	dt := &etable.Table{}
+	dt.SetMetaData("name", "Search")
+	dt.SetMetaData("desc", "Candidates of a parameter search, best first")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+	sch := etable.Schema{
+		{"Rank", etensor.INT64, nil, nil},
+		{"Name", etensor.STRING, nil, nil},
+	}
+	for i := range sr.Params {
+		sch = append(sch, etable.Column{sr.Params[i].Path, etensor.FLOAT64, nil, nil})
+	}
+	sch = append(sch, etable.Schema{
+		{"Runs", etensor.INT64, nil, nil},
+		{"Score", etensor.FLOAT64, nil, nil},
+		{"SEM", etensor.FLOAT64, nil, nil},
+	}...)
+	dt.SetFromSchema(sch, len(cds))
+	for row, cd := range cds {
+		dt.SetCellFloat("Rank", row, float64(row+1))
+		dt.SetCellString("Name", row, cd.Name)
+		for i := range sr.Params {
+			dt.SetCellFloat(sr.Params[i].Path, row, cd.Vals[i])
+		}
+		dt.SetCellFloat("Runs", row, float64(len(cd.Scores)))
+		dt.SetCellFloat("Score", row, cd.Score)
+		dt.SetCellFloat("SEM", row, cd.SEM)
+	}
+	return dt
+}
+
+// DoSearch runs the search on the protocol, and saves the ranked candidates
+// to <net>_<runname>_search.tsv and the ParamSet of the best, named Best, to
+// <net>_<runname>_best.json, which -paramsfile loads
+func (ss *Sim) DoSearch(sr *Search, pr *Protocol) error {
+	fnm := ss.LogFileName("search")
+	bnm := strings.TrimSuffix(ss.LogFileName("best"), ".tsv") + ".json"
+	cds, err := ss.RunSearch(sr, pr)
+	if err != nil || !ss.IsMaster() {
+		return err
+	}
+	if err := SaveCSV(sr.SearchTable(cds), fnm); err != nil {
+		return err
+	}
+	fmt.Printf("Saving search results to: %v\n", fnm)
+	best := cds[0]
+	ps, err := sr.ParamSet(ss, "Best", best.Vals)
+	if err != nil {
+		return err
+	}
+	ps.Desc = fmt.Sprintf("best of the parameter search: %s, score %g (SEM %g) over %d runs", best.Name, best.Score, best.SEM, len(best.Scores))
+	fmt.Printf("Best: %s, score: %g\n", best.Name, best.Score)
+	fmt.Printf("Saving best ParamSet to: %v\n", bnm)
+	return SaveParamSet(ps, bnm)
+}
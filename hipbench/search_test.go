@@ -0,0 +1,275 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+func TestSearchCandidates(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	tests := []struct {
+		name string
+		sr   Search
+		vals [][]float64 // nil = random
+	}{
+		{"grid", Search{Method: "grid", Params: []SearchParam{{Path: "Hip.MossyDel", Vals: []float64{2, 4}}, {Path: "Pat.ListSize", Min: 10, Max: 30}}},
+			[][]float64{{2, 10}, {2, 20}, {2, 30}, {4, 10}, {4, 20}, {4, 30}}},
+		{"grid n", Search{Method: "grid", Params: []SearchParam{{Path: "Hip.MossyDel", Min: 1, Max: 2, N: 2}}},
+			[][]float64{{1}, {2}}},
+		{"grid one", Search{Method: "grid", Params: []SearchParam{{Path: "Hip.MossyDel", Min: 1, Max: 2, N: 1}}},
+			[][]float64{{1}}},
+		{"random", Search{Method: "random", N: 10, Params: []SearchParam{{Path: "Hip.MossyDel", Min: 1, Max: 2}, {Path: "Pat.DriftPct", Min: 0, Max: .5}}}, nil},
+		{"halving", Search{Method: "halving", N: 4, Params: []SearchParam{{Path: "Hip.MossyDel", Min: 1, Max: 2}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cds := tt.sr.Candidates(ss, "S")
+			if tt.vals != nil {
+				if len(cds) != len(tt.vals) {
+					t.Fatalf("%d candidates, want %d", len(cds), len(tt.vals))
+				}
+				for i, cd := range cds {
+					if !reflect.DeepEqual(cd.Vals, tt.vals[i]) {
+						t.Errorf("candidate %d = %v, want %v", i, cd.Vals, tt.vals[i])
+					}
+				}
+				return
+			}
+			if len(cds) != tt.sr.N {
+				t.Fatalf("%d candidates, want %d", len(cds), tt.sr.N)
+			}
+			again := tt.sr.Candidates(ss, "S")
+			for i, cd := range cds {
+				for pi, v := range cd.Vals {
+					sp := &tt.sr.Params[pi]
+					if v < sp.Min || v >= sp.Max {
+						t.Errorf("candidate %d: %s = %g, not in [%g, %g)", i, sp.Path, v, sp.Min, sp.Max)
+					}
+				}
+				if !reflect.DeepEqual(cd.Vals, again[i].Vals) {
+					t.Errorf("candidate %d differs between calls: %v, %v", i, cd.Vals, again[i].Vals)
+				}
+			}
+			if cds[0].Name != "S000" || cds[1].Name != "S001" {
+				t.Errorf("names %s, %s, want S000, S001", cds[0].Name, cds[1].Name)
+			}
+		})
+	}
+}
+
+func TestSearchParamSet(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	sr := &Search{Method: "grid", Params: []SearchParam{
+		{Path: "Hip.MossyDel"},
+		{Path: "Pat.ListSize"},
+		{Path: "Sim.MemThr"},
+		{Path: "Network:.HippoCHL:Prjn.Learn.Lrate"},
+		{Path: "Network:#CA3ToCA3:Prjn.Learn.Lrate"},
+	}}
+	ps, err := sr.ParamSet(ss, "Cand", []float64{2.5, 19.6, .3, .15, .05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.Name != "Cand" {
+		t.Errorf("Name = %s, want Cand", ps.Name)
+	}
+	tests := []struct {
+		sheet, sel, path, val string
+	}{
+		{"Hip", "Hip", "HipParams.MossyDel", "2.5"},
+		{"Pat", "Pat", "PatParams.ListSize", "20"},
+		{"Sim", "Sim", "Sim.MemThr", "0.3"},
+		{"Network", ".HippoCHL", "Prjn.Learn.Lrate", "0.15"},
+		{"Network", "#CA3ToCA3", "Prjn.Learn.Lrate", "0.05"},
+	}
+	for _, tt := range tests {
+		sh, ok := ps.Sheets[tt.sheet]
+		if !ok {
+			t.Errorf("no %s sheet", tt.sheet)
+			continue
+		}
+		found := false
+		for _, sl := range *sh {
+			if sl.Sel == tt.sel {
+				found = true
+				if v := sl.Params[tt.path]; v != tt.val {
+					t.Errorf("%s %s %s = %q, want %q", tt.sheet, tt.sel, tt.path, v, tt.val)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s sheet: no Sel %s", tt.sheet, tt.sel)
+		}
+	}
+}
+
+func TestSearchValidate(t *testing.T) {
+	ss := &Sim{Model: NewModel("hip_bench")}
+	ss.New()
+	ok := func() Search {
+		sr := Search{}
+		sr.Defaults()
+		sr.Params = []SearchParam{{Path: "Hip.MossyDel", Min: 1, Max: 4}}
+		return sr
+	}
+	tests := []struct {
+		name string
+		set  func(sr *Search)
+		ok   bool
+	}{
+		{"ok", func(sr *Search) {}, true},
+		{"network", func(sr *Search) { sr.Params[0].Path = "Network:Prjn:Prjn.Learn.Lrate" }, true},
+		{"nested", func(sr *Search) { sr.Params[0].Path = "Hip.CA3Size.X" }, true},
+		{"method", func(sr *Search) { sr.Method = "anneal" }, false},
+		{"no params", func(sr *Search) { sr.Params = nil }, false},
+		{"no runs", func(sr *Search) { sr.Runs = 0 }, false},
+		{"no candidates", func(sr *Search) { sr.N = 0 }, false},
+		{"grid no candidates", func(sr *Search) { sr.Method = "grid"; sr.N = 0 }, true},
+		{"no stat", func(sr *Search) { sr.Objective.Stat = "" }, false},
+		{"no base", func(sr *Search) { sr.Objective.Base = "" }, true},
+		{"root", func(sr *Search) { sr.Params[0].Path = "Ret.Decay" }, false},
+		{"field", func(sr *Search) { sr.Params[0].Path = "Hip.NoSuchField" }, false},
+		{"not a number", func(sr *Search) { sr.Params[0].Path = "Pat.DriftCtxt" }, false},
+		{"struct", func(sr *Search) { sr.Params[0].Path = "Hip.CA3Size" }, false},
+		{"network form", func(sr *Search) { sr.Params[0].Path = "Network:Prjn.Learn.Lrate" }, false},
+		{"range", func(sr *Search) { sr.Params[0].Min = 4 }, false},
+		{"grid range", func(sr *Search) { sr.Method = "grid"; sr.Params[0].Min = 4 }, true},
+	}
+	for _, tt := range tests {
+		sr := ok()
+		tt.set(&sr)
+		if err := sr.Validate(ss); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestObjectiveScores(t *testing.T) {
+	dt := &etable.Table{}
+	dt.SetFromSchema(etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"TestNm", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Mem", etensor.FLOAT64, nil, nil},
+	}, 0)
+	add := func(run int, params, test, stage string, mem float64) {
+		row := dt.Rows
+		dt.SetNumRows(row + 1)
+		dt.SetCellFloat("Run", row, float64(run))
+		dt.SetCellString("Params", row, params)
+		dt.SetCellString("TestNm", row, test)
+		dt.SetCellString("Stage", row, stage)
+		dt.SetCellFloat("Mem", row, mem)
+	}
+	add(0, "a", "AB", "rp", 1)
+	add(0, "a", "AB", "rp", 0)
+	add(0, "a", "AB", "rs", .25)
+	add(0, "a", "AC", "rp", 0) // other test
+	add(0, "b", "AB", "rp", 0) // other params
+	add(1, "a", "AB", "rp", 1)
+	add(1, "a", "AB", "rs", 0)
+	add(2, "a", "AB", "rp", 1) // no base
+	tests := []struct {
+		name string
+		ob   Objective
+		want map[int]float64
+	}{
+		{"difference", Objective{Stat: "Mem", Test: "AB", Stage: "rp", Base: "rs"}, map[int]float64{0: .25, 1: 1}},
+		{"no base", Objective{Stat: "Mem", Test: "AB", Stage: "rp"}, map[int]float64{0: .5, 1: 1, 2: 1}},
+		{"reversed", Objective{Stat: "Mem", Test: "AB", Stage: "rs", Base: "rp"}, map[int]float64{0: -.25, 1: -1}},
+		{"other test", Objective{Stat: "Mem", Test: "AC", Stage: "rp"}, map[int]float64{0: 0}},
+		{"no stage", Objective{Stat: "Mem", Test: "AB", Stage: "final"}, map[int]float64{}},
+	}
+	for _, tt := range tests {
+		if got := tt.ob.Scores(dt, "a"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Scores() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCandidateScore(t *testing.T) {
+	tests := []struct {
+		scores    []float64
+		score, se float64
+	}{
+		{nil, 0, 0},
+		{[]float64{.5}, .5, 0},
+		{[]float64{1, 3}, 2, 1},
+		{[]float64{1, 2, 3, 4}, 2.5, math.Sqrt(5.0 / 12)},
+	}
+	for _, tt := range tests {
+		cd := &Candidate{Scores: tt.scores}
+		cd.SetScore()
+		if math.Abs(cd.Score-tt.score) > 1e-9 || math.Abs(cd.SEM-tt.se) > 1e-9 {
+			t.Errorf("%v: Score, SEM = %g, %g, want %g, %g", tt.scores, cd.Score, cd.SEM, tt.score, tt.se)
+		}
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	pr := &Protocol{Name: "Test", Stages: []Stage{
+		{Do: "init"},
+		{Do: "study"},
+		{Do: "test", Set: "AB", Save: "final"},
+	}}
+	tests := []struct {
+		method string
+		n      int
+		runs   []int // runs of the candidates, best first
+	}{
+		{"grid", 0, []int{2, 2}},
+		{"halving", 4, []int{2, 2, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ss := testSim(t)
+			ss.Dist.Workers = 2
+			// everything is recalled with MemThr above 1, and nothing below 0
+			sr := &Search{Method: tt.method, N: tt.n, Runs: 2,
+				Params:    []SearchParam{{Path: "Sim.MemThr", Min: -1, Max: 2, Vals: []float64{-1, 2}}},
+				Objective: Objective{Stat: "Mem", Test: "AB", Stage: "final"}}
+			cds, err := ss.RunSearch(sr, pr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cds) != len(tt.runs) {
+				t.Fatalf("%d candidates, want %d", len(cds), len(tt.runs))
+			}
+			for i, cd := range cds {
+				if len(cd.Scores) != tt.runs[i] {
+					t.Errorf("candidate %d (%s): %d runs, want %d", i, cd.Name, len(cd.Scores), tt.runs[i])
+				}
+				want := 0.0
+				switch {
+				case cd.Vals[0] > 1:
+					want = 1
+				case cd.Vals[0] >= 0:
+					continue
+				}
+				for run, sc := range cd.Scores {
+					if sc != want {
+						t.Errorf("candidate %s (MemThr %g): run %d score %g, want %g", cd.Name, cd.Vals[0], run, sc, want)
+					}
+				}
+			}
+			if tt.method == "grid" && cds[0].Vals[0] != 2 {
+				t.Errorf("best candidate has MemThr %g, want 2", cds[0].Vals[0])
+			}
+			dt := sr.SearchTable(cds)
+			if dt.Rows != len(cds) || dt.CellFloat("Rank", 0) != 1 || dt.CellString("Name", 0) != cds[0].Name {
+				t.Errorf("search table does not list the candidates in rank order")
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Random streams
+
+// Seeds are the seeds of the separate random streams of a run, each derived
+// from the master seed (Sim.RndSeed) and the run number by DeriveSeed.  The
+// stimuli, the initial weights and the item order of a run thus do not depend
+// on each other, nor on how many random numbers anything else has drawn: e.g.,
+// conditions with different params are compared on the same stimuli and
+// initial weights in each run.
+type Seeds struct {
+	Pats  int64 `desc:"seed of the pattern stream: the stimuli (patgen) -- the filler lists of the delay steps have their own seeds (see FillerSeed)"`
+	Wts   int64 `desc:"seed of the weights stream: the random connectivity and initial weights"`
+	Order int64 `desc:"seed of the order stream: the order of the items in each epoch of training"`
+	Run   int   `desc:"run that the weights and order seeds were derived for"`
+}
+
+// SeedStreams are the names of the random streams, as passed to DeriveSeed
+var SeedStreams = []string{"Pats", "Wts", "Order"}
+
+// DeriveSeed returns the seed of the named stream of the given run, from the
+// master seed -- a hash of all three, kept to 53 bits so it is exact as a float
+func DeriveSeed(master int64, run int, stream string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%d/%s", master, run, stream)
+	return int64(h.Sum64() >> 11)
+}
+
+// NewSeeds returns the Seeds of the given run derived from the master seed
+func NewSeeds(master int64, run int) Seeds {
+	return Seeds{Pats: DeriveSeed(master, run, "Pats"), Wts: DeriveSeed(master, run, "Wts"),
+		Order: DeriveSeed(master, run, "Order"), Run: run}
+}
+
+// countSource is a math/rand source that counts the numbers drawn from it.
+// It is not a rand.Source64, so rand.Rand draws everything through Int63.
+type countSource struct {
+	src  rand.Source
+	seed int64
+	n    int64
+}
+
+func (cs *countSource) Int63() int64 {
+	cs.n++
+	return cs.src.Int63()
+}
+
+func (cs *countSource) Seed(seed int64) {
+	cs.src.Seed(seed)
+	cs.seed, cs.n = seed, 0
+}
+
+// Stream is a random stream whose state can be read without changing it: its
+// seed and the number of values drawn from it since (see State)
+type Stream struct {
+	*rand.Rand
+	src *countSource
+}
+
+// NewStream returns a new Stream started from the seed
+func NewStream(seed int64) *Stream {
+	cs := &countSource{src: rand.NewSource(seed), seed: seed}
+	return &Stream{Rand: rand.New(cs), src: cs}
+}
+
+// StreamState is the state of a Stream
+type StreamState struct {
+	Seed int64 `desc:"seed that the stream was started from"`
+	N    int64 `desc:"number of values drawn from it since"`
+}
+
+// State returns the state of the stream
+func (st *Stream) State() StreamState {
+	return StreamState{Seed: st.src.seed, N: st.src.n}
+}
+
+// NewStreamAt returns a new Stream in the given state: started from its seed,
+// with its N values drawn
+func NewStreamAt(ss StreamState) *Stream {
+	st := NewStream(ss.Seed)
+	for i := int64(0); i < ss.N; i++ {
+		st.src.Int63()
+	}
+	return st
+}
+
+// RndStreams are the random streams of a run, started from its Seeds
+type RndStreams struct {
+	Pats  *Stream `desc:"pattern stream"`
+	Wts   *Stream `desc:"weights stream"`
+	Order *Stream `desc:"order stream"`
+}
+
+// RndState is the state of each of the RndStreams
+type RndState struct {
+	Pats, Wts, Order StreamState
+}
+
+// Start (re)starts the streams from the given seeds
+func (rs *RndStreams) Start(sd Seeds) {
+	rs.Pats = NewStream(sd.Pats)
+	rs.Wts = NewStream(sd.Wts)
+	rs.Order = NewStream(sd.Order)
+}
+
+// State returns the state of the streams, which SetState restores
+func (rs *RndStreams) State() RndState {
+	return RndState{Pats: rs.Pats.State(), Wts: rs.Wts.State(), Order: rs.Order.State()}
+}
+
+// SetState sets the streams to the given state
+func (rs *RndStreams) SetState(st RndState) {
+	rs.Pats = NewStreamAt(st.Pats)
+	rs.Wts = NewStreamAt(st.Wts)
+	rs.Order = NewStreamAt(st.Order)
+}
+
+// InitSeeds derives the Seeds of the given run from the master seed RndSeed
+// and starts their streams, at Init
+func (ss *Sim) InitSeeds(run int) {
+	ss.Seeds = NewSeeds(ss.RndSeed, run)
+	ss.Rnd.Start(ss.Seeds)
+}
+
+// RunSeeds derives the weights and order seeds of the given run, if the
+// streams are not already those of it, and restarts their streams -- for
+// NewRun.  The patterns are only made at Init, so the pattern stream goes on.
+func (ss *Sim) RunSeeds(run int) {
+	if ss.Rnd.Pats != nil && ss.Seeds.Run == run {
+		return
+	}
+	sd := NewSeeds(ss.RndSeed, run)
+	ss.Seeds.Wts, ss.Seeds.Order, ss.Seeds.Run = sd.Wts, sd.Order, run
+	ss.Rnd.Wts = NewStream(sd.Wts)
+	ss.Rnd.Order = NewStream(sd.Order)
+	if ss.Rnd.Pats == nil {
+		ss.Rnd.Pats = NewStream(ss.Seeds.Pats)
+	}
+}
+
+// randMu guards the global math/rand source, which all the Sims of a Runner share
+var randMu sync.Mutex
+
+// randGuardSeed is the seed that WithRand leaves the global source at, and
+// randGuardDraw the first number drawn from it: the next WithRand checks that
+// it still draws that, i.e., that nothing has drawn from the source since
+const randGuardSeed = 1
+
+var randGuardDraw = rand.New(rand.NewSource(randGuardSeed)).Int63()
+
+// randGuarded is whether a WithRand has left the global source at randGuardSeed
+var randGuarded bool
+
+// WithRand calls fun, which draws from the global math/rand source (as patgen
+// and leabra do), with that source seeded from the next seed of the given
+// stream (if non-nil), and locked against all other Sims -- so that what fun
+// draws only depends on that stream, however many Sims are running at once.
+//
+// Nothing else may draw from the global source: WithRand panics if anything
+// has since the last WithRand.  It also panics if rand.Seed does not seed the
+// global source, which it only does while go.mod has a go directive below
+// 1.24 (it is go 1.15) -- from go 1.24 on, rand.Seed is a no-op unless
+// GODEBUG=randseednop=0, and the runs would not be reproducible.
+func (ss *Sim) WithRand(rs *Stream, fun func()) {
+	randMu.Lock()
+	defer func() {
+		rand.Seed(randGuardSeed)
+		randGuarded = true
+		randMu.Unlock()
+	}()
+	if randGuarded && rand.Int63() != randGuardDraw {
+		panic("hipbench: the global math/rand source was drawn from outside WithRand, or rand.Seed did not seed it")
+	}
+	if rs != nil {
+		rand.Seed(rs.Int63())
+	}
+	fun()
+}
+
+// NewOrder sets a new order of the items of TrainEnv from the order stream,
+// unless they are studied in sequence.  TrainEnv itself is always sequential,
+// so that it does not permute from math/rand.
+func (ss *Sim) NewOrder() {
+	ev := &ss.TrainEnv
+	if ss.Rnd.Order == nil || ss.Pat.Sequential() {
+		return
+	}
+	ev.Order = ss.Rnd.Order.Perm(ev.Table.Len())
+}
+
+// StepTrain steps TrainEnv to the next trial, with a new order of the items
+// from the order stream at the start of each epoch -- all training steps
+// TrainEnv with this rather than its Step, which permutes from math/rand
+func (ss *Sim) StepTrain() {
+	ev := &ss.TrainEnv
+	ev.Step()
+	if _, _, chg := ev.Counter(env.Epoch); chg {
+		ss.NewOrder()
+		ev.SetTrialName()
+		ev.SetGroupName()
+	}
+}
+
+// LogSeeds records the master seed and the seeds of the streams of the run in
+// the RunLog row.  The seeds are int64 columns, set directly as they can be
+// too big to be exact as floats.
+func (ss *Sim) LogSeeds(dt *etable.Table, row int) {
+	vals := []int64{ss.RndSeed, ss.Seeds.Pats, ss.Seeds.Wts, ss.Seeds.Order}
+	for i, nm := range SeedsSchemaNames() {
+		if col, ok := dt.ColByName(nm).(*etensor.Int64); ok {
+			col.Values[row] = vals[i]
+		}
+	}
+}
+
+// SeedsSchemaNames returns the names of the RunLog columns of LogSeeds
+func SeedsSchemaNames() []string {
+	nms := []string{"Seed"}
+	for _, st := range SeedStreams {
+		nms = append(nms, st+"Seed")
+	}
+	return nms
+}
+
+// SeedsSchema returns the RunLog columns of LogSeeds
+func SeedsSchema() etable.Schema {
+	var sch etable.Schema
+	for _, nm := range SeedsSchemaNames() {
+		sch = append(sch, etable.Column{nm, etensor.INT64, nil, nil})
+	}
+	return sch
+}
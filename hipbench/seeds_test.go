@@ -0,0 +1,171 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/emer/etable/etable"
+)
+
+// runPats returns the stimuli of the run so far: its TrainAB and TrainFiller
+// patterns
+func runPats(ss *Sim) []float64 {
+	var pats []float64
+	for _, dt := range []*etable.Table{ss.TrainAB, ss.TrainFiller} {
+		for _, col := range []string{"Input", "Output"} {
+			var vals []float64
+			dt.ColByName(col).Floats(&vals)
+			pats = append(pats, vals...)
+		}
+	}
+	return pats
+}
+
+// runWts does nruns runs of the protocol with nworkers, and returns the final
+// weights and the patterns (see runPats) of each run
+func runWts(t *testing.T, pr *Protocol, nruns, nworkers int) ([][]byte, [][]float64) {
+	t.Helper()
+	ss := testSim(t)
+	ss.Pat.Permute = true
+	wts := make([][]byte, nruns)
+	pats := make([][]float64, nruns)
+	var mu sync.Mutex
+	rn := NewRunner(ss, RunJobs(nruns), nworkers)
+	rn.Run = func(rs *Sim) error {
+		if err := rs.RunProtocol(pr); err != nil {
+			return err
+		}
+		var b bytes.Buffer
+		if err := rs.WriteCkpt(&b); err != nil {
+			return err
+		}
+		mu.Lock()
+		wts[rs.TrainEnv.Run.Cur] = b.Bytes()
+		pats[rs.TrainEnv.Run.Cur] = runPats(rs)
+		mu.Unlock()
+		return nil
+	}
+	if err := rn.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	return wts, pats
+}
+
+// delayProtocol studies, has a retention interval step with a filler list,
+// and tests
+var delayProtocol = &Protocol{Name: "Test", Stages: []Stage{
+	{Do: "init"},
+	{Do: "study"},
+	{Do: "delay", Mode: "both"},
+	{Do: "test"},
+}}
+
+func TestWorkersDeterministic(t *testing.T) {
+	const nruns = 3
+	one, onePats := runWts(t, delayProtocol, nruns, 1)
+	many, manyPats := runWts(t, delayProtocol, nruns, nruns)
+	for run := range one {
+		if len(one[run]) == 0 || len(onePats[run]) == 0 {
+			t.Fatalf("run %d: no weights or patterns", run)
+		}
+		if !bytes.Equal(one[run], many[run]) {
+			t.Errorf("run %d: weights differ between 1 and %d workers", run, nruns)
+		}
+		if !reflect.DeepEqual(onePats[run], manyPats[run]) {
+			t.Errorf("run %d: patterns differ between 1 and %d workers", run, nruns)
+		}
+	}
+	if bytes.Equal(one[0], one[1]) || reflect.DeepEqual(onePats[0], onePats[1]) {
+		t.Errorf("runs 0 and 1 have the same weights or patterns")
+	}
+}
+
+// TestConcurrentSims runs two Sims of the same run at once, each on its own,
+// and checks that they all have the same patterns and weights
+func TestConcurrentSims(t *testing.T) {
+	sims := make([]*Sim, 3)
+	for i := range sims {
+		sims[i] = testSim(t)
+		sims[i].Init()
+	}
+	run := func(ss *Sim) {
+		if err := ss.RunProtocol(delayProtocol); err != nil {
+			t.Error(err)
+		}
+	}
+	run(sims[0])
+	var wg sync.WaitGroup
+	for _, ss := range sims[1:] {
+		wg.Add(1)
+		go func(ss *Sim) {
+			defer wg.Done()
+			run(ss)
+		}(ss)
+	}
+	wg.Wait()
+	wts0 := netWts(sims[0])
+	pats0 := runPats(sims[0])
+	for i, ss := range sims[1:] {
+		if d := wtsDiff(netWts(ss), wts0); d != 0 {
+			t.Errorf("concurrent sim %d: weights differ from the sim run on its own by %g", i, d)
+		}
+		if !reflect.DeepEqual(runPats(ss), pats0) {
+			t.Errorf("concurrent sim %d: patterns differ from the sim run on its own", i)
+		}
+	}
+}
+
+func TestWithRandGuard(t *testing.T) {
+	ss := testSim(t)
+	ss.WithRand(nil, func() {})
+	ss.WithRand(nil, func() { rand.Int63() }) // drawing inside is fine
+	rand.Int63()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithRand after a draw from the global source outside it: no panic")
+		}
+	}()
+	ss.WithRand(nil, func() {})
+}
+
+func TestDeriveSeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		master int64
+		run    int
+		stream string
+	}{
+		{"base", 1, 0, "Pats"},
+		{"master", 2, 0, "Pats"},
+		{"run", 1, 1, "Pats"},
+		{"stream", 1, 0, "Wts"},
+		{"order", 1, 0, "Order"},
+		{"negative master", -1, 0, "Pats"},
+		{"big run", 1, 1000, "Pats"},
+	}
+	seen := make(map[int64]string)
+	for _, tt := range tests {
+		sd := DeriveSeed(tt.master, tt.run, tt.stream)
+		if sd != DeriveSeed(tt.master, tt.run, tt.stream) {
+			t.Errorf("%s: DeriveSeed is not deterministic", tt.name)
+		}
+		if sd < 0 || sd >= 1<<53 {
+			t.Errorf("%s: seed %d is not in [0, 2^53)", tt.name, sd)
+		}
+		if nm, ok := seen[sd]; ok {
+			t.Errorf("%s: same seed as %s", tt.name, nm)
+		}
+		seen[sd] = tt.name
+	}
+	sd := NewSeeds(1, 3)
+	if sd.Pats != DeriveSeed(1, 3, "Pats") || sd.Wts != DeriveSeed(1, 3, "Wts") || sd.Order != DeriveSeed(1, 3, "Order") || sd.Run != 3 {
+		t.Errorf("NewSeeds(1, 3) = %+v, not the derived seeds", sd)
+	}
+}
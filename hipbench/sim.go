@@ -0,0 +1,517 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hipbench is the hippocampus + cortex model shared by the hip,
+// recognition and hip_ae benches, which each declare a Model with their
+// differences and pass it to Main.
+package hipbench
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/env"
+	"github.com/emer/emergent/params"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/emergent/relpos"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/hip"
+	"github.com/emer/leabra/leabra"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Sim encapsulates the entire simulation model, and we define all the
+// functionality as methods on this struct.  This structure keeps all relevant
+// state information organized and available without having to pass everything around
+// as arguments to methods, and provides the core GUI interface (note the view tags
+// for the fields which provide hints to how things should be displayed).
+type Sim struct {
+	Model        *Model                      `view:"-" desc:"what this model does differently from the shared defaults"`
+	Net          *leabra.Network             `view:"no-inline"`
+	Hip          HipParams                   `desc:"hippocampus sizing parameters"`
+	Pat          PatParams                   `desc:"parameters for the input patterns"`
+	Ret          RetParams                   `desc:"parameters for the retention interval (delay stages)"`
+	RP           RPParams                    `desc:"parameters for retrieval practice"`
+	Acts         ActParams                   `desc:"which activity patterns are recorded in ActLog"`
+	Cyc          CycParams                   `desc:"which layer variables are recorded in CycLog on each cycle, and in which stages"`
+	WtChg        WtChgParams                 `desc:"how the weight changes of each stage are recorded in WtChgLog"`
+	PoolVocab    map[string]*etensor.Float32 `view:"no-inline" desc:"pool patterns vocabulary"`
+	TrainAB      *etable.Table               `view:"no-inline" desc:"AB training patterns to use"`
+	TrainNoise   *etable.Table               `view:"no-inline" desc:"AB training patterns to use"`
+	TrainRP      *etable.Table               `view:"no-inline" desc:"AC training patterns to use"`
+	TrainAC      *etable.Table               `view:"no-inline" desc:"AC training patterns to use, for AB-AC interference"`
+	TestAB       *etable.Table               `view:"no-inline" desc:"AB testing patterns to use"`
+	TestLong     *etable.Table               `view:"no-inline" desc:"AB testing patterns to use"`
+	TestAC       *etable.Table               `view:"no-inline" desc:"AC testing patterns to use"`
+	TestLure     *etable.Table               `view:"no-inline" desc:"Lure testing patterns to use"`
+	TrainAll     *etable.Table               `view:"no-inline" desc:"all training patterns -- for pretrain"`
+	TrainFiller  *etable.Table               `view:"no-inline" desc:"filler list studied in the current retention interval step -- new for each step"`
+	MorePats     map[string]*etable.Table    `view:"no-inline" desc:"any other pattern tables of the Model.Tests, by name"`
+	TrnTrlLog    *etable.Table               `view:"no-inline" desc:"training trial-level log data"`
+	TrnEpcLog    *etable.Table               `view:"no-inline" desc:"training epoch-level log data"`
+	TstEpcLog    *etable.Table               `view:"no-inline" desc:"testing epoch-level log data"`
+	TstTrlLog    *etable.Table               `view:"no-inline" desc:"testing trial-level log data"`
+	RPTrlLog     *etable.Table               `view:"no-inline" desc:"retrieval practice trial-level log data: one row per practice attempt of the run"`
+	ActLog       *etable.Table               `view:"no-inline" desc:"activity patterns of the Acts layers on each item of each study, rp, restudy and test stage of the run, if Acts.On"`
+	CycLog       *etable.Table               `view:"no-inline" desc:"the Cyc layer variables on each cycle of the current trial, if it is in one of the Cyc stages"`
+	RSALog       *etable.Table               `view:"no-inline" desc:"representational similarity of the items in each stage and layer of the run, if Acts.RSA: its similarity matrices, stability and differentiation"`
+	WtChgLog     *etable.Table               `view:"no-inline" desc:"weight changes of each learning projection in each stage of the run that changes weights, if WtChg.On"`
+	TstCycLog    *etable.Table               `view:"no-inline" desc:"testing cycle-level log data"`
+	RunLog       *etable.Table               `view:"no-inline" desc:"summary log of each run"`
+	RunStats     *etable.Table               `view:"no-inline" desc:"aggregate stats on all runs"`
+	TstStats     *etable.Table               `view:"no-inline" desc:"testing stats"`
+	Params       params.Sets                 `view:"no-inline" desc:"full collection of param sets"`
+	ParamSet     string                      `desc:"which set of *additional* parameters to use -- always applies Base and optionaly this next if set"`
+	ExtraParams  []string                    `desc:"further ParamSets applied after ParamSet, in order -- e.g., the factors of a sweep"`
+	Protocol     string                      `desc:"experiment protocol run by the Run Protocol action: name of a built-in protocol (see Protocols) or a protocol .json file"`
+	Tag          string                      `desc:"extra tag string to add to any file names output from sim (e.g., weights files, log files, params)"`
+	MaxRuns      int                         `desc:"maximum number of model runs to perform"`
+	StartRun     int                         `desc:"number of the first run -- runs go from StartRun to StartRun+MaxRuns-1 (see Runner)"`
+	MaxEpcs      int                         `desc:"maximum number of epochs to run per model run"`
+	PreTrainEpcs int                         `desc:"number of epochs to run for pretraining"`
+	AETrainEpcs  int                         `desc:"number of epochs to run for pretraining"`
+	NZeroStop    int                         `desc:"if a positive number, training will stop after this many epochs with zero mem errors"`
+	TrainEnv     env.FixedTable              `desc:"Training environment -- contains everything about iterating over input / output patterns over training"`
+	TestEnv      env.FixedTable              `desc:"Testing environment -- manages iterating over testing"`
+	Time         leabra.Time                 `desc:"leabra timing parameters and state"`
+	Hiponly      bool                        `desc:"whether the final recall is based on hip only or both hip and cortex"`
+	Coronly      bool                        `desc:"whether the final recall is based on cortex only or both hip and cortex"`
+	Record       bool                        `desc:"whether to record the ECout activity of each test trial into the Autoin column of TrainNoise, for auto-encoder training (for models that use it, e.g., hip_ae)"`
+	ViewOn       bool                        `desc:"whether to update the network view while running"`
+	TrainUpdt    leabra.TimeScales           `desc:"at what time scale to update the display during training?  Anything longer than Epoch updates at Epoch in this model"`
+	TestUpdt     leabra.TimeScales           `desc:"at what time scale to update the display during testing?  Anything longer than Epoch updates at Epoch in this model"`
+	TestInterval int                         `desc:"how often to run through all the test patterns, in terms of training epochs -- can use 0 or -1 for no testing"`
+	MemLay       string                      `desc:"layer scored for Mem: ECout or Output -- empty = the Model.MemScore layer"`
+	MemThr       float64                     `desc:"threshold to use for memory test -- if error proportion is below this number, it is scored as a correct trial"`
+	FamThr       float64                     `desc:"criterion on Famil for calling an item old in the old / new recognition test (see OldNew) -- the ROC covers all criteria"`
+
+	// statistics: note use float64 as that is best for etable.Table
+	TestNm            string    `inactive:"+" desc:"what set of patterns are we currently testing"`
+	Delay             int       `inactive:"+" desc:"number of retention interval steps since the last study or practice stage"`
+	Mem               float64   `inactive:"+" desc:"whether current trial's scored pools (Model.MemScore) met memory criterion"`
+	TrgOnWasOffAll    float64   `inactive:"+" desc:"current trial's proportion of bits where target = on but the scored layer was off ( < 0.5), for all bits"`
+	TrgOnWasOffCmp    float64   `inactive:"+" desc:"current trial's proportion of bits where target = on but the scored layer was off ( < 0.5), for only the bits of completion pools that were empty in the input"`
+	TrgOffWasOn       float64   `inactive:"+" desc:"current trial's proportion of bits where target = off but the scored layer was on ( > 0.5)"`
+	PoolMem           []float64 `inactive:"+" desc:"Mem for each pool of the scored layer -- NaN for pools not scored"`
+	PoolTrgOnWasOff   []float64 `inactive:"+" desc:"TrgOnWasOff for each pool of the scored layer -- NaN for pools not scored"`
+	PoolTrgOffWasOn   []float64 `inactive:"+" desc:"TrgOffWasOn for each pool of the scored layer -- NaN for pools not scored"`
+	MemFam            float64   `inactive:"+" desc:"whether current trial's familiarity units (Model.FamScore) met memory criterion"`
+	TrgOnWasOffAllFam float64   `inactive:"+" desc:"familiarity version of TrgOnWasOffAll"`
+	TrgOnWasOffCmpFam float64   `inactive:"+" desc:"familiarity version of TrgOnWasOffCmp"`
+	TrgOffWasOnFam    float64   `inactive:"+" desc:"familiarity version of TrgOffWasOn"`
+	Famil             float64   `inactive:"+" desc:"current trial's continuous familiarity: cosine of ActM and Targ over the familiarity units (Model.FamScore)"`
+	TrlSSE            float64   `inactive:"+" desc:"current trial's sum squared error"`
+	TrlAvgSSE         float64   `inactive:"+" desc:"current trial's average sum squared error"`
+	TrlCosDiff        float64   `inactive:"+" desc:"current trial's cosine difference"`
+	CA312             float32   `inactive:"+" desc:"correlation between ca3 Q1 and Q2"`
+	CA323             float32   `inactive:"+" desc:"correlation between ca3 Q2 and Q3"`
+	CA334             float32   `inactive:"+" desc:"correlation between ca3 Q3 and Q4"`
+	EpcSSE            float64   `inactive:"+" desc:"last epoch's total sum squared error"`
+	EpcAvgSSE         float64   `inactive:"+" desc:"last epoch's average sum squared error (average over trials, and over units within layer)"`
+	EpcPctErr         float64   `inactive:"+" desc:"last epoch's percent of trials that had SSE > 0 (subject to .5 unit-wise tolerance)"`
+	EpcPctCor         float64   `inactive:"+" desc:"last epoch's percent of trials that had SSE == 0 (subject to .5 unit-wise tolerance)"`
+	EpcCosDiff        float64   `inactive:"+" desc:"last epoch's average cosine difference for output layer (a normalized error measure, maximum of 1 when the minus phase exactly matches the plus)"`
+	EpcPerTrlMSec     float64   `inactive:"+" desc:"how long did the epoch take per trial in wall-clock milliseconds"`
+	FirstZero         int       `inactive:"+" desc:"epoch at when Mem err first went to zero"`
+	NZero             int       `inactive:"+" desc:"number of epochs in a row with zero Mem err"`
+
+	// internal state - view:"-"
+	SumSSE       float64                     `view:"-" inactive:"+" desc:"sum to increment as we go through epoch"`
+	SumAvgSSE    float64                     `view:"-" inactive:"+" desc:"sum to increment as we go through epoch"`
+	SumCosDiff   float64                     `view:"-" inactive:"+" desc:"sum to increment as we go through epoch"`
+	CntErr       int                         `view:"-" inactive:"+" desc:"sum of errs to increment as we go through epoch"`
+	View         View                        `view:"-" desc:"the gui, if running with one -- nil when headless"`
+	NoThreads    bool                        `view:"-" desc:"if true, the network runs in a single goroutine instead of spreading the hippocampal layers over 4 -- Runner sets this, as it runs many networks in parallel"`
+	Dist         DistParams                  `view:"-" desc:"how jobs are distributed over goroutines and processes"`
+	NoSave       bool                        `view:"-" desc:"if true, protocol test stages do not save their own files -- Runner sets this and saves the merged logs instead"`
+	Stage        string                      `view:"-" desc:"name of the protocol test stage being run (its Save name), recorded in TstTrlLog"`
+	CurStage     *Stage                      `view:"-" desc:"protocol stage being run -- nil for none"`
+	CycFile      *os.File                    `view:"-" desc:"file the CycLog rows of the run are streamed to, if Cyc.Save"`
+	Ckpts        map[string]bool             `view:"-" desc:"checkpoints saved in the current run, by name -- only these can be loaded (see LoadCkpt)"`
+	StageWts     map[string][]float32        `view:"-" desc:"weights of the WtChg projections at the start of the stage being run, by projection"`
+	PracItems    map[int]bool                `view:"-" desc:"items run in retrieval practice so far in the run, by their row in TrainRP and TrainAB (see PracItem)"`
+	PracSyns     map[string][]bool           `view:"-" desc:"synapses of the practiced items so far in the run, by projection (see MarkPracSyns)"`
+	ActStage     string                      `view:"-" desc:"name of the protocol stage being run whose activity is recorded in ActLog (see ActStageName) -- empty for none"`
+	InProtocol   bool                        `view:"-" desc:"true while a protocol is being run: its study stages end without ending the run, which ends with the protocol"`
+	Conds        *Conds                      `view:"-" desc:"testing effect conditions of the protocol run, whose final tests the RunLog records (see SetConds) -- those of Short by default"`
+	FinalStage   string                      `view:"-" desc:"test stage (Save name) whose stats the RunLog row records -- the final full-route test of the protocol (see Protocol.FinalTest), or empty for the last test"`
+	TstTrlAll    *etable.Table               `view:"-" desc:"if non-nil, every TstTrlLog row is also added here -- Runner uses this to collect all test trials of a run"`
+	TrnEpcFile   *os.File                    `view:"-" desc:"log file"`
+	TrnEpcHdrs   bool                        `view:"-" desc:"headers written"`
+	TstEpcFile   *os.File                    `view:"-" desc:"log file"`
+	TstTrialFile *os.File                    `view:"-" desc:"log file"`
+	TstEpcHdrs   bool                        `view:"-" desc:"headers written"`
+	RunFile      *os.File                    `view:"-" desc:"log file"`
+	RPTrlFile    *os.File                    `view:"-" desc:"log file"`
+	ValsTsrs     map[string]*etensor.Float32 `view:"-" desc:"for holding layer values"`
+	TmpVals      []float32                   `view:"-" desc:"temp slice for holding values -- prevent mem allocs"`
+	LayStatNms   []string                    `view:"-" desc:"names of layers to collect more detailed stats on (avg act, etc)"`
+	TstNms       []string                    `view:"-" desc:"names of test tables"`
+	TstStatNms   []string                    `view:"-" desc:"names of test stats"`
+	TstPoolNms   []string                    `view:"-" desc:"names of per-pool test stats, which have a value for each pool"`
+	SaveWts      bool                        `view:"-" desc:"for command-line run only, auto-save final weights after each run"`
+	PreTrainWts  []byte                      `view:"-" desc:"pretrained weights file"`
+	OutDir       string                      `desc:"directory that all the files of a command-line invocation are written to (see OutFile) -- current directory if empty"`
+	CkptDir      string                      `desc:"directory where the checkpoints of the weights after each protocol stage are saved -- OutDir if empty"`
+	SnapFile     string                      `desc:"if set, a Snapshot of the whole Sim is saved after each protocol stage, to this file name with the run number added (see SnapFileName), from which the protocol can be resumed"`
+	NoGui        bool                        `view:"-" desc:"if true, runing in no GUI mode"`
+	LogSetParams bool                        `view:"-" desc:"if true, print message for all params that are set"`
+	IsRunning    bool                        `view:"-" desc:"true if sim is running"`
+	StopNow      bool                        `view:"-" desc:"flag to stop running"`
+	NeedsNewRun  bool                        `view:"-" desc:"flag to initialize NewRun if last one finished"`
+	RndSeed      int64                       `view:"-" desc:"the master random seed, from which the Seeds of each run are derived"`
+	Seeds        Seeds                       `view:"-" desc:"seeds of the random streams of the current run"`
+	Rnd          RndStreams                  `view:"-" desc:"random streams of the current run"`
+	LastEpcTime  time.Time                   `view:"-" desc:"timer for last epoch"`
+}
+
+// SimProps register Save methods so they can be used from the gui
+var SimProps = ki.Props{
+	"CallMethods": ki.PropSlice{
+		{"SaveWeights", ki.Props{
+			"desc": "save network weights to file",
+			"icon": "file-save",
+			"Args": ki.PropSlice{
+				{"File Name", ki.Props{
+					"ext": ".wts,.wts.gz",
+				}},
+			},
+		}},
+		{"SetEnv", ki.Props{
+			"desc": "select which set of patterns to train on: AB or AC",
+			"icon": "gear",
+			"Args": ki.PropSlice{
+				{"Train on AC", ki.Props{}},
+			},
+		}},
+	},
+}
+
+// this registers this Sim Type and gives it properties that e.g.,
+// prompt for filename for save methods.
+var KiT_Sim = kit.Types.AddType(&Sim{}, SimProps)
+
+// TheSim is the overall state for this simulation
+var TheSim Sim
+
+// New creates new blank elements and initializes defaults.
+// Model should be set first -- NewModel defaults are used otherwise.
+func (ss *Sim) New() {
+	if ss.Model == nil {
+		ss.Model = NewModel("hip_bench")
+	}
+	ss.Net = &leabra.Network{}
+	ss.PoolVocab = map[string]*etensor.Float32{}
+	ss.TrainAB = &etable.Table{}
+	ss.TrainNoise = &etable.Table{}
+	ss.TrainRP = &etable.Table{}
+	ss.TestAB = &etable.Table{}
+	ss.TestLong = &etable.Table{}
+	ss.TestAC = &etable.Table{}
+	ss.TrainAC = &etable.Table{}
+	ss.TrainFiller = &etable.Table{}
+	ss.MorePats = make(map[string]*etable.Table)
+	ss.TestLure = &etable.Table{}
+	ss.TrainAll = &etable.Table{}
+	ss.TrnTrlLog = &etable.Table{}
+	ss.TrnEpcLog = &etable.Table{}
+	ss.TstEpcLog = &etable.Table{}
+	ss.TstTrlLog = &etable.Table{}
+	ss.RPTrlLog = &etable.Table{}
+	ss.ActLog = &etable.Table{}
+	ss.RSALog = &etable.Table{}
+	ss.WtChgLog = &etable.Table{}
+	ss.CycLog = &etable.Table{}
+	ss.TstCycLog = &etable.Table{}
+	ss.RunLog = &etable.Table{}
+	ss.Conds = ProtocolConds(Protocols["Short"])
+	ss.RunStats = &etable.Table{}
+	ss.Params = ss.Model.Params
+	ss.RndSeed = 2
+	ss.ViewOn = true
+	ss.TrainUpdt = leabra.AlphaCycle
+	ss.TestUpdt = leabra.AlphaCycle
+	ss.TestInterval = -1
+	ss.LogSetParams = false
+	ss.Protocol = "Short"
+	ss.MemThr = 0.34
+	ss.FamThr = 0.5
+	ss.LayStatNms = []string{"ECin", "DG", "CA3", "CA1"}
+	ss.TstNms = nil
+	for _, ts := range ss.Model.Tests {
+		ss.TstNms = append(ss.TstNms, ts.Name)
+	}
+	ss.TstStatNms = []string{"Mem", "TrgOnWasOff", "TrgOffWasOn"}
+	ss.TstPoolNms = []string{"PoolMem", "PoolTrgOnWasOff", "PoolTrgOffWasOn"}
+
+	ss.Defaults()
+}
+
+func (ss *Sim) Defaults() {
+	ss.Hip.Defaults()
+	ss.Pat.Defaults()
+	ss.Ret.Defaults()
+	ss.RP.Defaults()
+	ss.Acts.Defaults()
+	ss.Cyc.Stages = []string{"study", "rp", "restudy", "test"}
+	ss.WtChg.Defaults()
+	ss.Time.CycPerQtr = 25 // note: key param - 25 seems like it is actually fine?
+	ss.Update()
+}
+
+func (ss *Sim) Update() {
+	ss.Hip.Update()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// 		Configs
+
+// Config configures all the elements using the standard functions
+func (ss *Sim) Config() {
+	ss.WithRand(ss.Rnd.Pats, ss.ConfigPats)
+	ss.ConfigEnv()
+	ss.WithRand(ss.Rnd.Wts, func() { ss.ConfigNet(ss.Net) })
+	ss.ConfigTrnTrlLog(ss.TrnTrlLog)
+	ss.ConfigTrnEpcLog(ss.TrnEpcLog)
+	ss.ConfigTstEpcLog(ss.TstEpcLog)
+	ss.ConfigTstTrlLog(ss.TstTrlLog)
+	ss.ConfigRPTrlLog(ss.RPTrlLog)
+	ss.ConfigActLog(ss.ActLog)
+	ss.ConfigRSALog(ss.RSALog)
+	ss.ConfigCycLog(ss.CycLog)
+	ss.ConfigWtChgLog(ss.WtChgLog)
+	ss.ConfigTstCycLog(ss.TstCycLog)
+	ss.ConfigRunLog(ss.RunLog)
+}
+
+func (ss *Sim) ConfigEnv() {
+	if ss.MaxRuns == 0 { // allow user override
+		ss.MaxRuns = 1
+	}
+	if ss.MaxEpcs == 0 { // allow user override
+		ss.MaxEpcs = 1
+		ss.NZeroStop = 1
+		ss.PreTrainEpcs = ss.Model.PreEpcs
+		ss.AETrainEpcs = ss.Model.AEEpcs
+	}
+
+	ss.TrainEnv.Nm = "TrainEnv"
+	ss.TrainEnv.Dsc = "training params and state"
+	ss.TrainEnv.Table = etable.NewIdxView(ss.TrainAB)
+	ss.TrainEnv.Validate()
+	ss.TrainEnv.Sequential = true                  // the order is set by NewOrder
+	ss.TrainEnv.Run.Max = ss.StartRun + ss.MaxRuns // note: we are not setting epoch max -- do that manually
+
+	ss.TestEnv.Nm = "TestEnv"
+	ss.TestEnv.Dsc = "testing params and state"
+	ss.TestEnv.Table = etable.NewIdxView(ss.TestAB)
+	ss.TestEnv.Sequential = true
+	ss.TestEnv.Validate()
+
+	ss.TrainEnv.Init(ss.StartRun)
+	ss.TestEnv.Init(ss.StartRun)
+}
+
+// SetEnv select which set of patterns to train on: AB or AC
+
+func (ss *Sim) SetEnv(trainRP bool) {
+	if trainRP {
+		ss.SetTrainPats(ss.TrainRP, ss.StartRun)
+	} else {
+		ss.SetTrainPats(ss.TrainAB, ss.StartRun)
+	}
+}
+
+// SetTrainPats sets the training env to the given patterns, in the order set
+// by Pat (see NewOrder), and initializes it for the given run
+func (ss *Sim) SetTrainPats(dt *etable.Table, run int) {
+	ss.TrainEnv.Table = etable.NewIdxView(dt)
+	ss.TrainEnv.Sequential = true
+	ss.TrainEnv.Init(run)
+	ss.NewOrder()
+}
+
+func (ss *Sim) ConfigNet(net *leabra.Network) {
+	net.InitName(net, "Hip_bench")
+	hp := &ss.Hip
+	m := ss.Model
+	in := net.AddLayer4D("Input", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Input)
+	ecin := net.AddLayer4D("ECin", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Hidden)
+	ecout := net.AddLayer4D("ECout", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, emer.Target)
+	ca1 := net.AddLayer4D("CA1", hp.ECSize.Y, hp.ECSize.X, hp.CA1Pool.Y, hp.CA1Pool.X, emer.Hidden)
+	dg := net.AddLayer2D("DG", hp.DGSize.Y, hp.DGSize.X, emer.Hidden)
+	ca3 := net.AddLayer2D("CA3", hp.CA3Size.Y, hp.CA3Size.X, emer.Hidden)
+	out := net.AddLayer4D("Output", hp.ECSize.Y, hp.ECSize.X, hp.ECPool.Y, hp.ECPool.X, m.OutType)
+	cortex := net.AddLayer2D("Cortex", 20, 20, emer.Hidden)
+
+	ecin.SetClass("EC")
+	ecout.SetClass("EC")
+
+	cortex.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "Input", YAlign: relpos.Front, Space: 4})
+	out.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "Cortex", YAlign: relpos.Front, Space: 4})
+	ecin.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "Input", YAlign: relpos.Front, XAlign: relpos.Right, Space: 0})
+	ecout.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "ECin", YAlign: relpos.Front, Space: 2})
+	dg.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "ECin", YAlign: relpos.Front, XAlign: relpos.Left, Space: 2})
+	ca3.SetRelPos(relpos.Rel{Rel: relpos.Above, Other: "DG", YAlign: relpos.Front, XAlign: relpos.Left, Space: 0})
+	ca1.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "CA3", YAlign: relpos.Front, Space: 2})
+
+	onetoone := prjn.NewOneToOne()
+	pool1to1 := prjn.NewPoolOneToOne()
+
+	full := prjn.NewFull()
+
+	net.ConnectLayers(in, ecin, onetoone, emer.Forward)
+	net.ConnectLayers(ecout, ecin, onetoone, emer.Back)
+	net.ConnectLayers(ecout, out, onetoone, emer.Forward)
+
+	// EC <-> CA1 encoder pathways
+
+	pj := net.ConnectLayersPrjn(ecin, ca1, pool1to1, emer.Forward, &hip.EcCa1Prjn{})
+	pj.SetClass("EcCa1Prjn")
+	pj = net.ConnectLayersPrjn(ca1, ecout, pool1to1, emer.Forward, &hip.EcCa1Prjn{})
+	pj.SetClass("EcCa1Prjn")
+	pj = net.ConnectLayersPrjn(ecout, ca1, pool1to1, emer.Back, &hip.EcCa1Prjn{})
+	pj.SetClass("EcCa1Prjn")
+
+	// in <-> cortex pathways: each gets its own prjn as the pool ranges differ by model
+	IntoCortex := prjn.NewPoolOneToOne()
+	IntoCortex.SendStart = m.InToCtx.SendStart
+	IntoCortex.RecvStart = m.InToCtx.RecvStart
+	IntoCortex.NPools = m.InToCtx.NPools
+	net.ConnectLayers(in, cortex, IntoCortex, emer.Forward)
+
+	CortextoOut := prjn.NewPoolOneToOne()
+	CortextoOut.SendStart = m.CtxToOut.SendStart
+	CortextoOut.RecvStart = m.CtxToOut.RecvStart
+	CortextoOut.NPools = m.CtxToOut.NPools
+	net.BidirConnectLayers(cortex, out, CortextoOut)
+
+	// Perforant pathway
+	ppathDG := prjn.NewUnifRnd()
+	ppathDG.PCon = hp.DGPCon
+	ppathCA3 := prjn.NewUnifRnd()
+	ppathCA3.PCon = hp.CA3PCon
+
+	pj = net.ConnectLayersPrjn(ecin, dg, ppathDG, emer.Forward, &hip.CHLPrjn{})
+	pj.SetClass("HippoCHL")
+
+	if true { // toggle for bcm vs. ppath
+		pj = net.ConnectLayersPrjn(ecin, ca3, ppathCA3, emer.Forward, &hip.EcCa1Prjn{})
+		pj.SetClass("PPath")
+		pj = net.ConnectLayersPrjn(ca3, ca3, full, emer.Lateral, &hip.EcCa1Prjn{})
+		pj.SetClass("PPath")
+	} else {
+		// so far, this is sig worse, even with error-driven MinusQ1 case (which is better than off)
+		pj = net.ConnectLayersPrjn(ecin, ca3, ppathCA3, emer.Forward, &hip.CHLPrjn{})
+		pj.SetClass("HippoCHL")
+		pj = net.ConnectLayersPrjn(ca3, ca3, full, emer.Lateral, &hip.CHLPrjn{})
+		pj.SetClass("HippoCHL")
+	}
+
+	// always use this for now:
+	if true {
+		pj = net.ConnectLayersPrjn(ca3, ca1, full, emer.Forward, &CHLPrjn{})
+		pj.SetClass("HippoCHL")
+	} else {
+		// note: this requires lrate = 1.0 or maybe 1.2, doesn't work *nearly* as well
+		pj = net.ConnectLayers(ca3, ca1, full, emer.Forward) // default con
+		// pj.SetClass("HippoCHL")
+	}
+
+	// Mossy fibers
+	mossy := prjn.NewUnifRnd()
+	mossy.PCon = hp.MossyPCon
+	pj = net.ConnectLayersPrjn(dg, ca3, mossy, emer.Forward, &hip.CHLPrjn{}) // no learning
+	pj.SetClass("HippoCHL")
+
+	if !ss.NoThreads {
+		// using 4 threads total (rest on 0)
+		dg.SetThread(1)
+		ca3.SetThread(2)
+		ca1.SetThread(3) // this has the most
+	}
+
+	if m.ConfigNet != nil {
+		m.ConfigNet(ss, net)
+	}
+
+	// note: if you wanted to change a layer type from e.g., Target to Compare, do this:
+	// outLay.SetType(emer.Compare)
+	// that would mean that the output layer doesn't reflect target values in plus phase
+	// and thus removes error-driven learning -- but stats are still computed.
+
+	net.Defaults()
+	ss.SetParams("Network", ss.LogSetParams) // only set Network params
+	err := net.Build()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	net.InitWts()
+}
+
+func (ss *Sim) ReConfigNet() {
+	ss.Update()
+	ss.WithRand(ss.Rnd.Pats, ss.ConfigPats)
+	if ss.Net.NThreads > 0 {
+		ss.Net.StopThreads() // stop the threads of the old network
+	}
+	ss.Net = &leabra.Network{} // start over with new network
+	ss.WithRand(ss.Rnd.Wts, func() { ss.ConfigNet(ss.Net) })
+	if ss.View != nil {
+		ss.View.SetNet(ss.Net)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// 	    Init, utils
+
+// Init restarts the run, and initializes everything, including network weights
+// and resets the epoch log table
+func (ss *Sim) Init() {
+	ss.InitSeeds(ss.StartRun)
+	ss.SetParams("", ss.LogSetParams) // all sheets
+	ss.ReConfigNet()
+	ss.ConfigEnv() // re-config env just in case a different set of patterns was
+	// selected or patterns have been modified etc
+	ss.StopNow = false
+	ss.NewRun()
+	ss.UpdateView(true)
+}
+
+// NewRndSeed gets a new random seed based on current time -- otherwise uses
+// the same random seed for every run
+func (ss *Sim) NewRndSeed() {
+	ss.RndSeed = time.Now().UnixNano()
+}
+
+// Counters returns a string of the current counter state
+// use tabs to achieve a reasonable formatting overall
+// and add a few tabs at the end to allow for expansion..
+func (ss *Sim) Counters(train bool) string {
+	if train {
+		return fmt.Sprintf("Run:\t%d\tEpoch:\t%d\tTrial:\t%d\tCycle:\t%d\tName:\t%v\t\t\t", ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur, ss.TrainEnv.Trial.Cur, ss.Time.Cycle, ss.TrainEnv.TrialName.Cur)
+	} else {
+		return fmt.Sprintf("Run:\t%d\tEpoch:\t%d\tTrial:\t%d\tCycle:\t%d\tName:\t%v\t\t\t", ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur, ss.TestEnv.Trial.Cur, ss.Time.Cycle, ss.TestEnv.TrialName.Cur)
+	}
+}
+
+// UpdateView updates the network view, if there is a gui
+func (ss *Sim) UpdateView(train bool) {
+	if ss.View != nil {
+		ss.View.UpdateNet(train)
+	}
+}
+
+// UpdatePlot updates the plot of the named log (e.g., "TstTrlLog"), if there is a gui
+func (ss *Sim) UpdatePlot(lognm string) {
+	if ss.View != nil {
+		ss.View.UpdatePlot(lognm)
+	}
+}
@@ -0,0 +1,542 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Snapshots
+
+// Snapshot is the full state of a Sim between two protocol stages: the network
+// state, timing, the environments, the pattern tables, the stats and the
+// in-memory logs, and where it is in its protocol.  It is saved by SaveSnapshot
+// and restored by LoadSnapshot, so a protocol can be paused and resumed, on
+// another machine if need be, exactly as it would have continued.
+//
+// Saving a snapshot does not change the state of the Sim: the random streams
+// of the run (RndStreams) are saved as their seeds and the number of values
+// drawn from them, and nothing draws from math/rand except from a seed of a
+// stream (see WithRand).  A snapshot also holds the weights checkpoints that
+// the protocol has saved so far, and the run settings, which the Sim that
+// resumes it must have too.
+type Snapshot struct {
+	Net      []LayerState           `desc:"state of each layer and its receiving projections"`
+	Time     leabra.Time            `desc:"network timing"`
+	TrainEnv EnvState               `desc:"training environment"`
+	TestEnv  EnvState               `desc:"testing environment"`
+	Pats     map[string]*TableState `desc:"pattern tables, by PatsByName name"`
+	Vocab    map[string]*ColState   `desc:"pool vocabulary"`
+	Logs     map[string]*TableState `desc:"in-memory logs, by field name"`
+	Stats    SimState               `desc:"stats and other Sim state"`
+	Hip      HipParams              `desc:"hippocampus parameters"`
+	Pat      PatParams              `desc:"pattern parameters"`
+	Ret      RetParams              `desc:"retention interval parameters"`
+	RP       RPParams               `desc:"retrieval practice parameters"`
+	Settings RunSettings            `desc:"run settings, which must be the same to resume"`
+	Ckpts    map[string][]byte      `desc:"contents of the weights checkpoint files saved so far, by checkpoint name"`
+	Protocol *Protocol              `desc:"protocol being run, if any"`
+	Stage    int                    `desc:"index of the protocol stage to run next"`
+	Rep      int                    `desc:"repetition of that stage to run next"`
+}
+
+// LayerState is the state of one layer and its receiving projections
+type LayerState struct {
+	Name    string              `desc:"layer name"`
+	Off     bool                `desc:"Off flag"`
+	Type    emer.LayerType      `desc:"layer type, which the phase schedules change"`
+	Neurons []leabra.Neuron     `desc:"neuron state"`
+	Pools   []leabra.Pool       `desc:"pool state, including the running-average activations"`
+	CosDiff leabra.CosDiffStats `desc:"cosine difference stats"`
+	Prjns   []PrjnState         `desc:"receiving projections"`
+}
+
+// PrjnState is the state of one projection, including the params that are
+// changed while running
+type PrjnState struct {
+	Name    string                 `desc:"projection name"`
+	Syns    []leabra.Synapse       `desc:"synapse state: weights, weight changes etc"`
+	GScale  float32                `desc:"conductance scaling"`
+	GInc    []float32              `desc:"conductance increments"`
+	WbRecv  []leabra.WtBalRecvPrjn `desc:"weight balance state"`
+	WtScale leabra.WtScaleParams   `desc:"weight scales, which the phase schedules change"`
+	Learn   bool                   `desc:"Learn.Learn flag"`
+	CHL     *CHLParams             `desc:"CHL params of a CHLPrjn, which RP params change"`
+}
+
+// EnvState is the state of an env.FixedTable
+type EnvState struct {
+	Table      string           `desc:"PatsByName name of the table"`
+	Idxs       []int            `desc:"indexes of the table view"`
+	Order      []int            `desc:"permuted order"`
+	Sequential bool             `desc:"sequential or permuted order"`
+	Run        env.Ctr          `desc:"run counter"`
+	Epoch      env.Ctr          `desc:"epoch counter"`
+	Trial      env.Ctr          `desc:"trial counter"`
+	TrialName  env.CurPrvString `desc:"trial name"`
+	GroupName  env.CurPrvString `desc:"group name"`
+}
+
+// SimState is the Sim state that is not in the network, the envs or the tables
+type SimState struct {
+	FirstZero, NZero, Delay, CntErr                     int
+	NeedsNewRun, Hiponly, Coronly                       bool
+	TestNm                                              string
+	SumSSE, SumAvgSSE, SumCosDiff                       float64
+	EpcSSE, EpcAvgSSE, EpcPctErr, EpcPctCor, EpcCosDiff float64
+	RndSeed                                             int64
+	Seeds                                               Seeds
+	Streams                                             RndState
+	StartRun                                            int
+	PreTrainWts                                         []byte
+	PracItems                                           map[int]bool
+}
+
+// TableState is the contents of an etable.Table
+type TableState struct {
+	Rows     int               `desc:"number of rows"`
+	MetaData map[string]string `desc:"table meta data"`
+	Cols     []*ColState       `desc:"columns"`
+}
+
+// ColState is the contents of a tensor: a table column or a vocabulary item
+type ColState struct {
+	Name    string       `desc:"column name"`
+	Type    etensor.Type `desc:"data type"`
+	Shape   []int        `desc:"shape, including rows"`
+	Names   []string     `desc:"dimension names"`
+	Floats  []float64    `desc:"values of a numeric tensor"`
+	Strings []string     `desc:"values of a string tensor"`
+}
+
+// NewColState returns the state of the tensor
+func NewColState(name string, tsr etensor.Tensor) *ColState {
+	cs := &ColState{Name: name, Type: tsr.DataType(), Shape: tsr.Shapes(), Names: tsr.DimNames()}
+	if st, ok := tsr.(*etensor.String); ok {
+		cs.Strings = append([]string{}, st.Values...)
+	} else {
+		tsr.Floats(&cs.Floats)
+	}
+	return cs
+}
+
+// Tensor returns a new tensor with the state
+func (cs *ColState) Tensor() etensor.Tensor {
+	tsr := etensor.New(cs.Type, cs.Shape, nil, cs.Names)
+	if st, ok := tsr.(*etensor.String); ok {
+		copy(st.Values, cs.Strings)
+	} else {
+		tsr.SetFloats(cs.Floats)
+	}
+	return tsr
+}
+
+// NewTableState returns the state of the table
+func NewTableState(dt *etable.Table) *TableState {
+	ts := &TableState{Rows: dt.Rows, MetaData: make(map[string]string)}
+	for k, v := range dt.MetaData {
+		ts.MetaData[k] = v
+	}
+	for ci, cl := range dt.Cols {
+		ts.Cols = append(ts.Cols, NewColState(dt.ColNames[ci], cl))
+	}
+	return ts
+}
+
+// SetTable sets dt to the state, in place, so that views of dt remain valid
+func (ts *TableState) SetTable(dt *etable.Table) {
+	nt := &etable.Table{Rows: ts.Rows, MetaData: ts.MetaData}
+	for _, cs := range ts.Cols {
+		nt.AddCol(cs.Tensor(), cs.Name)
+	}
+	*dt = *nt
+}
+
+// PatsNames returns the PatsByName names of all the pattern tables
+func (ss *Sim) PatsNames() []string {
+	nms := []string{"TrainAB", "TrainAll", "TrainNoise", "TrainRP", "TestAB", "TestLong", "TrainAC", "TrainFiller", "TestAC", "TestLure"}
+	for nm := range ss.MorePats {
+		nms = append(nms, nm)
+	}
+	return nms
+}
+
+// LogTables returns the in-memory logs, by name
+func (ss *Sim) LogTables() map[string]*etable.Table {
+	lts := map[string]*etable.Table{
+		"TrnTrlLog": ss.TrnTrlLog,
+		"TrnEpcLog": ss.TrnEpcLog,
+		"TstEpcLog": ss.TstEpcLog,
+		"TstTrlLog": ss.TstTrlLog,
+		"RPTrlLog":  ss.RPTrlLog,
+		"ActLog":    ss.ActLog,
+		"RSALog":    ss.RSALog,
+		"WtChgLog":  ss.WtChgLog,
+		"TstCycLog": ss.TstCycLog,
+		"RunLog":    ss.RunLog,
+	}
+	if ss.TstTrlAll != nil {
+		lts["TstTrlAll"] = ss.TstTrlAll
+	}
+	return lts
+}
+
+// patsName returns the PatsByName name of dt, or "" if it is not a pattern table
+func (ss *Sim) patsName(dt *etable.Table) string {
+	for _, nm := range ss.PatsNames() {
+		if ss.PatsByName(nm) == dt {
+			return nm
+		}
+	}
+	return ""
+}
+
+// envState returns the state of the env
+func (ss *Sim) envState(ev *env.FixedTable) EnvState {
+	es := EnvState{Order: append([]int{}, ev.Order...), Sequential: ev.Sequential,
+		Run: ev.Run, Epoch: ev.Epoch, Trial: ev.Trial, TrialName: ev.TrialName, GroupName: ev.GroupName}
+	if ev.Table != nil {
+		es.Table = ss.patsName(ev.Table.Table)
+		es.Idxs = append([]int{}, ev.Table.Idxs...)
+	}
+	return es
+}
+
+// setEnv sets the env to the state
+func (ss *Sim) setEnv(ev *env.FixedTable, es *EnvState) error {
+	if es.Table != "" {
+		dt := ss.PatsByName(es.Table)
+		if dt == nil {
+			return fmt.Errorf("hipbench: snapshot env table not found: %s", es.Table)
+		}
+		ev.Table = etable.NewIdxView(dt)
+		ev.Table.Idxs = es.Idxs
+	}
+	ev.Order = es.Order
+	ev.Sequential = es.Sequential
+	ev.Run, ev.Epoch, ev.Trial = es.Run, es.Epoch, es.Trial
+	ev.TrialName, ev.GroupName = es.TrialName, es.GroupName
+	return nil
+}
+
+// RunSettings are the settings of the Sim that are not part of its state, but
+// that a resumed protocol must be run with to continue as it would have
+type RunSettings struct {
+	Model        string
+	ParamSet     string
+	ExtraParams  []string
+	MaxEpcs      int
+	PreTrainEpcs int
+	NZeroStop    int
+	MemLay       string
+	MemThr       float64
+	Tag          string
+}
+
+// RunSettings returns the current RunSettings
+func (ss *Sim) RunSettings() RunSettings {
+	rs := RunSettings{Model: ss.Model.Name, ParamSet: ss.ParamSet, MaxEpcs: ss.MaxEpcs, PreTrainEpcs: ss.PreTrainEpcs,
+		NZeroStop: ss.NZeroStop, MemLay: ss.MemLay, MemThr: ss.MemThr, Tag: ss.Tag}
+	if len(ss.ExtraParams) > 0 { // gob saves an empty list as nil
+		rs.ExtraParams = append([]string{}, ss.ExtraParams...)
+	}
+	return rs
+}
+
+// Check returns an error naming the first setting that differs in cur
+func (rs *RunSettings) Check(cur *RunSettings) error {
+	sv, cv := reflect.ValueOf(*rs), reflect.ValueOf(*cur)
+	for i := 0; i < sv.NumField(); i++ {
+		if !reflect.DeepEqual(sv.Field(i).Interface(), cv.Field(i).Interface()) {
+			return fmt.Errorf("hipbench: snapshot was saved with %s %v, not %v", sv.Type().Field(i).Name, sv.Field(i).Interface(), cv.Field(i).Interface())
+		}
+	}
+	return nil
+}
+
+// SnapCkpts returns the contents of the checkpoint files saved by the stages
+// of the protocol before the given one, by checkpoint name
+func (ss *Sim) SnapCkpts(pr *Protocol, stage int) (map[string][]byte, error) {
+	cks := make(map[string][]byte)
+	if pr == nil {
+		return cks, nil
+	}
+	for i := 0; i < stage && i < len(pr.Stages); i++ {
+		st := &pr.Stages[i]
+		if !CkptStages[st.Do] {
+			continue
+		}
+		b, err := ioutil.ReadFile(ss.CkptFileName(st.ckptName()))
+		if err != nil {
+			return nil, fmt.Errorf("hipbench: snapshot: missing checkpoint %s: %v", st.ckptName(), err)
+		}
+		cks[st.ckptName()] = b
+	}
+	return cks, nil
+}
+
+// CheckCkpts returns an error if a load stage still to run in the snapshot's
+// protocol needs a checkpoint that neither the snapshot has, nor a stage
+// before it saves
+func (sn *Snapshot) CheckCkpts() error {
+	pr := sn.Protocol
+	if pr == nil {
+		return nil
+	}
+	have := make(map[string]bool)
+	for nm := range sn.Ckpts {
+		have[nm] = true
+	}
+	for i := sn.Stage; i < len(pr.Stages); i++ {
+		st := &pr.Stages[i]
+		if st.Do == "load" && !have[st.Ckpt] {
+			return fmt.Errorf("hipbench: snapshot: protocol %s: stage %d: missing checkpoint %s", pr.Name, i, st.Ckpt)
+		}
+		if CkptStages[st.Do] {
+			have[st.ckptName()] = true
+		}
+	}
+	return nil
+}
+
+// NewSnapshot returns a snapshot of the current state, positioned to continue
+// the protocol at the given stage and rep -- it fails if a checkpoint that the
+// protocol saved before that stage cannot be read
+func (ss *Sim) NewSnapshot(pr *Protocol, stage, rep int) (*Snapshot, error) {
+	cks, err := ss.SnapCkpts(pr, stage)
+	if err != nil {
+		return nil, err
+	}
+	sn := &Snapshot{Time: ss.Time, Hip: ss.Hip, Pat: ss.Pat, Ret: ss.Ret, RP: ss.RP,
+		Settings: ss.RunSettings(), Ckpts: cks, Protocol: pr, Stage: stage, Rep: rep}
+	for _, lyi := range ss.Net.Layers {
+		ly := lyi.(leabra.LeabraLayer).AsLeabra()
+		ls := LayerState{Name: ly.Nm, Off: ly.Off, Type: ly.Typ, CosDiff: ly.CosDiff,
+			Neurons: append([]leabra.Neuron{}, ly.Neurons...), Pools: append([]leabra.Pool{}, ly.Pools...)}
+		for _, p := range ly.RcvPrjns {
+			pj := p.(leabra.LeabraPrjn).AsLeabra()
+			ps := PrjnState{Name: pj.Name(), Syns: append([]leabra.Synapse{}, pj.Syns...), GScale: pj.GScale,
+				GInc: append([]float32{}, pj.GInc...), WbRecv: append([]leabra.WtBalRecvPrjn{}, pj.WbRecv...),
+				WtScale: pj.WtScale, Learn: pj.Learn.Learn}
+			if cp, ok := p.(*CHLPrjn); ok {
+				chl := cp.CHL
+				ps.CHL = &chl
+			}
+			ls.Prjns = append(ls.Prjns, ps)
+		}
+		sn.Net = append(sn.Net, ls)
+	}
+	sn.TrainEnv = ss.envState(&ss.TrainEnv)
+	sn.TestEnv = ss.envState(&ss.TestEnv)
+	sn.Pats = make(map[string]*TableState)
+	for _, nm := range ss.PatsNames() {
+		if dt := ss.PatsByName(nm); dt != nil {
+			sn.Pats[nm] = NewTableState(dt)
+		}
+	}
+	sn.Vocab = make(map[string]*ColState)
+	for nm, tsr := range ss.PoolVocab {
+		sn.Vocab[nm] = NewColState(nm, tsr)
+	}
+	sn.Logs = make(map[string]*TableState)
+	for nm, dt := range ss.LogTables() {
+		sn.Logs[nm] = NewTableState(dt)
+	}
+	sn.Stats = SimState{FirstZero: ss.FirstZero, NZero: ss.NZero, Delay: ss.Delay, CntErr: ss.CntErr,
+		NeedsNewRun: ss.NeedsNewRun, Hiponly: ss.Hiponly, Coronly: ss.Coronly, TestNm: ss.TestNm,
+		SumSSE: ss.SumSSE, SumAvgSSE: ss.SumAvgSSE, SumCosDiff: ss.SumCosDiff,
+		EpcSSE: ss.EpcSSE, EpcAvgSSE: ss.EpcAvgSSE, EpcPctErr: ss.EpcPctErr, EpcPctCor: ss.EpcPctCor, EpcCosDiff: ss.EpcCosDiff,
+		RndSeed: ss.RndSeed, Seeds: ss.Seeds, StartRun: ss.StartRun, PreTrainWts: ss.PreTrainWts,
+		PracItems: ss.PracItems}
+	if ss.Rnd.Pats != nil {
+		sn.Stats.Streams = ss.Rnd.State()
+	}
+	return sn, nil
+}
+
+// SetSnapshot sets the Sim to the state of the snapshot, and writes its
+// checkpoints to their files.  The Sim must have been configured as the one
+// that saved it, with the same network and RunSettings.
+func (ss *Sim) SetSnapshot(sn *Snapshot) error {
+	cur := ss.RunSettings()
+	if err := sn.Settings.Check(&cur); err != nil {
+		return err
+	}
+	if err := sn.CheckCkpts(); err != nil {
+		return err
+	}
+	if len(sn.Net) != len(ss.Net.Layers) {
+		return fmt.Errorf("hipbench: snapshot has %d layers, network has %d", len(sn.Net), len(ss.Net.Layers))
+	}
+	for li, lyi := range ss.Net.Layers {
+		ly := lyi.(leabra.LeabraLayer).AsLeabra()
+		ls := &sn.Net[li]
+		if ls.Name != ly.Nm || len(ls.Neurons) != len(ly.Neurons) || len(ls.Prjns) != len(ly.RcvPrjns) {
+			return fmt.Errorf("hipbench: snapshot layer %s does not match network layer %s", ls.Name, ly.Nm)
+		}
+		for pi, p := range ly.RcvPrjns {
+			pj := p.(leabra.LeabraPrjn).AsLeabra()
+			if ps := &ls.Prjns[pi]; ps.Name != pj.Name() || len(ps.Syns) != len(pj.Syns) {
+				return fmt.Errorf("hipbench: snapshot projection %s does not match network projection %s", ps.Name, pj.Name())
+			}
+		}
+	}
+	for li, lyi := range ss.Net.Layers {
+		ly := lyi.(leabra.LeabraLayer).AsLeabra()
+		ls := &sn.Net[li]
+		ly.Off = ls.Off
+		ly.SetType(ls.Type)
+		ly.UpdateExtFlags()
+		copy(ly.Neurons, ls.Neurons)
+		copy(ly.Pools, ls.Pools)
+		ly.CosDiff = ls.CosDiff
+		for pi, p := range ly.RcvPrjns {
+			pj := p.(leabra.LeabraPrjn).AsLeabra()
+			ps := &ls.Prjns[pi]
+			copy(pj.Syns, ps.Syns)
+			copy(pj.GInc, ps.GInc)
+			copy(pj.WbRecv, ps.WbRecv)
+			pj.GScale = ps.GScale
+			pj.WtScale = ps.WtScale
+			pj.Learn.Learn = ps.Learn
+			if cp, ok := p.(*CHLPrjn); ok && ps.CHL != nil {
+				cp.CHL = *ps.CHL
+			}
+		}
+	}
+	ss.Time = sn.Time
+	ss.Hip, ss.Pat, ss.Ret, ss.RP = sn.Hip, sn.Pat, sn.Ret, sn.RP
+	for nm, ts := range sn.Pats {
+		ts.SetTable(ss.PatsTable(nm))
+	}
+	ss.PoolVocab = make(map[string]*etensor.Float32)
+	for nm, cs := range sn.Vocab {
+		if tsr, ok := cs.Tensor().(*etensor.Float32); ok {
+			ss.PoolVocab[nm] = tsr
+		}
+	}
+	lts := ss.LogTables()
+	for nm, ts := range sn.Logs {
+		if dt, ok := lts[nm]; ok {
+			ts.SetTable(dt)
+		}
+	}
+	if err := ss.setEnv(&ss.TrainEnv, &sn.TrainEnv); err != nil {
+		return err
+	}
+	if err := ss.setEnv(&ss.TestEnv, &sn.TestEnv); err != nil {
+		return err
+	}
+	st := &sn.Stats
+	ss.FirstZero, ss.NZero, ss.Delay, ss.CntErr = st.FirstZero, st.NZero, st.Delay, st.CntErr
+	ss.NeedsNewRun, ss.Hiponly, ss.Coronly, ss.TestNm = st.NeedsNewRun, st.Hiponly, st.Coronly, st.TestNm
+	ss.SumSSE, ss.SumAvgSSE, ss.SumCosDiff = st.SumSSE, st.SumAvgSSE, st.SumCosDiff
+	ss.EpcSSE, ss.EpcAvgSSE, ss.EpcPctErr, ss.EpcPctCor, ss.EpcCosDiff = st.EpcSSE, st.EpcAvgSSE, st.EpcPctErr, st.EpcPctCor, st.EpcCosDiff
+	ss.RndSeed, ss.Seeds, ss.StartRun, ss.PreTrainWts = st.RndSeed, st.Seeds, st.StartRun, st.PreTrainWts
+	ss.PracItems = st.PracItems
+	ss.Rnd.SetState(st.Streams)
+	ss.Ckpts = nil
+	for nm, b := range sn.Ckpts {
+		if err := ioutil.WriteFile(ss.CkptFileName(nm), b, 0644); err != nil {
+			return err
+		}
+		ss.markCkpt(nm)
+	}
+	ss.UpdateView(true)
+	return nil
+}
+
+// SaveSnapshot saves a snapshot of the current state to a gzipped gob file,
+// positioned to continue protocol pr at the given stage and rep (pr may be nil)
+func (ss *Sim) SaveSnapshot(fname string, pr *Protocol, stage, rep int) error {
+	sn, err := ss.NewSnapshot(pr, stage, rep)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	err = gob.NewEncoder(gz).Encode(sn)
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// LoadSnapshot restores the state of the snapshot saved in the file, and
+// returns the snapshot, whose Protocol, Stage and Rep say where to continue
+func (ss *Sim) LoadSnapshot(fname string) (*Snapshot, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	sn := &Snapshot{}
+	if err := gob.NewDecoder(gz).Decode(sn); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return sn, ss.SetSnapshot(sn)
+}
+
+// SnapFileName returns the file that snapshots of the current run are saved
+// to: SnapFile with the run number added before its extension, in OutDir
+func (ss *Sim) SnapFileName() string {
+	base, ext := ss.SnapFile, ".snap"
+	if i := strings.LastIndex(base, "."); i > 0 {
+		base, ext = base[:i], base[i:]
+	}
+	return ss.OutFile(fmt.Sprintf("%s_run%03d%s", base, ss.TrainEnv.Run.Cur, ext))
+}
+
+// SnapStage saves a snapshot to SnapFileName if SnapFile is set, positioned
+// to continue protocol pr at the given stage and rep
+func (ss *Sim) SnapStage(pr *Protocol, stage, rep int) {
+	if ss.SnapFile == "" {
+		return
+	}
+	if err := ss.SaveSnapshot(ss.SnapFileName(), pr, stage, rep); err != nil {
+		log.Println(err)
+	}
+}
+
+// ResumeProtocol loads the snapshot saved in the file, and continues its
+// protocol from where it was saved.  A snapshot is of one run, so this
+// continues just that run, whatever MaxRuns is.
+func (ss *Sim) ResumeProtocol(fname string) error {
+	sn, err := ss.LoadSnapshot(fname)
+	if err != nil {
+		ss.Stopped()
+		return err
+	}
+	if sn.Protocol == nil {
+		ss.Stopped()
+		return fmt.Errorf("hipbench: snapshot %s has no protocol to resume", fname)
+	}
+	ss.Conds = ProtocolConds(sn.Protocol) // the restored RunLog has its columns
+	fmt.Printf("Resuming protocol: %s at stage: %d\n", sn.Protocol.Name, sn.Stage)
+	return ss.RunProtocolFrom(sn.Protocol, sn.Stage, sn.Rep)
+}
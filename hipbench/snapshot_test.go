@@ -0,0 +1,137 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamState(t *testing.T) {
+	for _, n := range []int{0, 1, 17} {
+		st := NewStream(42)
+		for i := 0; i < n; i++ {
+			st.Perm(5)
+		}
+		rs := NewStreamAt(st.State())
+		if rs.State() != st.State() {
+			t.Errorf("%d draws: restored state %v, want %v", n, rs.State(), st.State())
+		}
+		for i := 0; i < 10; i++ {
+			if a, b := st.Int63(), rs.Int63(); a != b {
+				t.Fatalf("%d draws: value %d of restored stream = %d, want %d", n, i, b, a)
+			}
+		}
+	}
+}
+
+// snapProtocol studies, forks from the study checkpoint after a delay, and tests
+var snapProtocol = &Protocol{Name: "Snap", Stages: []Stage{
+	{Do: "init"},
+	{Do: "study"},
+	{Do: "delay", Mode: "both"},
+	{Do: "load", Ckpt: "study"},
+	{Do: "study"},
+	{Do: "test", Save: "final"},
+}}
+
+// ckptBytes returns the weights of the sim
+func ckptBytes(t *testing.T, ss *Sim) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := ss.WriteCkpt(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestSnapshotResume(t *testing.T) {
+	full := testSim(t)
+	full.Pat.Permute = true
+	full.Init()
+	if err := full.RunProtocol(snapProtocol); err != nil {
+		t.Fatal(err)
+	}
+	want := ckptBytes(t, full)
+
+	// run the first stages, saving a snapshot after each
+	part := testSim(t)
+	part.Pat.Permute = true
+	part.Init()
+	part.SnapFile = filepath.Join(t.TempDir(), "snap.snap")
+	pre := &Protocol{Name: snapProtocol.Name, Stages: snapProtocol.Stages[:3]}
+	if err := part.RunProtocol(pre); err != nil {
+		t.Fatal(err)
+	}
+	rst := part.Rnd.State()
+	if err := part.SaveSnapshot(part.SnapFileName(), snapProtocol, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if part.Rnd.State() != rst {
+		t.Errorf("saving a snapshot changed the random streams")
+	}
+
+	// resume in a new sim, with its own checkpoint dir
+	res := testSim(t)
+	res.Pat.Permute = true
+	res.Init()
+	if err := res.ResumeProtocol(part.SnapFileName()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ckptBytes(t, res), want) {
+		t.Errorf("resumed weights differ from those of the full run")
+	}
+}
+
+func TestSnapshotChecks(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	pre := &Protocol{Name: snapProtocol.Name, Stages: snapProtocol.Stages[:2]}
+	if err := ss.RunProtocol(pre); err != nil {
+		t.Fatal(err)
+	}
+	fnm := filepath.Join(t.TempDir(), "snap.snap")
+	if err := ss.SaveSnapshot(fnm, snapProtocol, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		set  func(ss *Sim)
+		ok   bool
+	}{
+		{"same", func(ss *Sim) {}, true},
+		{"MaxEpcs", func(ss *Sim) { ss.MaxEpcs = 2 }, false},
+		{"ParamSet", func(ss *Sim) { ss.ParamSet = "RP" }, false},
+		{"ExtraParams", func(ss *Sim) { ss.ExtraParams = []string{"RP"} }, false},
+		{"Tag", func(ss *Sim) { ss.Tag = "other" }, false},
+		{"MemThr", func(ss *Sim) { ss.MemThr = .5 }, false},
+		{"empty ExtraParams", func(ss *Sim) { ss.ExtraParams = []string{} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := testSim(t)
+			tt.set(rs)
+			if _, err := rs.LoadSnapshot(fnm); (err == nil) != tt.ok {
+				t.Errorf("LoadSnapshot() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+
+	// a snapshot of a protocol that needs a checkpoint it has not saved
+	bad := &Protocol{Name: "Bad", Stages: []Stage{{Do: "init"}, {Do: "study"}, {Do: "load", Ckpt: "rp"}}}
+	if err := ss.SaveSnapshot(fnm, bad, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testSim(t).LoadSnapshot(fnm); err == nil {
+		t.Errorf("LoadSnapshot() of a snapshot missing a checkpoint succeeded")
+	}
+
+	// a snapshot after a stage whose checkpoint file is gone
+	ss.CkptDir = t.TempDir()
+	if err := ss.SaveSnapshot(fnm, snapProtocol, 2, 0); err == nil {
+		t.Errorf("SaveSnapshot() without the study checkpoint succeeded")
+	}
+}
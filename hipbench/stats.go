@@ -0,0 +1,261 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"math"
+
+	"github.com/emer/etable/etensor"
+	"github.com/emer/etable/metric"
+	"github.com/emer/leabra/leabra"
+)
+
+// InitStats initializes all the statistics, especially important for the
+// cumulative epoch stats -- called at start of new run
+func (ss *Sim) InitStats() {
+	// accumulators
+	ss.SumSSE = 0
+	ss.SumAvgSSE = 0
+	ss.SumCosDiff = 0
+	ss.CntErr = 0
+	ss.FirstZero = -1
+	ss.NZero = 0
+	ss.Delay = 0
+	// clear rest just to make Sim look initialized
+	ss.Mem = 0
+	ss.TrgOnWasOffAll = 0
+	ss.TrgOnWasOffCmp = 0
+	ss.TrgOffWasOn = 0
+	ss.PoolMem = nil
+	ss.PoolTrgOnWasOff = nil
+	ss.PoolTrgOffWasOn = nil
+	ss.MemFam = 0
+	ss.TrgOnWasOffAllFam = 0
+	ss.TrgOnWasOffCmpFam = 0
+	ss.TrgOffWasOnFam = 0
+	ss.Famil = 0
+	ss.TrlSSE = 0
+	ss.TrlAvgSSE = 0
+	ss.EpcSSE = 0
+	ss.EpcAvgSSE = 0
+	ss.EpcPctErr = 0
+	ss.EpcCosDiff = 0
+}
+
+func (ss *Sim) ValsTsr(name string) *etensor.Float32 {
+	if ss.ValsTsrs == nil {
+		ss.ValsTsrs = make(map[string]*etensor.Float32)
+	}
+	tsr, ok := ss.ValsTsrs[name]
+	if !ok {
+		tsr = &etensor.Float32{}
+		ss.ValsTsrs[name] = tsr
+	}
+	return tsr
+}
+
+func (ss *Sim) CA3COR() {
+	ca1 := ss.Net.LayerByName("CA3").(leabra.LeabraLayer).AsLeabra()
+
+	var ca3q1 []float32
+	var ca3q2 []float32
+	var ca3q3 []float32
+	var ca3q4 []float32
+
+	ca1.UnitVals(&ca3q1, "ActQ1")
+	ca1.UnitVals(&ca3q2, "ActQ2")
+	ca1.UnitVals(&ca3q3, "ActM")
+	ca1.UnitVals(&ca3q4, "ActP")
+
+	ss.CA312 = metric.Correlation32(ca3q1, ca3q2)
+	ss.CA323 = metric.Correlation32(ca3q2, ca3q3)
+	ss.CA334 = metric.Correlation32(ca3q3, ca3q4)
+}
+
+// PrjnDWt returns the mean |DWt| over the synapses of the named projection --
+// the learning of the last trial, until WtFmDWt applies it at the start of the
+// next -- or NaN if there is no such projection
+func (ss *Sim) PrjnDWt(name string) float64 {
+	pj := ss.PrjnByName(name)
+	if pj == nil || len(pj.Syns) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for si := range pj.Syns {
+		sum += math.Abs(float64(pj.Syns[si].DWt))
+	}
+	return sum / float64(len(pj.Syns))
+}
+
+// MemStats computes ActM vs. Target on the Model.MemScore pools with binary counts
+// (and on the Model.FamScore pools for familiarity, if set), with a per-pool
+// breakdown of the MemScore pools in PoolMem etc.
+// must be called at end of 3rd quarter so that Targ values are
+// for the entire full pattern as opposed to the plus-phase target
+// values clamped from ECin activations
+func (ss *Sim) MemStats(train bool) {
+	tbl := ss.CurPatsName(train)
+	sc := ss.Model.MemScore
+	if ss.MemLay != "" {
+		sc.Layer = ss.MemLay
+	}
+	ss.Mem, ss.TrgOnWasOffAll, ss.TrgOnWasOffCmp, ss.TrgOffWasOn = ss.ScoreMem(&sc, tbl, train, true)
+	if ss.Model.FamScore.On() {
+		ss.MemFam, ss.TrgOnWasOffAllFam, ss.TrgOnWasOffCmpFam, ss.TrgOffWasOnFam = ss.ScoreMem(&ss.Model.FamScore, tbl, train, false)
+		ss.FamilStats(tbl)
+	}
+}
+
+// CurPatsName returns the name of the pattern table being run by the train or
+// test env, which names its pool mix in Model.Pats
+func (ss *Sim) CurPatsName(train bool) string {
+	ev := &ss.TestEnv
+	if train {
+		ev = &ss.TrainEnv
+	}
+	if ev.Table == nil || ev.Table.Table == nil {
+		return ""
+	}
+	return ev.Table.Table.MetaData["name"]
+}
+
+// ScorePools returns the pools that sc scores on pattern table tbl, and which
+// of them are completion pools of tbl
+func (ss *Sim) ScorePools(sc *Score, tbl string) (pools []int, cmp map[int]bool) {
+	cmp = make(map[int]bool)
+	pm, ok := ss.Model.Pats[tbl]
+	if ok {
+		for _, pi := range pm.CmpPools() {
+			cmp[pi] = true
+		}
+	}
+	pools = sc.Pools
+	if pools != nil {
+		return
+	}
+	if ok {
+		pools = pm.CmpPools()
+	}
+	if len(pools) == 0 {
+		cue := sc.Cue
+		if cue == "" {
+			cue = "TestAB"
+		}
+		cm := ss.Model.Pats[cue]
+		pools = cm.CmpPools()
+	}
+	return
+}
+
+// ScoreMem scores the pools of sc on pattern table tbl: the completion units are
+// the target-on units of its completion pools (see ScorePools).  mem is 1 if the
+// proportion of errors is below MemThr -- for all target-on units when training,
+// and completion units when testing, if any are scored.  If pools is true, the
+// per-pool scores are recorded in PoolMem, PoolTrgOnWasOff, and PoolTrgOffWasOn,
+// which are NaN for the pools not scored.
+func (ss *Sim) ScoreMem(sc *Score, tbl string, train, pools bool) (mem, trgOnWasOffAll, trgOnWasOffCmp, trgOffWasOn float64) {
+	ly := ss.Net.LayerByName(sc.Layer).(leabra.LeabraLayer).AsLeabra()
+	scp, cmp := ss.ScorePools(sc, tbl)
+	npl := ly.Shp.Dim(0) * ly.Shp.Dim(1)
+	npu := ly.Shp.Dim(2) * ly.Shp.Dim(3)
+	if pools {
+		ss.PoolMem = resetNaN(ss.PoolMem, npl)
+		ss.PoolTrgOnWasOff = resetNaN(ss.PoolTrgOnWasOff, npl)
+		ss.PoolTrgOffWasOn = resetNaN(ss.PoolTrgOffWasOn, npl)
+	}
+
+	cmpN := 0.0 // completion target
+	trgOnN := 0.0
+	trgOffN := 0.0
+	actMi, _ := ly.UnitVarIdx("ActM")
+	targi, _ := ly.UnitVarIdx("Targ")
+	for _, pi := range scp {
+		if pi < 0 || pi >= npl {
+			continue
+		}
+		pOnN, pOffN, pOnWasOff, pOffWasOn := 0.0, 0.0, 0.0, 0.0
+		for ni := pi * npu; ni < (pi+1)*npu; ni++ {
+			actm := ly.UnitVal1D(actMi, ni)
+			trg := ly.UnitVal1D(targi, ni) // full pattern target
+			if trg < 0.5 {                 // trgOff
+				pOffN += 1
+				if actm > 0.5 {
+					pOffWasOn += 1
+				}
+			} else { // trgOn
+				pOnN += 1
+				if actm < 0.5 {
+					pOnWasOff += 1
+				}
+			}
+		}
+		trgOnN += pOnN
+		trgOffN += pOffN
+		trgOnWasOffAll += pOnWasOff
+		trgOffWasOn += pOffWasOn
+		if cmp[pi] {
+			cmpN += pOnN
+			trgOnWasOffCmp += pOnWasOff
+		}
+		if pools {
+			ss.PoolTrgOnWasOff[pi] = propOf(pOnWasOff, pOnN)
+			ss.PoolTrgOffWasOn[pi] = propOf(pOffWasOn, pOffN)
+			ss.PoolMem[pi] = 0
+			if ss.PoolTrgOnWasOff[pi] < ss.MemThr && ss.PoolTrgOffWasOn[pi] < ss.MemThr {
+				ss.PoolMem[pi] = 1
+			}
+		}
+	}
+	trgOnWasOffAll = propOf(trgOnWasOffAll, trgOnN)
+	trgOffWasOn = propOf(trgOffWasOn, trgOffN)
+	trgOnWasOffCmp = propOf(trgOnWasOffCmp, cmpN)
+	errOn := trgOnWasOffAll
+	if !train && cmpN > 0 { // test on completion, if there is any to do
+		errOn = trgOnWasOffCmp
+	}
+	if trgOnN > 0 && errOn < ss.MemThr && trgOffWasOn < ss.MemThr {
+		mem = 1
+	}
+	return
+}
+
+// propOf returns n / of, or 0 if of is 0
+func propOf(n, of float64) float64 {
+	if of == 0 {
+		return 0
+	}
+	return n / of
+}
+
+// resetNaN returns vals with n elements, all set to NaN
+func resetNaN(vals []float64, n int) []float64 {
+	if len(vals) != n {
+		vals = make([]float64, n)
+	}
+	for i := range vals {
+		vals[i] = math.NaN()
+	}
+	return vals
+}
+
+// TrialStats computes the trial-level statistics and adds them to the epoch accumulators if
+// accum is true.  Note that we're accumulating stats here on the Sim side so the
+// core algorithm side remains as simple as possible, and doesn't need to worry about
+// different time-scales over which stats could be accumulated etc.
+// You can also aggregate directly from log data, as is done for testing stats
+func (ss *Sim) TrialStats(accum bool) (sse, avgsse, cosdiff float64) {
+	outLay := ss.Net.LayerByName("ECout").(leabra.LeabraLayer).AsLeabra()
+	ss.TrlCosDiff = float64(outLay.CosDiff.Cos)
+	ss.TrlSSE, ss.TrlAvgSSE = outLay.MSE(0.5) // 0.5 = per-unit tolerance -- right side of .5
+	if accum {
+		ss.SumSSE += ss.TrlSSE
+		ss.SumAvgSSE += ss.TrlAvgSSE
+		ss.SumCosDiff += ss.TrlCosDiff
+		if ss.TrlSSE != 0 {
+			ss.CntErr++
+		}
+	}
+	return
+}
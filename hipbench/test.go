@@ -0,0 +1,153 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"github.com/emer/emergent/env"
+	"github.com/emer/etable/etable"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Testing
+
+// TestTrial runs one trial of testing -- always sequentially presented inputs
+func (ss *Sim) TestTrial(returnOnChg bool) {
+	ss.TestEnv.Step()
+
+	// Query counters FIRST
+	_, _, chg := ss.TestEnv.Counter(env.Epoch)
+	if chg {
+		if ss.ViewOn && ss.TestUpdt > leabra.AlphaCycle {
+			ss.UpdateView(false)
+		}
+		if returnOnChg {
+			return
+		}
+	}
+
+	ss.ApplyInputs(&ss.TestEnv)
+	ss.AlphaCyc(false)   // !train
+	ss.TrialStats(false) // !accumulate
+	ss.LogTstTrl(ss.TstTrlLog)
+	ss.LogAct(ss.ActLog, &ss.TestEnv, ss.TestNm)
+}
+
+// TestItem tests given item which is at given index in test item list
+func (ss *Sim) TestItem(idx int) {
+	cur := ss.TestEnv.Trial.Cur
+	ss.TestEnv.Trial.Cur = idx
+	ss.TestEnv.SetTrialName()
+	ss.ApplyInputs(&ss.TestEnv)
+	ss.AlphaCyc(false)   // !train
+	ss.TrialStats(false) // !accumulate
+	ss.TestEnv.Trial.Cur = cur
+}
+
+// TestAll runs through the items of each of the Model.Tests
+func (ss *Sim) TestAll() {
+	for i := range ss.Model.Tests {
+		ts := &ss.Model.Tests[i]
+		if ss.StopNow {
+			break
+		}
+		ss.TestTable(ts.Name, ss.PatsByName(ts.Pats))
+	}
+	// log only at very end
+	ss.LogTstEpc(ss.TstEpcLog)
+}
+
+// TestAllLong runs through the long-cue version of the items of each of the Model.Tests
+func (ss *Sim) TestAllLong() {
+	for i := range ss.Model.Tests {
+		ts := &ss.Model.Tests[i]
+		if ss.StopNow {
+			break
+		}
+		ss.TestTable(ts.Name, ss.PatsByName(ts.LongPats()))
+	}
+	// log only at very end
+	ss.LogTstEpc(ss.TstEpcLog)
+}
+
+// TestOne runs through the items of the one of the Model.Tests of given name,
+// in a new TstTrlLog
+func (ss *Sim) TestOne(name string) {
+	ts := ss.Model.Test(name)
+	if ts == nil {
+		return
+	}
+	ss.TstTrlLog.SetNumRows(0)
+	ss.TestTable(ts.Name, ss.PatsByName(ts.Pats))
+	ss.LogTstEpc(ss.TstEpcLog)
+}
+
+// TestSet returns the function running the test set of given name: one of
+// the TestSets, or else the test of that name (see TestOne)
+func (ss *Sim) TestSet(name string) func(ss *Sim) {
+	if fun, ok := TestSets[name]; ok {
+		return fun
+	}
+	return func(ss *Sim) { ss.TestOne(name) }
+}
+
+// TestTable runs through all the items in given table, logged under name nm
+func (ss *Sim) TestTable(nm string, dt *etable.Table) {
+	ss.TestNm = nm
+	ss.TestEnv.Table = etable.NewIdxView(dt)
+	ss.TestEnv.Init(ss.TrainEnv.Run.Cur)
+	for {
+		ss.TestTrial(true) // return on chg
+		_, _, chg := ss.TestEnv.Counter(env.Epoch)
+		if chg || ss.StopNow {
+			break
+		}
+	}
+}
+
+// SetRoute sets the Output projection scales for the final recall test,
+// using Model.Route if set, and RouteRecall otherwise
+func (ss *Sim) SetRoute() {
+	if ss.Model.Route != nil {
+		ss.Model.Route(ss)
+		return
+	}
+	ss.RouteRecall()
+}
+
+// RouteRecall routes recall in Output through the hippocampus (ECout) and / or
+// cortex according to the Hiponly and Coronly flags
+func (ss *Sim) RouteRecall() {
+	output := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+	outputFmCortex := output.RcvPrjns.SendName("Cortex").(leabra.LeabraPrjn).AsLeabra()
+	outputFmECout := output.RcvPrjns.SendName("ECout").(leabra.LeabraPrjn).AsLeabra()
+	if ss.Hiponly {
+		outputFmCortex.WtScale.Rel = 0
+	} else {
+		outputFmCortex.WtScale.Rel = 1
+	}
+
+	if ss.Coronly {
+		outputFmECout.WtScale.Rel = 0
+	} else {
+		outputFmECout.WtScale.Rel = ss.Model.OutRel
+	}
+}
+
+// RunTestAll runs through the full set of testing items, has stop running = false at end -- for gui
+func (ss *Sim) RunTestAll() {
+	ss.StopNow = false
+	ss.SetRoute()
+	ss.TestAll()
+	ss.Stopped()
+}
+
+// RunTestAllLong runs TestAllLong, has stop running = false at end -- for gui
+func (ss *Sim) RunTestAllLong() {
+	ss.StopNow = false
+	ss.SetRoute()
+	ss.TestAllLong()
+	ss.Stopped()
+}
@@ -0,0 +1,411 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/emer/emergent/env"
+	"github.com/emer/emergent/params"
+	"github.com/emer/etable/etable"
+	"github.com/emer/leabra/leabra"
+)
+
+// ApplyInputs applies input patterns from given envirbonment.
+// It is good practice to have this be a separate method with appropriate
+// args so that it can be used for various different contexts
+// (training, testing, etc).
+func (ss *Sim) ApplyInputs(en env.Env) {
+	ss.ApplyLays(en, []string{"Input", "Output"})
+}
+
+// ApplyLays applies input patterns from given environment to the named layers only
+func (ss *Sim) ApplyLays(en env.Env, lays []string) {
+	ss.Net.InitExt() // clear any existing inputs -- not strictly necessary if always
+	// going to the same layers, but good practice and cheap anyway
+
+	for _, lnm := range lays {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		pats := en.State(ly.Nm)
+		if pats != nil {
+			ly.ApplyExt(pats)
+		}
+	}
+}
+
+// TrainTrial runs one trial of training using TrainEnv
+func (ss *Sim) TrainTrial() {
+	if ss.NeedsNewRun {
+		ss.NewRun()
+	}
+
+	ss.StepTrain() // the Env encapsulates and manages all counter state
+
+	// Key to query counters FIRST because current state is in NEXT epoch
+	// if epoch counter has changed
+	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	if chg {
+		ss.LogTrnEpc(ss.TrnEpcLog)
+		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
+			ss.UpdateView(true)
+		}
+		if ss.TestInterval > 0 && epc%ss.TestInterval == 0 { // note: epc is *next* so won't trigger first time
+			ss.TestAll()
+		}
+		learned := (ss.NZeroStop > 0 && ss.NZero >= ss.NZeroStop)
+		//if ss.TrainEnv.Table.Table == ss.TrainAB && (learned || epc == ss.MaxEpcs/2) {
+		//	ss.TrainEnv.Table = etable.NewIdxView(ss.TrainAC)
+		//	learned = false
+		//}
+		if learned || epc >= ss.MaxEpcs { // done with training..
+			if ss.InProtocol { // the run goes on with the next stage
+				ss.StopNow = true
+				return
+			}
+			ss.RunEnd()
+			if ss.TrainEnv.Run.Incr() { // we are done!
+				ss.StopNow = true
+				return
+			} else {
+				ss.NeedsNewRun = true
+				return
+			}
+		}
+	}
+
+	ss.ApplyInputs(&ss.TrainEnv)
+	ss.AlphaCyc(true)   // train
+	ss.TrialStats(true) // accumulate
+	ss.LogTrnTrl(ss.TrnTrlLog)
+	ss.LogAct(ss.ActLog, &ss.TrainEnv, "")
+	ss.MarkPracSyns()
+}
+
+func (ss *Sim) RestudyTrial() {
+	if ss.NeedsNewRun {
+		ss.NewRun()
+	}
+
+	ss.StepTrain() // the Env encapsulates and manages all counter state
+
+	// Key to query counters FIRST because current state is in NEXT epoch
+	// if epoch counter has changed
+	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	if chg {
+		ss.LogTrnEpc(ss.TrnEpcLog)
+		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
+			ss.UpdateView(true)
+		}
+		if ss.TestInterval > 0 && epc%ss.TestInterval == 0 { // note: epc is *next* so won't trigger first time
+			ss.TestAll()
+		}
+		learned := (ss.NZeroStop > 0 && ss.NZero >= ss.NZeroStop)
+		//if ss.TrainEnv.Table.Table == ss.TrainAB && (learned || epc == ss.MaxEpcs/2) {
+		//	ss.TrainEnv.Table = etable.NewIdxView(ss.TrainAC)
+		//	learned = false
+		//}
+		if learned || epc >= ss.MaxEpcs { // done with training..
+			if ss.InProtocol { // the run goes on with the next stage
+				ss.StopNow = true
+				return
+			}
+			ss.RunEnd()
+			if ss.TrainEnv.Run.Incr() { // we are done!
+				ss.StopNow = true
+				return
+			} else {
+				ss.NeedsNewRun = true
+				return
+			}
+		}
+	}
+
+	ss.ApplyInputs(&ss.TrainEnv)
+	ss.AlphaCycPhase("Restudy", true) // train
+	ss.TrialStats(true)               // accumulate
+	ss.LogTrnTrl(ss.TrnTrlLog)
+	ss.LogAct(ss.ActLog, &ss.TrainEnv, "")
+	ss.MarkPracSyns()
+}
+
+// RetrievalPracticeTrial runs one trial of retrieval practice using TrainEnv,
+// with the phase schedules of the given Feedbacks condition
+func (ss *Sim) RetrievalPracticeTrial(fb [2]string) {
+	ss.StepTrain()
+
+	// Query counters FIRST
+	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	if chg {
+		if fb[1] != "" {
+			ss.FeedbackEpoch(fb[1])
+		}
+		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
+			ss.UpdateView(true)
+		}
+		if epc >= ss.MaxEpcs { // done with training..
+			ss.StopNow = true
+			return
+		}
+	}
+
+	ss.ApplyLays(&ss.TrainEnv, ss.Model.RPLays)
+	ss.AlphaCycPhase(fb[0], true) // train
+	ss.TrialStats(true)           // !accumulate
+	ss.LogRPTrl(ss.RPTrlLog)
+	ss.LogAct(ss.ActLog, &ss.TrainEnv, "")
+	ss.MarkPracItem()
+	ss.MarkPracSyns()
+}
+
+// FeedbackEpoch gives the delayed feedback on an epoch of retrieval practice:
+// one trial of the given phase schedule on each item of TrainEnv, without logging.
+// TrainEnv is left at the trial where it was.
+func (ss *Sim) FeedbackEpoch(phase string) {
+	cur := ss.TrainEnv.Trial.Cur
+	for ti := 0; ti < ss.TrainEnv.Trial.Max; ti++ {
+		ss.TrainEnv.Trial.Cur = ti
+		ss.ApplyLays(&ss.TrainEnv, ss.Model.RPLays)
+		ss.AlphaCycPhase(phase, true) // train
+	}
+	ss.TrainEnv.Trial.Cur = cur
+}
+
+// PreTrainTrial runs one trial of pretraining using TrainEnv
+func (ss *Sim) PreTrainTrial() {
+	if ss.NeedsNewRun {
+		ss.NewRun()
+	}
+
+	ss.StepTrain() // the Env encapsulates and manages all counter state
+
+	// Key to query counters FIRST because current state is in NEXT epoch
+	// if epoch counter has changed
+	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	if chg {
+		ss.LogTrnEpc(ss.TrnEpcLog)
+		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
+			ss.UpdateView(true)
+		}
+		if epc >= ss.PreTrainEpcs { // done with training..
+			ss.StopNow = true
+			return
+		}
+	}
+
+	ss.ApplyInputs(&ss.TrainEnv)
+	ss.AlphaCycPhase("PreTrain", true) // train
+	ss.TrialStats(true)                // accumulate
+	ss.LogTrnTrl(ss.TrnTrlLog)
+}
+
+// RunEnd is called at the end of a run -- save weights, record final log, etc here
+
+func (ss *Sim) RunEnd() {
+	ss.RunRSA()
+	ss.LogRun(ss.RunLog)
+	if ss.Acts.RSA && ss.Acts.SaveRSA {
+		fnm := ss.RSAFileName()
+		fmt.Printf("Saving RSA log to: %v\n", fnm)
+		if err := SaveCSV(ss.RSALog, fnm); err != nil {
+			log.Println(err)
+		}
+	}
+	if ss.Acts.On && ss.Acts.Format != "" {
+		fnm := ss.ActFileName()
+		fmt.Printf("Saving activity log to: %v\n", fnm)
+		if err := ss.SaveActLog(fnm); err != nil {
+			log.Println(err)
+		}
+	}
+	if ss.WtChg.On {
+		fnm := ss.WtChgFileName()
+		fmt.Printf("Saving weight change log to: %v\n", fnm)
+		if err := SaveCSV(ss.WtChgLog, fnm); err != nil {
+			log.Println(err)
+		}
+	}
+	if ss.SaveWts {
+		fnm := ss.WeightsFileName()
+		fmt.Printf("Saving Weights to: %v\n", fnm)
+		if err := ss.SaveWtsFile(fnm); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// NewRun intializes a new run of the model, using the TrainEnv.Run counter
+// for the new run value
+func (ss *Sim) NewRun() {
+	run := ss.TrainEnv.Run.Cur
+	ss.RunSeeds(run)
+	ss.SetTrainPats(ss.TrainAB, run)
+	ss.TestEnv.Init(run)
+	ss.Time.Reset()
+	ss.WithRand(ss.Rnd.Wts, ss.Net.InitWts)
+	//ss.LoadPretrainedWts()
+	ss.InitStats()
+	ss.TrnTrlLog.SetNumRows(0)
+	ss.TrnEpcLog.SetNumRows(0)
+	ss.TstEpcLog.SetNumRows(0)
+	ss.RPTrlLog.SetNumRows(0)
+	ss.ConfigActLog(ss.ActLog) // layer sizes can change with the params
+	ss.ConfigRSALog(ss.RSALog)
+	ss.ConfigCycLog(ss.CycLog)
+	ss.WtChgLog.SetNumRows(0)
+	ss.PracItems = nil
+	ss.PracSyns = nil
+	ss.Ckpts = nil
+	ss.NeedsNewRun = false
+}
+
+func (ss *Sim) LoadPretrainedWts() bool {
+	if ss.PreTrainWts == nil {
+		return false
+	}
+	b := bytes.NewReader(ss.PreTrainWts)
+	err := ss.Net.ReadWtsJSON(b)
+	if err != nil {
+		log.Println(err)
+		// } else {
+		// 	fmt.Printf("loaded pretrained wts\n")
+	}
+	return true
+}
+
+// TrainEpoch runs training trials for remainder of this epoch
+func (ss *Sim) TrainEpoch() {
+	ss.StopNow = false
+	curEpc := ss.TrainEnv.Epoch.Cur
+	for {
+		ss.TrainTrial()
+		if ss.StopNow || ss.TrainEnv.Epoch.Cur != curEpc {
+			break
+		}
+	}
+	ss.Stopped()
+}
+
+// TrainRun runs training trials for remainder of run
+func (ss *Sim) TrainRun() {
+	ss.SetEnv(false)
+	ss.StopNow = false
+	curRun := ss.TrainEnv.Run.Cur
+	for {
+		ss.TrainTrial()
+		if ss.StopNow || ss.TrainEnv.Run.Cur != curRun {
+			break
+		}
+	}
+	ss.Stopped()
+}
+
+// RPRun runs retrieval practice on TrainRP, with the feedback of RP.Feedback,
+// and with the RP.ParamSet params applied until it is done.  It returns an
+// error, without running, if either of them is unknown.
+func (ss *Sim) RPRun() error {
+	defer ss.Stopped()
+	fb, ok := Feedbacks[ss.RP.Feedback]
+	if !ok {
+		return fmt.Errorf("hipbench: unknown retrieval practice feedback: %s", ss.RP.Feedback)
+	}
+	var revert *params.Sheet
+	if ss.RP.ParamSet != "" {
+		var err error
+		revert, err = ss.ApplyParamsRevert(ss.RP.ParamSet)
+		if err != nil {
+			return fmt.Errorf("hipbench: retrieval practice ParamSet: %v", err)
+		}
+	}
+	ss.SetTrainPats(ss.TrainRP, ss.TrainEnv.Run.Cur)
+	ss.TrainEnv.Trial.Cur = -1
+	ss.StopNow = false
+	curRun := ss.TrainEnv.Run.Cur
+	for {
+		ss.RetrievalPracticeTrial(fb)
+		if ss.StopNow || ss.TrainEnv.Run.Cur != curRun {
+			break
+		}
+	}
+	if revert != nil {
+		ss.Net.ApplyParams(revert, ss.LogSetParams)
+	}
+	return nil
+}
+
+// Train runs the full training on TrainAB from this point onward
+func (ss *Sim) Train() {
+	ss.TrainTable(ss.TrainAB)
+}
+
+// TrainTable runs the full training on the given patterns from this point onward
+func (ss *Sim) TrainTable(dt *etable.Table) {
+	ss.SetTrainPats(dt, ss.TrainEnv.Run.Cur)
+	ss.TrainEnv.Trial.Cur = -1
+	ss.StopNow = false
+	for {
+		ss.TrainTrial()
+		if ss.StopNow {
+			break
+		}
+	}
+	ss.Stopped()
+}
+
+func (ss *Sim) RestudyRun() {
+	ss.SetTrainPats(ss.TrainAB, ss.TrainEnv.Run.Cur)
+	ss.TrainEnv.Trial.Cur = -1
+	ss.StopNow = false
+	for {
+		ss.RestudyTrial()
+		if ss.StopNow {
+			break
+		}
+	}
+	ss.Stopped()
+}
+
+// Stop tells the sim to stop running
+func (ss *Sim) Stop() {
+	ss.StopNow = true
+}
+
+// Stopped is called when a run method stops running -- updates the IsRunning flag and toolbar
+func (ss *Sim) Stopped() {
+	ss.IsRunning = false
+	if ss.View != nil {
+		ss.View.Stopped()
+	}
+}
+
+// SetDgCa3Off sets the DG and CA3 layers off (or on)
+func (ss *Sim) SetDgCa3Off(net *leabra.Network, off bool) {
+	ca3 := net.LayerByName("CA3").(leabra.LeabraLayer).AsLeabra()
+	dg := net.LayerByName("DG").(leabra.LeabraLayer).AsLeabra()
+	ca3.Off = off
+	dg.Off = off
+}
+
+// PreTrain runs pre-training, saves weights to PreTrainWts
+func (ss *Sim) PreTrain() {
+	//ss.SetDgCa3Off(ss.Net, true)
+	ss.SetTrainPats(ss.TrainAll, ss.TrainEnv.Run.Cur)
+	// todo: pretrain on all patterns!
+	ss.StopNow = false
+	curRun := ss.TrainEnv.Run.Cur
+	for {
+		ss.PreTrainTrial()
+		if ss.StopNow || ss.TrainEnv.Run.Cur != curRun {
+			break
+		}
+	}
+	b := &bytes.Buffer{}
+	ss.WriteCkpt(b)
+	ss.PreTrainWts = b.Bytes()
+	ss.SetTrainPats(ss.TrainAB, ss.TrainEnv.Run.Cur)
+	//ss.SetDgCa3Off(ss.Net, false)
+	ss.Stopped()
+}
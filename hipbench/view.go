@@ -0,0 +1,36 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"github.com/emer/leabra/leabra"
+)
+
+// View is the interface to the gui, which the Sim updates as it runs.
+// The gui (gui.go, plots.go) is only built with cgo and without the nogui tag,
+// so that command-line runs can be built with CGO_ENABLED=0 -- Sim.View is nil then.
+type View interface {
+	// SetNet sets the network shown in the network view, after it has been rebuilt
+	SetNet(net *leabra.Network)
+
+	// UpdateNet updates the network view with the current state
+	UpdateNet(train bool)
+
+	// UpdatePlot updates the plot of the named log (e.g., "TstTrlLog") --
+	// "RunStats" re-configures its plot for the newly computed table
+	UpdatePlot(lognm string)
+
+	// Stopped updates the toolbar after a run method stops running
+	Stopped()
+}
+
+// Action is a model-specific toolbar action.  Func runs in its own goroutine
+// and must call Sim.Stopped when done, as the Sim run methods do.
+type Action struct {
+	Label   string        `desc:"toolbar label"`
+	Icon    string        `desc:"toolbar icon"`
+	Tooltip string        `desc:"toolbar tooltip"`
+	Func    func(ss *Sim) `view:"-" desc:"what the action runs"`
+}
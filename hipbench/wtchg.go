@@ -0,0 +1,228 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////
+// Weight change log
+//
+// With WtChg.On, the weights of each of the Model.DecayPrjns are snapshot at
+// the start of each stage of a protocol that changes them, and the WtChgLog
+// accounts for their change over the stage, per projection: its mean |dWt|,
+// the number of synapses changed beyond WtChg.Thr, and the net change of the
+// synapses of the practiced items -- so that the learning of retrieval
+// practice and restudy can be attributed to the hippocampal or the cortical
+// pathway.
+
+// WtChgStages are the protocol stages (by Do) whose weight changes are
+// recorded in the WtChgLog
+var WtChgStages = map[string]bool{
+	"pretrain": true,
+	"study":    true,
+	"rp":       true,
+	"restudy":  true,
+	"delay":    true,
+}
+
+// WtChgStageName returns the name of the stage in the WtChgLog: its
+// ActStageName for study, rp and restudy, and its Do otherwise, with the
+// number of steps since the last study or practice for a delay (e.g., delay2)
+func (ss *Sim) WtChgStageName(st *Stage) string {
+	switch st.Do {
+	case "delay":
+		return fmt.Sprintf("delay%d", ss.Delay)
+	case "pretrain":
+		return st.Do
+	}
+	return st.ActStageName()
+}
+
+// PrjnPathway returns the pathway that the projection belongs to: cortex
+// for those to or from the Cortex layer, and hip for the hippocampal ones
+func PrjnPathway(pj *leabra.Prjn) string {
+	if pj.Send.Name() == "Cortex" || pj.Recv.Name() == "Cortex" {
+		return "cortex"
+	}
+	return "hip"
+}
+
+// PrjnWts returns the weights of the projection with all of its learning so
+// far: including the pending dWt of the last training trial, which WtFmDWt
+// only applies at the start of the next one.  The synapses are left as they
+// were.
+func PrjnWts(pj *leabra.Prjn) []float32 {
+	syns := append([]leabra.Synapse(nil), pj.Syns...)
+	pj.WtFmDWt()
+	wts := make([]float32, len(pj.Syns))
+	for si := range pj.Syns {
+		wts[si] = pj.Syns[si].Wt
+	}
+	copy(pj.Syns, syns)
+	return wts
+}
+
+// SnapStageWts snapshots the weights of the Model.DecayPrjns (see PrjnWts)
+// into StageWts, at the start of a stage
+func (ss *Sim) SnapStageWts() {
+	ss.StageWts = map[string][]float32{}
+	for _, nm := range ss.Model.DecayPrjns {
+		if pj := ss.PrjnByName(nm); pj != nil {
+			ss.StageWts[nm] = PrjnWts(pj)
+		}
+	}
+}
+
+// MarkPracItem adds the item of the current TrainEnv trial of retrieval
+// practice to PracItems
+func (ss *Sim) MarkPracItem() {
+	if ss.PracItems == nil {
+		ss.PracItems = map[int]bool{}
+	}
+	ss.PracItems[ss.TrainEnv.Row()] = true
+}
+
+// PracItem returns true if the current TrainEnv trial is on one of the
+// practiced items: a trial of retrieval practice (on TrainRP), or a study
+// or restudy trial (on TrainAB) of an item already run in retrieval practice
+// in the run (see PracItems) -- so that a run without retrieval practice has
+// no practiced items
+func (ss *Sim) PracItem() bool {
+	switch ss.TrainEnv.Table.Table {
+	case ss.TrainRP:
+		return true
+	case ss.TrainAB:
+		return ss.PracItems[ss.TrainEnv.Row()]
+	}
+	return false
+}
+
+// MarkPracSyns marks, in PracSyns, the synapses of the Model.DecayPrjns whose
+// sending and receiving units both have ActP above WtChg.ActThr, after a
+// training trial of a protocol stage on one of the practiced items (see
+// PracItem), if WtChg.On
+func (ss *Sim) MarkPracSyns() {
+	if !ss.WtChg.On || ss.CurStage == nil || !ss.PracItem() {
+		return
+	}
+	if ss.PracSyns == nil {
+		ss.PracSyns = map[string][]bool{}
+	}
+	thr := float32(ss.WtChg.ActThr)
+	for _, nm := range ss.Model.DecayPrjns {
+		pj := ss.PrjnByName(nm)
+		if pj == nil {
+			continue
+		}
+		ps := ss.PracSyns[nm]
+		if len(ps) != len(pj.Syns) {
+			ps = make([]bool, len(pj.Syns))
+			ss.PracSyns[nm] = ps
+		}
+		slay := pj.Send.(leabra.LeabraLayer).AsLeabra()
+		rlay := pj.Recv.(leabra.LeabraLayer).AsLeabra()
+		for si := range slay.Neurons {
+			if slay.Neurons[si].ActP <= thr {
+				continue
+			}
+			nc := int(pj.SConN[si])
+			st := int(pj.SConIdxSt[si])
+			for ci := st; ci < st+nc; ci++ {
+				if rlay.Neurons[pj.SConIdx[ci]].ActP > thr {
+					ps[ci] = true
+				}
+			}
+		}
+	}
+}
+
+// LogWtChg adds a row for each of the Model.DecayPrjns to the WtChgLog table,
+// which has the stages of the run, with the change of its weights since
+// SnapStageWts at the start of the stage
+func (ss *Sim) LogWtChg(dt *etable.Table, st *Stage) {
+	stg := ss.WtChgStageName(st)
+	thr := ss.WtChg.Thr
+	for _, nm := range ss.Model.DecayPrjns {
+		pj := ss.PrjnByName(nm)
+		wt0, ok := ss.StageWts[nm]
+		if pj == nil || !ok {
+			continue
+		}
+		wts := PrjnWts(pj)
+		if len(wts) != len(wt0) { // network rebuilt in the stage
+			continue
+		}
+		ps := ss.PracSyns[nm]
+		sumAbs, net, netPrac := 0.0, 0.0, 0.0
+		nchg, nprac := 0, 0
+		for si, wt := range wts {
+			dw := float64(wt - wt0[si])
+			sumAbs += math.Abs(dw)
+			net += dw
+			if math.Abs(dw) > thr {
+				nchg++
+			}
+			if si < len(ps) && ps[si] {
+				nprac++
+				netPrac += dw
+			}
+		}
+		mabs := math.NaN()
+		if len(wts) > 0 {
+			mabs = sumAbs / float64(len(wts))
+		}
+		row := dt.Rows
+		dt.SetNumRows(row + 1)
+		dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+		dt.SetCellString("Params", row, ss.RunName())
+		dt.SetCellString("Stage", row, stg)
+		dt.SetCellString("Prjn", row, nm)
+		dt.SetCellString("Pathway", row, PrjnPathway(pj))
+		dt.SetCellFloat("NSyns", row, float64(len(wts)))
+		dt.SetCellFloat("MeanAbsDWt", row, mabs)
+		dt.SetCellFloat("NChanged", row, float64(nchg))
+		dt.SetCellFloat("NetDWt", row, net)
+		dt.SetCellFloat("NPrac", row, float64(nprac))
+		dt.SetCellFloat("NetPracDWt", row, netPrac)
+	}
+	ss.StageWts = nil
+}
+
+// WtChgFileName returns the file that the WtChgLog of the current run is
+// saved to
+func (ss *Sim) WtChgFileName() string {
+	return ss.LogFileName(fmt.Sprintf("run%03d_wtchg", ss.TrainEnv.Run.Cur))
+}
+
+// ConfigWtChgLog configures the WtChgLog, with no rows
+func (ss *Sim) ConfigWtChgLog(dt *etable.Table) {
+	dt.SetMetaData("name", "WtChgLog")
+	dt.SetMetaData("desc", "Weight changes of each learning projection per stage")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Params", etensor.STRING, nil, nil},
+		{"Stage", etensor.STRING, nil, nil},
+		{"Prjn", etensor.STRING, nil, nil},
+		{"Pathway", etensor.STRING, nil, nil},
+		{"NSyns", etensor.INT64, nil, nil},
+		{"MeanAbsDWt", etensor.FLOAT64, nil, nil},
+		{"NChanged", etensor.INT64, nil, nil},
+		{"NetDWt", etensor.FLOAT64, nil, nil},
+		{"NPrac", etensor.INT64, nil, nil},
+		{"NetPracDWt", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hipbench
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/leabra/leabra"
+)
+
+func TestPrjnWts(t *testing.T) {
+	ss := testSim(t)
+	ss.Init()
+	ss.SetTrainPats(ss.TrainAB, 0)
+	ss.TrainEnv.Trial.Cur = -1
+	ss.TrainTrial() // leaves its dWt pending
+	pj := ss.PrjnByName("ECinToCA3")
+	syns := append([]leabra.Synapse(nil), pj.Syns...)
+	wts := PrjnWts(pj)
+	if !reflect.DeepEqual(pj.Syns, syns) {
+		t.Fatalf("PrjnWts changed the synapses")
+	}
+	ss.Net.WtFmDWt()
+	for si := range pj.Syns {
+		if wts[si] != pj.Syns[si].Wt {
+			t.Fatalf("synapse %d: PrjnWts = %g, want the Wt after WtFmDWt: %g", si, wts[si], pj.Syns[si].Wt)
+		}
+	}
+}
+
+func TestWtChgLog(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.OutDir = t.TempDir()
+	ss.WtChg.On = true
+	ss.Init()
+	if err := ss.RunProtocol(actProtocol); err != nil {
+		t.Fatal(err)
+	}
+	dt := ss.WtChgLog
+	rows := map[string]map[string]int{}
+	for row := 0; row < dt.Rows; row++ {
+		stg := dt.CellString("Stage", row)
+		if rows[stg] == nil {
+			rows[stg] = map[string]int{}
+		}
+		rows[stg][dt.CellString("Prjn", row)] = row
+	}
+	for _, stg := range []string{"study", "rp", "restudy", "delay1"} {
+		if len(rows[stg]) != len(ss.Model.DecayPrjns) {
+			t.Errorf("stage %s: %d projections, want %d", stg, len(rows[stg]), len(ss.Model.DecayPrjns))
+		}
+	}
+	if len(rows) != 4 {
+		t.Errorf("stages %v, want just study, rp, restudy and delay1", rows)
+	}
+	for pnm, want := range map[string]string{"ECinToDG": "hip", "CA3ToCA1": "hip", "InputToCortex": "cortex", "CortexToOutput": "cortex"} {
+		if pw := dt.CellString("Pathway", rows["study"][pnm]); pw != want {
+			t.Errorf("%s: Pathway = %s, want %s", pnm, pw, want)
+		}
+	}
+	row := rows["study"]["ECinToCA3"]
+	if dt.CellFloat("MeanAbsDWt", row) <= 0 || dt.CellFloat("NChanged", row) == 0 {
+		t.Errorf("no ECinToCA3 weight changes in study")
+	}
+	if np := dt.CellFloat("NPrac", row); np != 0 {
+		t.Errorf("study before rp: %g synapses of the practiced items, want none", np)
+	}
+	row = rows["rp"]["ECinToCA3"]
+	if np := dt.CellFloat("NPrac", row); np == 0 || np > dt.CellFloat("NSyns", row) {
+		t.Errorf("rp: ECinToCA3: %g synapses of the practiced items, of %g", np, dt.CellFloat("NSyns", row))
+	}
+	if len(ss.PracItems) != ss.TrainRP.Rows {
+		t.Errorf("%d practiced items, want the %d of TrainRP", len(ss.PracItems), ss.TrainRP.Rows)
+	}
+	if ss.StageWts != nil {
+		t.Errorf("StageWts left after the protocol")
+	}
+
+	fnm := ss.WtChgFileName()
+	if _, err := os.Stat(fnm); err != nil {
+		t.Fatal(err)
+	}
+	od := &etable.Table{}
+	if err := OpenCSV(od, fnm); err != nil {
+		t.Fatal(err)
+	}
+	if od.Rows != dt.Rows || od.ColIdx("NetPracDWt") < 0 {
+		t.Errorf("opened %d rows, want %d", od.Rows, dt.Rows)
+	}
+}
+
+func TestWtChgNoPrac(t *testing.T) {
+	ss := testSim(t)
+	ss.Tag = "T"
+	ss.OutDir = t.TempDir()
+	ss.WtChg.On = true
+	ss.Init()
+	pr := &Protocol{Name: "Study", Stages: []Stage{{Do: "init"}, {Do: "study"}, {Do: "restudy"}}}
+	if err := ss.RunProtocol(pr); err != nil {
+		t.Fatal(err)
+	}
+	dt := ss.WtChgLog
+	if dt.Rows == 0 {
+		t.Fatalf("no weight changes logged")
+	}
+	for row := 0; row < dt.Rows; row++ {
+		if np := dt.CellFloat("NPrac", row); np != 0 {
+			t.Errorf("%s %s: %g synapses of the practiced items, with no retrieval practice", dt.CellString("Stage", row), dt.CellString("Prjn", row), np)
+		}
+	}
+}